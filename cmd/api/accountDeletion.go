@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/FaustCelaj/GetFit.git/internal/store"
+	"github.com/gofiber/fiber/v2"
+)
+
+// accounts purged per run, the rest wait for the next tick
+const accountPurgeBatchSize = 100
+
+// cancelScheduledDeletion keeps an account that was scheduled for deletion, logging
+// back in during the grace period is how users change their mind
+func (app *application) cancelScheduledDeletion(c *fiber.Ctx, user *store.User) {
+	if user.DeletionScheduledFor == nil {
+		return
+	}
+
+	if err := app.store.Users.CancelDeletion(c.Context(), user.ID); err != nil {
+		app.logger.Errorf("Error cancelling account deletion: %v", err)
+		return
+	}
+
+	app.auditUserUpdate(c, store.AuditActionDeletionCancel, user)
+}
+
+// runAccountPurger removes accounts whose deletion grace period has ended, until ctx is cancelled
+func (app *application) runAccountPurger(ctx context.Context) {
+	ticker := time.NewTicker(app.config.account.purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		app.purgeDeletedAccounts(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (app *application) purgeDeletedAccounts(ctx context.Context) {
+	now := time.Now()
+	userIDs, err := app.store.Users.GetDueForDeletion(ctx, now, accountPurgeBatchSize)
+	if err != nil {
+		app.logger.Errorf("Error fetching accounts due for deletion: %v", err)
+		return
+	}
+
+	for _, userID := range userIDs {
+		// the media records go with the account, their files are removed after
+		media, err := app.store.Media.GetAllUserMedia(ctx, userID)
+		if err != nil {
+			app.logger.Errorf("Error fetching media of account %s: %v", userID.Hex(), err)
+			continue
+		}
+
+		if err := app.store.Users.Delete(ctx, userID, now); err != nil {
+			if errors.Is(err, store.ErrNotDueForDeletion) {
+				app.logger.Infof("Deletion of account %s was cancelled, keeping it", userID.Hex())
+				continue
+			}
+			app.logger.Errorf("Error purging account %s: %v", userID.Hex(), err)
+			continue
+		}
+
+		// the account is gone, so the entry is attributed to its owner
+		entry := &store.AuditEntry{
+			ActorID:      userID,
+			OwnerID:      userID,
+			Action:       store.AuditActionDelete,
+			ResourceType: store.AuditResourceUser,
+			ResourceID:   userID,
+		}
+		if err := app.store.Audit.Create(ctx, entry); err != nil {
+			app.logger.Errorf("Error writing audit entry for purged account %s: %v", userID.Hex(), err)
+		}
+
+		// export archives live on disk, outside the transaction
+		if err := os.RemoveAll(filepath.Join(app.config.export.dir, userID.Hex())); err != nil {
+			app.logger.Errorf("Error removing exports of purged account %s: %v", userID.Hex(), err)
+		}
+		for _, item := range media {
+			app.removeMediaBlobs(ctx, item)
+		}
+
+		app.logger.Infof("Purged account %s", userID.Hex())
+	}
+}
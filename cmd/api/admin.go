@@ -0,0 +1,404 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/FaustCelaj/GetFit.git/internal/store"
+	"github.com/go-playground/validator"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	adminDefaultPageSize = 20
+	adminMaxPageSize     = 100
+)
+
+// AdminListUsers godoc
+//
+//	@Summary		List users
+//	@Description	List and search every user account, newest first. Admin only.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			search		query		string		false	"Matches part of the username or email"
+//	@Param			role		query		string		false	"Filter by role (user, coach, admin)"
+//	@Param			disabled	query		bool		false	"Filter by disabled status"
+//	@Param			page		query		int			false	"Page number, starting at 1"
+//	@Param			limit		query		int			false	"Page size, up to 100"
+//	@Success		200			{array}		store.User	"Users and total count"
+//	@Failure		400			{object}	error		"Invalid query"
+//	@Failure		403			{object}	error		"Not an admin"
+//	@Failure		500			{object}	error		"Failed to fetch users"
+//
+//	@Security		ApiKeyAuth
+//
+//	@Router			/admin/users [get]
+func (app *application) adminListUsersHandler(c *fiber.Ctx) error {
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", adminDefaultPageSize)
+	if page < 1 || limit < 1 || limit > adminMaxPageSize {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "page must be at least 1 and limit between 1 and 100",
+		})
+	}
+
+	filter := store.UserFilter{
+		Search: c.Query("search"),
+		Role:   c.Query("role"),
+		Skip:   int64((page - 1) * limit),
+		Limit:  int64(limit),
+	}
+
+	if filter.Role != "" && !validRole(filter.Role) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid role",
+		})
+	}
+
+	if disabled := c.Query("disabled"); disabled != "" {
+		value, err := strconv.ParseBool(disabled)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "disabled must be true or false",
+			})
+		}
+		filter.Disabled = &value
+	}
+
+	users, total, err := app.store.Users.List(c.Context(), filter)
+	if err != nil {
+		app.logger.Errorf("Error listing users: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch users",
+		})
+	}
+
+	if users == nil {
+		users = []*store.User{}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"users": users,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// AdminGetUser godoc
+//
+//	@Summary		Get a user
+//	@Description	Retrieve any user's account. Admin only.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			targetUserID	path		string		true	"User ID"
+//	@Success		200				{object}	store.User	"User information"
+//	@Failure		400				{object}	error		"Invalid user ID format"
+//	@Failure		403				{object}	error		"Not an admin"
+//	@Failure		404				{object}	error		"User not found"
+//
+//	@Security		ApiKeyAuth
+//
+//	@Router			/admin/users/{targetUserID} [get]
+func (app *application) adminGetUserHandler(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(getTargetUserFromContext(c))
+}
+
+// AdminDisableUser godoc
+//
+//	@Summary		Disable a user
+//	@Description	Block a user from logging in and end all of their sessions. Admin only.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			targetUserID	path		string	true	"User ID"
+//	@Success		200				{object}	string	"User disabled"
+//	@Failure		400				{object}	error	"Admins cannot disable themselves"
+//	@Failure		403				{object}	error	"Not an admin"
+//	@Failure		404				{object}	error	"User not found"
+//	@Failure		500				{object}	error	"Failed to disable user"
+//
+//	@Security		ApiKeyAuth
+//
+//	@Router			/admin/users/{targetUserID}/disable [post]
+func (app *application) adminDisableUserHandler(c *fiber.Ctx) error {
+	targetUser := getTargetUserFromContext(c)
+
+	if targetUser.ID == getAuthUserIDFromContext(c) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "You cannot disable your own account",
+		})
+	}
+
+	if err := app.store.Users.SetDisabled(c.Context(), targetUser.ID, true); err != nil {
+		app.logger.Errorf("Error disabling user: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to disable user",
+		})
+	}
+
+	// access tokens are rejected by the auth middleware, refresh tokens have to go
+	if err := app.store.RefreshTokens.RevokeAllForUser(c.Context(), targetUser.ID); err != nil {
+		app.logger.Errorf("Error revoking sessions: %v", err)
+	}
+
+	app.auditUserUpdate(c, store.AuditActionDisable, targetUser)
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "User disabled",
+	})
+}
+
+// AdminEnableUser godoc
+//
+//	@Summary		Re-enable a user
+//	@Description	Allow a disabled user to log in again. Admin only.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			targetUserID	path		string	true	"User ID"
+//	@Success		200				{object}	string	"User enabled"
+//	@Failure		403				{object}	error	"Not an admin"
+//	@Failure		404				{object}	error	"User not found"
+//	@Failure		500				{object}	error	"Failed to enable user"
+//
+//	@Security		ApiKeyAuth
+//
+//	@Router			/admin/users/{targetUserID}/enable [post]
+func (app *application) adminEnableUserHandler(c *fiber.Ctx) error {
+	targetUser := getTargetUserFromContext(c)
+
+	if err := app.store.Users.SetDisabled(c.Context(), targetUser.ID, false); err != nil {
+		app.logger.Errorf("Error enabling user: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to enable user",
+		})
+	}
+
+	app.auditUserUpdate(c, store.AuditActionEnable, targetUser)
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "User enabled",
+	})
+}
+
+// AdminForcePasswordReset godoc
+//
+//	@Summary		Force a password reset
+//	@Description	Sign the user out everywhere, block password logins and API keys and email them a reset link. Admin only.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			targetUserID	path		string	true	"User ID"
+//	@Success		202				{object}	string	"Reset email sent"
+//	@Failure		403				{object}	error	"Not an admin"
+//	@Failure		404				{object}	error	"User not found"
+//	@Failure		500				{object}	error	"Failed to force password reset"
+//
+//	@Security		ApiKeyAuth
+//
+//	@Router			/admin/users/{targetUserID}/force-password-reset [post]
+func (app *application) adminForcePasswordResetHandler(c *fiber.Ctx) error {
+	targetUser := getTargetUserFromContext(c)
+
+	if err := app.store.Users.ForcePasswordReset(c.Context(), targetUser.ID); err != nil {
+		app.logger.Errorf("Error forcing password reset: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to force password reset",
+		})
+	}
+
+	if err := app.store.RefreshTokens.RevokeAllForUser(c.Context(), targetUser.ID); err != nil {
+		app.logger.Errorf("Error revoking sessions: %v", err)
+	}
+
+	app.auditUserUpdate(c, store.AuditActionForcePasswordReset, targetUser)
+
+	if err := app.sendPasswordReset(c, targetUser); err != nil {
+		app.logger.Errorf("Error sending password reset: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to send password reset email",
+		})
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "Password reset required, a reset link has been sent to the user",
+	})
+}
+
+type setRolePayload struct {
+	Role *string `json:"role" validate:"required"`
+}
+
+// AdminSetRole godoc
+//
+//	@Summary		Change a user's role
+//	@Description	Set the role of a user to user, coach or admin. Admin only.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			targetUserID	path		string			true	"User ID"
+//	@Param			role			body		setRolePayload	true	"New role"
+//	@Success		200				{object}	string			"Role updated"
+//	@Failure		400				{object}	error			"Invalid role"
+//	@Failure		403				{object}	error			"Not an admin"
+//	@Failure		404				{object}	error			"User not found"
+//	@Failure		500				{object}	error			"Failed to update role"
+//
+//	@Security		ApiKeyAuth
+//
+//	@Router			/admin/users/{targetUserID}/role [put]
+func (app *application) adminSetRoleHandler(c *fiber.Ctx) error {
+	targetUser := getTargetUserFromContext(c)
+
+	var payload setRolePayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request format",
+		})
+	}
+
+	if err := validate.Struct(payload); err != nil {
+		validationErrors := err.(validator.ValidationErrors)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Validation failed",
+			"details": formatValidationErrors(validationErrors),
+		})
+	}
+
+	if !validRole(*payload.Role) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid role",
+		})
+	}
+
+	// keeps at least the acting admin around
+	if targetUser.ID == getAuthUserIDFromContext(c) && *payload.Role != store.RoleAdmin {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "You cannot remove your own admin role",
+		})
+	}
+
+	if err := app.store.Users.SetRole(c.Context(), targetUser.ID, *payload.Role); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "User not found",
+			})
+		}
+		app.logger.Errorf("Error setting role: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update role",
+		})
+	}
+
+	app.auditUserUpdate(c, store.AuditActionRoleChange, targetUser)
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Role updated",
+		"role":    *payload.Role,
+	})
+}
+
+// AdminStats godoc
+//
+//	@Summary		System statistics
+//	@Description	Count users, exercises, routines and workouts across the whole system. Admin only.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	string	"Counts per collection"
+//	@Failure		403	{object}	error	"Not an admin"
+//	@Failure		500	{object}	error	"Failed to fetch statistics"
+//
+//	@Security		ApiKeyAuth
+//
+//	@Router			/admin/stats [get]
+func (app *application) adminStatsHandler(c *fiber.Ctx) error {
+	counters := []struct {
+		name  string
+		count func(c *fiber.Ctx) (int64, error)
+	}{
+		{"users", func(c *fiber.Ctx) (int64, error) { return app.store.Users.Count(c.Context()) }},
+		{"exercises", func(c *fiber.Ctx) (int64, error) { return app.store.Exercise.Count(c.Context()) }},
+		{"routines", func(c *fiber.Ctx) (int64, error) { return app.store.Routine.Count(c.Context()) }},
+		{"workouts", func(c *fiber.Ctx) (int64, error) { return app.store.WorkoutSession.Count(c.Context()) }},
+	}
+
+	stats := fiber.Map{}
+	for _, counter := range counters {
+		count, err := counter.count(c)
+		if err != nil {
+			app.logger.Errorf("Error counting %s: %v", counter.name, err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to fetch statistics",
+			})
+		}
+		stats[counter.name] = count
+	}
+
+	return c.Status(fiber.StatusOK).JSON(stats)
+}
+
+// seedAdmin makes sure the account configured through ADMIN_EMAIL is an admin. The
+// account is created with ADMIN_PASSWORD if it does not exist yet.
+func (app *application) seedAdmin(ctx context.Context) error {
+	if app.config.admin.email == "" {
+		return nil
+	}
+
+	user, err := app.store.Users.GetByEmail(ctx, app.config.admin.email)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return fmt.Errorf("failed to look up admin: %w", err)
+	}
+
+	if user != nil {
+		if !user.HasRole(store.RoleAdmin) {
+			if err := app.store.Users.SetRole(ctx, user.ID, store.RoleAdmin); err != nil {
+				return fmt.Errorf("failed to promote admin: %w", err)
+			}
+			app.logger.Infof("Promoted %s to admin", user.Email)
+		}
+		// whoever configured ADMIN_EMAIL vouches for the address
+		if !user.EmailVerified {
+			if err := app.store.Users.VerifyEmail(ctx, user.ID, user.Email); err != nil {
+				return fmt.Errorf("failed to verify admin email: %w", err)
+			}
+		}
+		return nil
+	}
+
+	if app.config.admin.password == "" {
+		app.logger.Warnf("No account exists for ADMIN_EMAIL %s and ADMIN_PASSWORD is not set, skipping admin seed", app.config.admin.email)
+		return nil
+	}
+
+	admin := &store.User{
+		Username:      "admin",
+		Email:         app.config.admin.email,
+		EmailVerified: true,
+		Role:          store.RoleAdmin,
+	}
+	if err := admin.SetPassword(app.config.admin.password); err != nil {
+		return fmt.Errorf("failed to hash admin password: %w", err)
+	}
+
+	if err := app.store.Users.Create(ctx, admin); err != nil {
+		return fmt.Errorf("failed to create admin: %w", err)
+	}
+	app.logger.Infof("Created admin account %s", admin.Email)
+
+	return nil
+}
+
+func validRole(role string) bool {
+	for _, r := range store.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/FaustCelaj/GetFit.git/internal/store"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// adminTargetUserMiddleware loads the user an admin route acts on
+func (app *application) adminTargetUserMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		targetUserID, err := primitive.ObjectIDFromHex(c.Params("targetUserID"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid user ID format",
+			})
+		}
+
+		targetUser, err := app.store.Users.GetByID(c.Context(), targetUserID)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "User not found",
+				})
+			}
+			app.logger.Errorf("Error fetching user: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to fetch user",
+			})
+		}
+
+		c.Locals("targetUser", targetUser)
+
+		return c.Next()
+	}
+}
+
+// getTargetUserFromContext retrieves the user loaded by adminTargetUserMiddleware
+func getTargetUserFromContext(c *fiber.Ctx) *store.User {
+	user, ok := c.Locals("targetUser").(*store.User)
+	if !ok {
+		return nil
+	}
+	return user
+}
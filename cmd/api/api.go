@@ -5,26 +5,112 @@ import (
 	"time"
 
 	"github.com/FaustCelaj/GetFit.git/docs" // required to generate swagger docs
+	"github.com/FaustCelaj/GetFit.git/internal/auth"
+	"github.com/FaustCelaj/GetFit.git/internal/blob"
+	"github.com/FaustCelaj/GetFit.git/internal/mailer"
 	"github.com/FaustCelaj/GetFit.git/internal/store"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
 	swagger "github.com/gofiber/swagger"
 	"go.uber.org/zap"
 )
 
 type application struct {
-	config config
-	store  store.Storage
-	logger *zap.SugaredLogger
+	config        config
+	store         store.Storage
+	logger        *zap.SugaredLogger
+	authenticator auth.Authenticator
+	totp          *auth.TOTP
+	mailer        mailer.Mailer
+	exportQueue   chan struct{}
+	blobs         blob.BlobStore
 }
 
 type config struct {
-	addr   string
-	db     dbConfig
-	env    string
-	apiURL string
+	addr        string
+	db          dbConfig
+	env         string
+	apiURL      string
+	frontendURL string
+	auth        authConfig
+	mail        mailConfig
+	rateLimit   rateLimitConfig
+	admin       adminConfig
+	account     accountConfig
+	export      exportConfig
+	dataImport  importConfig
+	media       mediaConfig
+}
+
+type exportConfig struct {
+	dir string
+	ttl time.Duration
+}
+
+type importConfig struct {
+	maxSize int // largest accepted request body, in bytes
+}
+
+type mediaConfig struct {
+	driver          string // local or s3
+	dir             string
+	s3              blob.S3Config
+	maxImageSize    int // in bytes
+	maxVideoSize    int // in bytes
+	maxPerExercise  int64
+	thumbnailSize   int    // longest side of a thumbnail, in pixels
+	catalogImageURL string // where catalog image paths are resolved from
+}
+
+type accountConfig struct {
+	deletionGrace time.Duration
+	purgeInterval time.Duration
+}
+
+type adminConfig struct {
+	email    string
+	password string
+}
+
+type rateLimitConfig struct {
+	ipMax            int
+	ipWindow         time.Duration
+	accountMax       int
+	accountWindow    time.Duration
+	lockoutThreshold int
+	lockoutBase      time.Duration
+	lockoutMax       time.Duration
+}
+
+type authConfig struct {
+	token                tokenConfig
+	mfaTokenExp          time.Duration
+	passwordResetExp     time.Duration
+	emailVerificationExp time.Duration
+}
+
+type mailConfig struct {
+	driver    string
+	fromEmail string
+	outboxDir string
+	smtp      smtpConfig
+}
+
+type smtpConfig struct {
+	host     string
+	port     int
+	username string
+	password string
+}
+
+type tokenConfig struct {
+	secret     string
+	exp        time.Duration
+	refreshExp time.Duration
+	iss        string
 }
 
 type dbConfig struct {
@@ -44,11 +130,15 @@ func (app *application) mount() *fiber.App {
 		WriteTimeout: 30 * time.Second,
 		IdleTimeout:  time.Minute,
 	})
+	// every route keeps fiber's default body limit except the upload routes
+	fiberApp.Server().HeaderReceived = app.requestBodyLimit
 
 	// Add middlewares
+	// Tags every request with an ID, echoed in the X-Request-ID header and the audit log
+	fiberApp.Use(requestid.New())
 	// Logs all requests in HTTP level vs zap logs application level
 	fiberApp.Use(logger.New(logger.Config{
-		Format: "${time} ${status} - ${method} ${path}\n",
+		Format: "${time} ${status} - ${method} ${path} ${locals:requestid}\n",
 	}))
 	fiberApp.Use(recover.New()) // Recovers from panics
 	fiberApp.Use(cors.New(cors.Config{
@@ -68,29 +158,119 @@ func (app *application) mount() *fiber.App {
 	}))
 
 	// Public Routes
-	auth := api.Group("/authentication")
+	auth := api.Group("/authentication", app.ipRateLimitMiddleware())
 	auth.Post("/register", app.registerUserHandler)
+	auth.Post("/token", app.accountRateLimitMiddleware(), app.createTokenHandler)
+	auth.Post("/token/2fa", app.createTokenTwoFactorHandler)
+	auth.Post("/refresh", app.refreshTokenHandler)
+	auth.Post("/forgot-password", app.accountRateLimitMiddleware(), app.forgotPasswordHandler)
+	auth.Post("/reset-password", app.resetPasswordHandler)
+	auth.Post("/verify-email", app.verifyEmailHandler)
+
+	// Admin Routes
+	admin := api.Group("/admin", app.AuthTokenMiddleware(), app.requireUserSessionMiddleware(), app.requireRoleMiddleware(store.RoleAdmin))
+	admin.Get("/stats", app.adminStatsHandler)
+	admin.Get("/users", app.adminListUsersHandler)
+	admin.Get("/audit", app.adminGetAuditHandler)
+
+	adminUser := admin.Group("/users/:targetUserID", app.adminTargetUserMiddleware())
+	adminUser.Get("/", app.adminGetUserHandler)
+	adminUser.Post("/disable", app.adminDisableUserHandler)
+	adminUser.Post("/enable", app.adminEnableUserHandler)
+	adminUser.Post("/force-password-reset", app.adminForcePasswordResetHandler)
+	adminUser.Put("/role", app.adminSetRoleHandler)
 
-	user := api.Group("/user")
-	user.Get("/:userID", app.getUserHandler)
+	// Exercise Catalog Routes (shared by every user)
+	catalog := api.Group("/exercises", app.AuthTokenMiddleware(), app.requireScopeMiddleware("exercises"))
+	catalog.Get("/", app.listCatalogHandler)
+	catalog.Get("/search", app.searchExercisesHandler)
+	catalog.Get("/match", app.matchExerciseHandler)
+	catalog.Get("/:exerciseID", app.getCatalogExerciseHandler)
+
+	// Protected Routes
+	user := api.Group("/user/:userID", app.AuthTokenMiddleware(), app.userContextMiddleware(), app.requireUserSessionMiddleware())
+	user.Get("/", app.getUserHandler)
 	// user.Post("/", app.createUserHandler)
-	user.Patch("/:userID", app.patchUserHandler)
-	user.Delete("/:userID", app.deleteUserHandler)
+	user.Patch("/", app.patchUserHandler)
+	user.Delete("/", app.deleteUserHandler)
+
+	userScoped := api.Group("/users/:userID", app.AuthTokenMiddleware(), app.userContextMiddleware())
+	app.mountUserScopedRoutes(userScoped)
+
+	// "/me" aliases resolve the user from the access token instead of the URL
+	me := api.Group("/me", app.AuthTokenMiddleware(), app.userContextMiddleware())
+	me.Get("/", app.requireUserSessionMiddleware(), app.getUserHandler)
+	me.Patch("/", app.requireUserSessionMiddleware(), app.patchUserHandler)
+	me.Delete("/", app.requireUserSessionMiddleware(), app.deleteUserHandler)
+	app.mountUserScopedRoutes(me)
+
+	return fiberApp
+}
 
-	userScoped := api.Group("/users/:userID", app.userContextMiddleware())
+// mountUserScopedRoutes registers every route that acts on the user resolved by userContextMiddleware
+func (app *application) mountUserScopedRoutes(userScoped fiber.Router) {
+	// Session Routes (logins that hold a refresh token)
+	sessions := userScoped.Group("/sessions", app.requireUserSessionMiddleware())
+	sessions.Get("/", app.getSessionsHandler)
+	sessions.Delete("/", app.revokeAllSessionsHandler)
+	sessions.Delete("/:sessionID", app.revokeSessionHandler)
+
+	userScoped.Post("/email/verify", app.requireUserSessionMiddleware(), app.resendEmailVerificationHandler)
+	userScoped.Post("/password", app.requireUserSessionMiddleware(), app.changePasswordHandler)
+	userScoped.Get("/audit", app.requireUserSessionMiddleware(), app.getUserAuditHandler)
+
+	// Data Export Routes
+	export := userScoped.Group("/export", app.requireUserSessionMiddleware())
+	export.Post("/", app.createExportHandler)
+
+	exportJob := export.Group("/:jobID", app.exportJobContextMiddleware())
+	exportJob.Get("/", app.getExportHandler)
+	exportJob.Get("/download", app.downloadExportHandler)
+
+	// Data Import Routes
+	userScoped.Post("/import", app.requireUserSessionMiddleware(), app.importDataHandler)
+
+	// Two-factor authentication Routes
+	twoFactor := userScoped.Group("/2fa", app.requireUserSessionMiddleware())
+	twoFactor.Post("/enroll", app.enrollTwoFactorHandler)
+	twoFactor.Post("/confirm", app.confirmTwoFactorHandler)
+	twoFactor.Post("/disable", app.disableTwoFactorHandler)
+
+	// Personal API Key Routes
+	apiKeys := userScoped.Group("/api-keys", app.requireUserSessionMiddleware())
+	apiKeys.Post("/", app.createAPIKeyHandler)
+	apiKeys.Get("/", app.getAllAPIKeysHandler)
+	apiKeys.Get("/:keyID", app.getAPIKeyHandler)
+	apiKeys.Patch("/:keyID", app.updateAPIKeyHandler)
+	apiKeys.Delete("/:keyID", app.deleteAPIKeyHandler)
 
 	// Exercise Routes
-	exercise := userScoped.Group("/exercise")
+	exercise := userScoped.Group("/exercise", app.requireVerifiedEmailMiddleware(), app.requireScopeMiddleware("exercises"))
 	exercise.Post("/", app.createExerciseHandler)
 	exercise.Get("/", app.getAllUserExerciseHandler)
+	// registered before the /:exerciseID group, whose middleware would take these paths for an ID
+	exercise.Post("/fork/:exerciseID", app.exerciseContextMiddleware(), app.forkExerciseHandler)
+	exercise.Get("/duplicates", app.findDuplicateExercisesHandler)
+	exercise.Post("/merge", app.mergeExercisesHandler)
+	exercise.Get("/merges", app.getExerciseMergesHandler)
+	exercise.Post("/merges/:mergeID/undo", app.undoExerciseMergeHandler)
 
 	exerciseWithID := exercise.Group("/:exerciseID", app.exerciseContextMiddleware())
 	exerciseWithID.Get("/", app.getExerciseByIDHandler)
-	exerciseWithID.Patch("/", app.updateExerciseHandler)
-	exerciseWithID.Delete("/", app.deleteExerciseHandler)
+	exerciseWithID.Patch("/", app.requireCustomExerciseMiddleware(), app.updateExerciseHandler)
+	exerciseWithID.Delete("/", app.requireCustomExerciseMiddleware(), app.deleteExerciseHandler)
+	exerciseWithID.Get("/dependents", app.getExerciseDependentsHandler)
+	exerciseWithID.Get("/revisions", app.getExerciseRevisionsHandler)
+	exerciseWithID.Post("/revisions/:version/restore", app.requireCustomExerciseMiddleware(), app.restoreExerciseRevisionHandler)
+
+	exerciseMedia := exerciseWithID.Group("/media")
+	exerciseMedia.Post("/", app.requireCustomExerciseMiddleware(), app.uploadExerciseMediaHandler)
+	exerciseMedia.Get("/", app.listExerciseMediaHandler)
+	exerciseMedia.Get("/:mediaID", app.mediaContextMiddleware(), app.getExerciseMediaHandler)
+	exerciseMedia.Delete("/:mediaID", app.requireCustomExerciseMiddleware(), app.mediaContextMiddleware(), app.deleteExerciseMediaHandler)
 
 	// Routine Routes
-	routine := userScoped.Group("/routine")
+	routine := userScoped.Group("/routine", app.requireVerifiedEmailMiddleware(), app.requireScopeMiddleware("routines"))
 	routine.Post("/", app.createRoutineHandler)
 	routine.Get("/", app.getAllUserRoutinesIDHandler)
 
@@ -98,6 +278,8 @@ func (app *application) mount() *fiber.App {
 	routineWithID.Get("/", app.getRoutineByIDHandler)
 	routineWithID.Patch("/", app.patchRoutineHandler)
 	routineWithID.Delete("/", app.deleteRoutineHandler)
+	routineWithID.Get("/revisions", app.getRoutineRevisionsHandler)
+	routineWithID.Post("/revisions/:version/restore", app.restoreRoutineRevisionHandler)
 
 	// Editing exercises in routines
 	routineExercise := routineWithID.Group("/exercise/:exerciseID", app.exerciseContextMiddleware())
@@ -106,7 +288,7 @@ func (app *application) mount() *fiber.App {
 	routineExercise.Delete("/", app.removeExerciseFromRoutineHandler)
 
 	// Workout Session Routes (Actual performed workouts)
-	workouts := userScoped.Group("/workout")
+	workouts := userScoped.Group("/workout", app.requireVerifiedEmailMiddleware(), app.requireScopeMiddleware("workouts"))
 	workouts.Post("/", app.createWorkoutSessionHandler)
 	workouts.Get("/", app.getAllWorkoutSessionsHandler)
 
@@ -120,8 +302,4 @@ func (app *application) mount() *fiber.App {
 
 	workoutSets := workoutSession.Group("/exercise/:exerciseID/sets", app.exerciseContextMiddleware())
 	workoutSets.Post("/", app.addSetToWorkoutHandler)
-
-	// search := api.Group("/search")
-	// search.Get("/:exerciseID", app.searchExerciseByIDHandler)
-	return fiberApp
 }
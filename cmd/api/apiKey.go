@@ -0,0 +1,405 @@
+package main
+
+import (
+	"errors"
+	"time"
+
+	"github.com/FaustCelaj/GetFit.git/internal/auth"
+	"github.com/FaustCelaj/GetFit.git/internal/store"
+	"github.com/go-playground/validator"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type createAPIKeyPayload struct {
+	Name      *string    `json:"name" validate:"required,max=50"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// CreateAPIKey godoc
+//
+//	@Summary		Create a personal API key
+//	@Description	Create a scoped API key for scripts and integrations. The key is only returned once.
+//	@Tags			api-keys
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID	path		string				true	"User ID"
+//	@Param			key		body		createAPIKeyPayload	true	"Key name, scopes and optional expiry"
+//	@Success		201		{object}	store.APIKey		"API key created, includes the plaintext key"
+//	@Failure		400		{object}	error				"Invalid request body or scopes"
+//	@Failure		500		{object}	error				"Failed to create API key"
+//
+// @Security		ApiKeyAuth
+//
+//	@Router			/users/{userID}/api-keys [post]
+func (app *application) createAPIKeyHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == primitive.NilObjectID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "userID not found in context",
+		})
+	}
+
+	var payload createAPIKeyPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	if err := validate.Struct(payload); err != nil {
+		validationErrors := err.(validator.ValidationErrors)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Validation failed",
+			"details": formatValidationErrors(validationErrors),
+		})
+	}
+
+	if invalid := invalidScopes(payload.Scopes); len(invalid) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":        "unknown scopes",
+			"details":      invalid,
+			"valid_scopes": store.APIKeyScopes,
+		})
+	}
+
+	if payload.ExpiresAt != nil && !payload.ExpiresAt.After(time.Now()) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "expires_at must be in the future",
+		})
+	}
+
+	plaintext, prefix, hash, err := auth.NewAPIKey()
+	if err != nil {
+		app.logger.Errorf("Error generating api key: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to create API key",
+		})
+	}
+
+	key := &store.APIKey{
+		UserID:    userID,
+		Name:      *payload.Name,
+		Prefix:    prefix,
+		KeyHash:   hash,
+		Scopes:    payload.Scopes,
+		ExpiresAt: payload.ExpiresAt,
+	}
+
+	if err := app.store.APIKeys.Create(c.Context(), key); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "failed to create API key",
+			"details": err.Error(),
+		})
+	}
+
+	app.recordAudit(c, store.AuditActionCreate, store.AuditResourceAPIKey, key.ID, userID, nil, key)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "API key created successfully, copy it now as it will not be shown again",
+		"key":     plaintext,
+		"api_key": key,
+	})
+}
+
+// GetAllAPIKeys godoc
+//
+//	@Summary		List personal API keys
+//	@Description	List the user's API keys. Only the key prefix is shown.
+//	@Tags			api-keys
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID	path		string			true	"User ID"
+//	@Success		200		{array}		store.APIKey	"List of API keys"
+//	@Failure		400		{object}	error			"Invalid user ID"
+//	@Failure		500		{object}	error			"Failed to fetch API keys"
+//
+// @Security		ApiKeyAuth
+//
+//	@Router			/users/{userID}/api-keys [get]
+func (app *application) getAllAPIKeysHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == primitive.NilObjectID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "userID not found in context",
+		})
+	}
+
+	keys, err := app.store.APIKeys.GetAllUserKeys(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "failed to fetch API keys",
+			"details": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":  "API keys retrieved successfully",
+		"api_keys": keys,
+	})
+}
+
+// GetAPIKey godoc
+//
+//	@Summary		Get a personal API key
+//	@Description	Retrieve a single API key's metadata
+//	@Tags			api-keys
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID	path		string			true	"User ID"
+//	@Param			keyID	path		string			true	"API key ID"
+//	@Success		200		{object}	store.APIKey	"API key"
+//	@Failure		400		{object}	error			"Invalid ID format"
+//	@Failure		404		{object}	error			"API key not found"
+//	@Failure		500		{object}	error			"Failed to fetch API key"
+//
+// @Security		ApiKeyAuth
+//
+//	@Router			/users/{userID}/api-keys/{keyID} [get]
+func (app *application) getAPIKeyHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == primitive.NilObjectID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "userID not found in context",
+		})
+	}
+
+	keyID, err := primitive.ObjectIDFromHex(c.Params("keyID"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid keyID format",
+		})
+	}
+
+	key, err := app.store.APIKeys.GetByID(c.Context(), keyID, userID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "API key not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch API key",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "API key retrieved successfully",
+		"api_key": key,
+	})
+}
+
+type updateAPIKeyPayload struct {
+	Name            *string    `json:"name" validate:"omitempty,max=50"`
+	Scopes          *[]string  `json:"scopes"`
+	ExpiresAt       *time.Time `json:"expires_at"`
+	ExpectedVersion int16      `json:"expected_version"`
+}
+
+// UpdateAPIKey godoc
+//
+//	@Summary		Update a personal API key
+//	@Description	Rename a key or change its scopes or expiry
+//	@Tags			api-keys
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID	path		string				true	"User ID"
+//	@Param			keyID	path		string				true	"API key ID"
+//	@Param			key		body		updateAPIKeyPayload	true	"Updated key information"
+//	@Success		200		{object}	string				"API key updated successfully"
+//	@Failure		400		{object}	error				"Invalid request body or scopes"
+//	@Failure		409		{object}	error				"Version conflict - record has been modified"
+//	@Failure		500		{object}	error				"Failed to update API key"
+//
+// @Security		ApiKeyAuth
+//
+//	@Router			/users/{userID}/api-keys/{keyID} [patch]
+func (app *application) updateAPIKeyHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == primitive.NilObjectID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "userID not found in context",
+		})
+	}
+
+	keyID, err := primitive.ObjectIDFromHex(c.Params("keyID"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid keyID format",
+		})
+	}
+
+	var payload updateAPIKeyPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := validate.Struct(payload); err != nil {
+		validationErrors := err.(validator.ValidationErrors)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Validation failed",
+			"details": formatValidationErrors(validationErrors),
+		})
+	}
+
+	if payload.ExpectedVersion == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Current version is required",
+		})
+	}
+
+	updates := make(map[string]interface{})
+
+	if payload.Name != nil {
+		if *payload.Name == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Name cannot be empty",
+			})
+		}
+		updates["name"] = *payload.Name
+	}
+	if payload.Scopes != nil {
+		if len(*payload.Scopes) == 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "At least one scope is required",
+			})
+		}
+		if invalid := invalidScopes(*payload.Scopes); len(invalid) > 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":        "unknown scopes",
+				"details":      invalid,
+				"valid_scopes": store.APIKeyScopes,
+			})
+		}
+		updates["scopes"] = *payload.Scopes
+	}
+	if payload.ExpiresAt != nil {
+		updates["expires_at"] = *payload.ExpiresAt
+	}
+
+	if len(updates) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "No fields to update",
+		})
+	}
+
+	before, err := app.store.APIKeys.GetByID(c.Context(), keyID, userID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "API key not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "Failed to fetch API key",
+			"details": err.Error(),
+		})
+	}
+
+	if err := app.store.APIKeys.Update(c.Context(), keyID, userID, updates, payload.ExpectedVersion); err != nil {
+		if errors.Is(err, store.ErrVersionMismatch) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "This record has been modified since you last viewed it. Please refresh and try again.",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "Failed to update API key",
+			"details": err.Error(),
+		})
+	}
+
+	after, err := app.store.APIKeys.GetByID(c.Context(), keyID, userID)
+	if err != nil {
+		app.logger.Errorf("Error fetching updated API key for audit: %v", err)
+	}
+	app.recordAudit(c, store.AuditActionUpdate, store.AuditResourceAPIKey, keyID, userID, before, after)
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "API key updated successfully",
+	})
+}
+
+// DeleteAPIKey godoc
+//
+//	@Summary		Delete a personal API key
+//	@Description	Revoke an API key so it can no longer be used
+//	@Tags			api-keys
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID	path		string	true	"User ID"
+//	@Param			keyID	path		string	true	"API key ID"
+//	@Success		200		{object}	string	"API key deleted successfully"
+//	@Failure		400		{object}	error	"Invalid ID format"
+//	@Failure		404		{object}	error	"API key not found"
+//	@Failure		500		{object}	error	"Failed to delete API key"
+//
+// @Security		ApiKeyAuth
+//
+//	@Router			/users/{userID}/api-keys/{keyID} [delete]
+func (app *application) deleteAPIKeyHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == primitive.NilObjectID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "userID not found in context",
+		})
+	}
+
+	keyID, err := primitive.ObjectIDFromHex(c.Params("keyID"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid keyID format",
+		})
+	}
+
+	before, err := app.store.APIKeys.GetByID(c.Context(), keyID, userID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "API key not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "Failed to fetch API key",
+			"details": err.Error(),
+		})
+	}
+
+	if err := app.store.APIKeys.Delete(c.Context(), keyID, userID); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "API key not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "Failed to delete API key",
+			"details": err.Error(),
+		})
+	}
+
+	app.recordAudit(c, store.AuditActionDelete, store.AuditResourceAPIKey, keyID, userID, before, nil)
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "API key deleted successfully",
+	})
+}
+
+// invalidScopes returns every scope that is not in store.APIKeyScopes
+func invalidScopes(scopes []string) []string {
+	var invalid []string
+	for _, scope := range scopes {
+		known := false
+		for _, valid := range store.APIKeyScopes {
+			if scope == valid {
+				known = true
+				break
+			}
+		}
+		if !known {
+			invalid = append(invalid, scope)
+		}
+	}
+	return invalid
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/FaustCelaj/GetFit.git/internal/store"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	auditDefaultPageSize = 50
+	auditMaxPageSize     = 200
+)
+
+// recordAudit appends an entry to the audit log for the current request. before and
+// after are the resource as it was and as it is now, either may be nil. A failed
+// write is logged and does not fail the request, the change has already been made.
+func (app *application) recordAudit(c *fiber.Ctx, action, resourceType string, resourceID, ownerID primitive.ObjectID, before, after interface{}) {
+	entry := &store.AuditEntry{
+		ActorID:      getAuthUserIDFromContext(c),
+		OwnerID:      ownerID,
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		IP:           c.IP(),
+		UserAgent:    c.Get(fiber.HeaderUserAgent),
+		RequestID:    getRequestIDFromContext(c),
+	}
+
+	// unauthenticated flows like password reset act on behalf of the owner
+	if entry.ActorID == primitive.NilObjectID {
+		entry.ActorID = ownerID
+	}
+
+	if key := getAuthAPIKeyFromContext(c); key != nil {
+		entry.APIKeyID = &key.ID
+	}
+
+	if before != nil || after != nil {
+		changes, err := store.Diff(before, after)
+		if err != nil {
+			app.logger.Errorf("Error computing audit diff: %v", err)
+		}
+		entry.Changes = changes
+	}
+
+	// the request context is recycled by fiber once the handler returns
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := app.store.Audit.Create(ctx, entry); err != nil {
+		app.logger.Errorf("Error writing audit entry %s %s %s: %v", action, resourceType, resourceID.Hex(), err)
+	}
+}
+
+// GetUserAudit godoc
+//
+//	@Summary		List account history
+//	@Description	List audit entries for changes made to the user's account and data, newest first
+//	@Tags			audit
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID			path		string				true	"User ID"
+//	@Param			action			query		string				false	"Filter by action"
+//	@Param			resource_type	query		string				false	"Filter by resource type"
+//	@Param			resource_id		query		string				false	"Filter by resource ID"
+//	@Param			from			query		string				false	"Only entries at or after this RFC 3339 time"
+//	@Param			to				query		string				false	"Only entries before this RFC 3339 time"
+//	@Param			page			query		int					false	"Page number, starting at 1"
+//	@Param			limit			query		int					false	"Page size, up to 200"
+//	@Success		200				{array}		store.AuditEntry	"Audit entries and total count"
+//	@Failure		400				{object}	error				"Invalid query"
+//	@Failure		500				{object}	error				"Failed to fetch audit log"
+//
+//	@Security		ApiKeyAuth
+//
+//	@Router			/users/{userID}/audit [get]
+func (app *application) getUserAuditHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == primitive.NilObjectID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "userID not found in context",
+		})
+	}
+
+	filter, err := parseAuditFilter(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	filter.OwnerID = &userID
+
+	return app.respondWithAuditEntries(c, filter)
+}
+
+// AdminGetAudit godoc
+//
+//	@Summary		Search the audit log
+//	@Description	List audit entries across every user, newest first. Admin only.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			owner_id		query		string				false	"Filter by the user whose data was affected"
+//	@Param			actor_id		query		string				false	"Filter by the user who made the change"
+//	@Param			action			query		string				false	"Filter by action"
+//	@Param			resource_type	query		string				false	"Filter by resource type"
+//	@Param			resource_id		query		string				false	"Filter by resource ID"
+//	@Param			from			query		string				false	"Only entries at or after this RFC 3339 time"
+//	@Param			to				query		string				false	"Only entries before this RFC 3339 time"
+//	@Param			page			query		int					false	"Page number, starting at 1"
+//	@Param			limit			query		int					false	"Page size, up to 200"
+//	@Success		200				{array}		store.AuditEntry	"Audit entries and total count"
+//	@Failure		400				{object}	error				"Invalid query"
+//	@Failure		403				{object}	error				"Not an admin"
+//	@Failure		500				{object}	error				"Failed to fetch audit log"
+//
+//	@Security		ApiKeyAuth
+//
+//	@Router			/admin/audit [get]
+func (app *application) adminGetAuditHandler(c *fiber.Ctx) error {
+	filter, err := parseAuditFilter(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if filter.OwnerID, err = parseObjectIDQuery(c, "owner_id"); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if filter.ActorID, err = parseObjectIDQuery(c, "actor_id"); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return app.respondWithAuditEntries(c, filter)
+}
+
+func (app *application) respondWithAuditEntries(c *fiber.Ctx, filter store.AuditFilter) error {
+	entries, total, err := app.store.Audit.List(c.Context(), filter)
+	if err != nil {
+		app.logger.Errorf("Error listing audit entries: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch audit log",
+		})
+	}
+
+	if entries == nil {
+		entries = []*store.AuditEntry{}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"entries": entries,
+		"total":   total,
+		"page":    filter.Skip/filter.Limit + 1,
+		"limit":   filter.Limit,
+	})
+}
+
+// parseAuditFilter reads the query parameters shared by the user and admin audit views
+func parseAuditFilter(c *fiber.Ctx) (store.AuditFilter, error) {
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", auditDefaultPageSize)
+	if page < 1 || limit < 1 || limit > auditMaxPageSize {
+		return store.AuditFilter{}, fiber.NewError(fiber.StatusBadRequest, "page must be at least 1 and limit between 1 and 200")
+	}
+
+	filter := store.AuditFilter{
+		Action:       c.Query("action"),
+		ResourceType: c.Query("resource_type"),
+		Skip:         int64((page - 1) * limit),
+		Limit:        int64(limit),
+	}
+
+	var err error
+	if filter.ResourceID, err = parseObjectIDQuery(c, "resource_id"); err != nil {
+		return store.AuditFilter{}, err
+	}
+	if filter.From, err = parseTimeQuery(c, "from"); err != nil {
+		return store.AuditFilter{}, err
+	}
+	if filter.To, err = parseTimeQuery(c, "to"); err != nil {
+		return store.AuditFilter{}, err
+	}
+
+	return filter, nil
+}
+
+func parseObjectIDQuery(c *fiber.Ctx, key string) (*primitive.ObjectID, error) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+
+	id, err := primitive.ObjectIDFromHex(value)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid "+key+" format")
+	}
+	return &id, nil
+}
+
+func parseTimeQuery(c *fiber.Ctx, key string) (*time.Time, error) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, key+" must be an RFC 3339 time")
+	}
+	return &t, nil
+}
+
+// getRequestIDFromContext retrieves the ID set by the requestid middleware
+func getRequestIDFromContext(c *fiber.Ctx) string {
+	requestID, ok := c.Locals("requestid").(string)
+	if !ok {
+		return ""
+	}
+	return requestID
+}
@@ -1,11 +1,15 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
+	"github.com/FaustCelaj/GetFit.git/internal/auth"
 	"github.com/FaustCelaj/GetFit.git/internal/store"
 	"github.com/go-playground/validator"
 	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 var validate = validator.New()
@@ -84,12 +88,281 @@ func (app *application) registerUserHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	if err := app.sendEmailVerification(c, user, user.Email); err != nil {
+		app.logger.Errorf("Error creating email verification token: %v", err)
+	}
+
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-		"message": "User registered successfully",
+		"message": "User registered successfully, check your email to verify your address",
 		"id":      user.ID.Hex(),
 	})
 }
 
+type createTokenPayload struct {
+	Email    *string `json:"email" validate:"required,email,max=50"`
+	Password *string `json:"password" validate:"required,min=8,max=72"`
+}
+
+// CreateToken godoc
+//
+//	@Summary		Log in and obtain an access token
+//	@Description	Verify a user's email and password and return a signed, expiring JWT. Users with 2FA enabled get an mfa_token to use with /authentication/token/2fa instead.
+//	@Tags			authentication
+//	@Accept			json
+//	@Produce		json
+//	@Param			credentials	body		createTokenPayload	true	"User credentials"
+//	@Success		200			{object}	map[string]string	"2FA is required, returns an mfa_token"
+//	@Success		201			{object}	map[string]string	"Access token, refresh token and their expiry"
+//	@Failure		400			{object}	error				"Invalid request body"
+//	@Failure		401			{object}	error				"Invalid email or password"
+//	@Failure		500			{object}	error				"Failed to create token"
+//	@Router			/authentication/token [post]
+func (app *application) createTokenHandler(c *fiber.Ctx) error {
+	var payload createTokenPayload
+
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request format",
+		})
+	}
+
+	if err := validate.Struct(payload); err != nil {
+		validationErrors := err.(validator.ValidationErrors)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Validation failed",
+			"details": formatValidationErrors(validationErrors),
+		})
+	}
+
+	user, err := app.store.Users.GetByEmail(c.Context(), *payload.Email)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			app.logSecurityEvent(c, store.SecurityEventLoginFailed, nil, *payload.Email, nil)
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Invalid email or password",
+			})
+		}
+		app.logger.Errorf("Error fetching user by email: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Internal server error",
+		})
+	}
+
+	if locked, err := app.lockedOut(c, user); locked {
+		return err
+	}
+
+	matches, err := user.CheckPassword(*payload.Password)
+	if err != nil {
+		app.logger.Errorf("Error checking password: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Internal server error",
+		})
+	}
+	if !matches {
+		app.recordFailedLogin(c, user, store.SecurityEventLoginFailed)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid email or password",
+		})
+	}
+
+	if user.Disabled {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Account has been disabled",
+		})
+	}
+
+	if user.PasswordResetRequired {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "A password reset is required, use the link sent to your email",
+		})
+	}
+
+	// with 2FA enabled the password only earns a short-lived token for the second step
+	if user.TOTPEnabled {
+		claims := auth.NewClaims(user.ID.Hex(), app.config.auth.mfaTokenExp)
+		claims.Purpose = auth.PurposeMFA
+
+		mfaToken, err := app.authenticator.GenerateToken(claims)
+		if err != nil {
+			app.logger.Errorf("Error generating token: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to create token",
+			})
+		}
+
+		// kept so the second step can consume it, a replayed mfa_token finds nothing
+		err = app.store.UserTokens.Create(c.Context(), &store.UserToken{
+			UserID:    user.ID,
+			TokenHash: auth.HashToken(mfaToken),
+			Purpose:   store.TokenPurposeMFA,
+			ExpiresAt: time.Unix(claims.ExpiresAt, 0),
+		})
+		if err != nil {
+			app.logger.Errorf("Error storing mfa token: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to create token",
+			})
+		}
+
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"mfa_required": true,
+			"mfa_token":    mfaToken,
+			"expires_at":   time.Unix(claims.ExpiresAt, 0).UTC(),
+		})
+	}
+
+	if err := app.store.Users.ResetFailedLogins(c.Context(), user.ID); err != nil {
+		app.logger.Errorf("Error resetting failed logins: %v", err)
+	}
+	app.cancelScheduledDeletion(c, user)
+
+	refreshPlaintext, refreshToken, err := app.startSession(c, user.ID)
+	if err != nil {
+		app.logger.Errorf("Error creating refresh token: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create token",
+		})
+	}
+
+	return app.respondWithTokens(c, fiber.StatusCreated, user.ID, refreshPlaintext, refreshToken)
+}
+
+type refreshTokenPayload struct {
+	RefreshToken *string `json:"refresh_token" validate:"required"`
+}
+
+// RefreshToken godoc
+//
+//	@Summary		Refresh an access token
+//	@Description	Exchange a refresh token for a new access token and a rotated refresh token. Replaying a rotated refresh token revokes the whole session.
+//	@Tags			authentication
+//	@Accept			json
+//	@Produce		json
+//	@Param			token	body		refreshTokenPayload	true	"Refresh token"
+//	@Success		200		{object}	map[string]string	"New access and refresh tokens"
+//	@Failure		400		{object}	error				"Invalid request body"
+//	@Failure		401		{object}	error				"Invalid, expired or revoked refresh token"
+//	@Failure		500		{object}	error				"Failed to refresh token"
+//	@Router			/authentication/refresh [post]
+func (app *application) refreshTokenHandler(c *fiber.Ctx) error {
+	var payload refreshTokenPayload
+
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request format",
+		})
+	}
+
+	if err := validate.Struct(payload); err != nil {
+		validationErrors := err.(validator.ValidationErrors)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Validation failed",
+			"details": formatValidationErrors(validationErrors),
+		})
+	}
+
+	current, err := app.store.RefreshTokens.GetByHash(c.Context(), auth.HashToken(*payload.RefreshToken))
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Invalid refresh token",
+			})
+		}
+		app.logger.Errorf("Error fetching refresh token: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Internal server error",
+		})
+	}
+
+	if current.RevokedAt != nil || !current.ExpiresAt.After(time.Now()) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Refresh token has expired or been revoked",
+		})
+	}
+
+	plaintext, hash, err := auth.NewOpaqueToken()
+	if err != nil {
+		app.logger.Errorf("Error generating refresh token: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to refresh token",
+		})
+	}
+
+	next := &store.RefreshToken{
+		TokenHash: hash,
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+		IP:        c.IP(),
+		ExpiresAt: time.Now().Add(app.config.auth.token.refreshExp),
+	}
+
+	if err := app.store.RefreshTokens.Rotate(c.Context(), current, next); err != nil {
+		if errors.Is(err, store.ErrTokenReused) {
+			// a rotated token was replayed, assume it leaked and end the whole session
+			app.logger.Warnf("Refresh token reuse detected for user %s, revoking session %s", current.UserID.Hex(), current.FamilyID.Hex())
+			app.logSecurityEvent(c, store.SecurityEventRefreshTokenReuse, &current.UserID, "", map[string]interface{}{
+				"session_id": current.FamilyID.Hex(),
+			})
+			if err := app.store.RefreshTokens.RevokeFamily(c.Context(), current.FamilyID); err != nil {
+				app.logger.Errorf("Error revoking session: %v", err)
+			}
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Refresh token has already been used",
+			})
+		}
+		app.logger.Errorf("Error rotating refresh token: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to refresh token",
+		})
+	}
+
+	return app.respondWithTokens(c, fiber.StatusOK, current.UserID, plaintext, next)
+}
+
+// startSession creates the first refresh token of a new session and returns its plaintext value
+func (app *application) startSession(c *fiber.Ctx, userID primitive.ObjectID) (string, *store.RefreshToken, error) {
+	plaintext, hash, err := auth.NewOpaqueToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	refreshToken := &store.RefreshToken{
+		UserID:    userID,
+		TokenHash: hash,
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+		IP:        c.IP(),
+		ExpiresAt: time.Now().Add(app.config.auth.token.refreshExp),
+	}
+
+	if err := app.store.RefreshTokens.Create(c.Context(), refreshToken); err != nil {
+		return "", nil, err
+	}
+
+	return plaintext, refreshToken, nil
+}
+
+// respondWithTokens signs an access token bound to the refresh token's session and writes both to the response
+func (app *application) respondWithTokens(c *fiber.Ctx, status int, userID primitive.ObjectID, refreshPlaintext string, refreshToken *store.RefreshToken) error {
+	claims := auth.NewClaims(userID.Hex(), app.config.auth.token.exp)
+	claims.SessionID = refreshToken.FamilyID.Hex()
+
+	token, err := app.authenticator.GenerateToken(claims)
+	if err != nil {
+		app.logger.Errorf("Error generating token: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create token",
+		})
+	}
+
+	return c.Status(status).JSON(fiber.Map{
+		"token":              token,
+		"token_type":         "Bearer",
+		"expires_at":         time.Unix(claims.ExpiresAt, 0).UTC(),
+		"refresh_token":      refreshPlaintext,
+		"refresh_expires_at": refreshToken.ExpiresAt.UTC(),
+	})
+}
+
 // helper function for error validation
 func formatValidationErrors(errors validator.ValidationErrors) map[string]string {
 	formattedErrors := make(map[string]string)
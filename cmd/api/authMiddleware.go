@@ -0,0 +1,292 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/FaustCelaj/GetFit.git/internal/auth"
+	"github.com/FaustCelaj/GetFit.git/internal/store"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// AuthTokenMiddleware validates the bearer token or personal API key from the
+// Authorization header and stores the authenticated user in the request context
+func (app *application) AuthTokenMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		authHeader := c.Get(fiber.HeaderAuthorization)
+		if authHeader == "" {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Authorization header is missing",
+			})
+		}
+
+		// personal API keys may be sent bare or with the Bearer scheme
+		credential := strings.TrimSpace(authHeader)
+		if parts := strings.SplitN(credential, " ", 2); len(parts) == 2 && strings.EqualFold(parts[0], "Bearer") {
+			credential = strings.TrimSpace(parts[1])
+		}
+		if strings.HasPrefix(credential, auth.APIKeyPrefix) {
+			return app.authenticateAPIKey(c, credential)
+		}
+
+		parts := strings.SplitN(authHeader, " ", 2)
+		if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Authorization header is malformed",
+			})
+		}
+
+		claims, err := app.authenticator.ValidateToken(strings.TrimSpace(parts[1]))
+		if err != nil {
+			message := "Invalid token"
+			if err == auth.ErrExpiredToken {
+				message = "Token has expired"
+			}
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": message,
+			})
+		}
+
+		// only access tokens are accepted here, not the intermediate 2FA login token
+		if claims.Purpose != "" {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Invalid token",
+			})
+		}
+
+		authUserID, err := primitive.ObjectIDFromHex(claims.Subject)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Invalid token",
+			})
+		}
+
+		user, err := app.store.Users.GetByID(c.Context(), authUserID)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+					"error": "Invalid token",
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to fetch user",
+			})
+		}
+
+		if user.Disabled {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Account has been disabled",
+			})
+		}
+
+		if user.DeletionScheduledFor != nil {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Account is scheduled for deletion, log in again to cancel",
+			})
+		}
+
+		// tokens issued before the last password change are no longer valid
+		if user.PasswordChangedAt != nil && claims.IssuedAtOrBefore(*user.PasswordChangedAt) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Token has been revoked",
+			})
+		}
+
+		// a revoked session loses its access tokens too, not just its refresh token
+		if claims.SessionID != "" {
+			sessionID, err := primitive.ObjectIDFromHex(claims.SessionID)
+			if err != nil {
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+					"error": "Invalid token",
+				})
+			}
+			revoked, err := app.store.RefreshTokens.IsSessionRevoked(c.Context(), sessionID)
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": "Failed to fetch session",
+				})
+			}
+			if revoked {
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+					"error": "Token has been revoked",
+				})
+			}
+		}
+
+		c.Locals("authUser", user)
+		c.Locals("authUserID", authUserID)
+		c.Locals("authSessionID", claims.SessionID)
+
+		return c.Next()
+	}
+}
+
+// authenticateAPIKey resolves a personal API key to its owner. What the key may do
+// is enforced per route group by requireScopeMiddleware.
+func (app *application) authenticateAPIKey(c *fiber.Ctx, plaintext string) error {
+	key, err := app.store.APIKeys.GetByHash(c.Context(), auth.HashToken(plaintext))
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Invalid API key",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch API key",
+		})
+	}
+
+	if key.Expired() {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "API key has expired",
+		})
+	}
+
+	user, err := app.store.Users.GetByID(c.Context(), key.UserID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Invalid API key",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch user",
+		})
+	}
+
+	if user.Disabled {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Account has been disabled",
+		})
+	}
+
+	if user.DeletionScheduledFor != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Account is scheduled for deletion, log in again to cancel",
+		})
+	}
+
+	if user.PasswordResetRequired {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Password reset required, check your email for a reset link",
+		})
+	}
+
+	// keys created before the last password change are no longer valid
+	if user.PasswordChangedAt != nil && key.CreatedAt.Before(*user.PasswordChangedAt) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "API key has been revoked",
+		})
+	}
+
+	// last_used_at only needs to be roughly right, so avoid a write on every request
+	if key.LastUsedAt == nil || time.Since(*key.LastUsedAt) > time.Minute {
+		if err := app.store.APIKeys.TouchLastUsed(c.Context(), key.ID); err != nil {
+			app.logger.Errorf("Error updating api key last used: %v", err)
+		}
+	}
+
+	c.Locals("authUser", user)
+	c.Locals("authUserID", user.ID)
+	c.Locals("authAPIKey", key)
+
+	return c.Next()
+}
+
+// requireScopeMiddleware lets API keys through only if they hold the read scope
+// of the resource for GET requests and the write scope for everything else.
+// Requests authenticated with an access token are not restricted.
+func (app *application) requireScopeMiddleware(resource string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := getAuthAPIKeyFromContext(c)
+		if key == nil {
+			return c.Next()
+		}
+
+		scope := resource + ":write"
+		if c.Method() == fiber.MethodGet || c.Method() == fiber.MethodHead {
+			scope = resource + ":read"
+		}
+
+		if !key.HasScope(scope) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "API key is missing the " + scope + " scope",
+			})
+		}
+
+		return c.Next()
+	}
+}
+
+// requireUserSessionMiddleware rejects API keys on account management routes,
+// these need a logged in user
+func (app *application) requireUserSessionMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if getAuthAPIKeyFromContext(c) != nil {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "API keys cannot be used for this route",
+			})
+		}
+
+		return c.Next()
+	}
+}
+
+// requireRoleMiddleware only lets through users holding one of the given roles
+func (app *application) requireRoleMiddleware(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user := getAuthUserFromContext(c)
+		if user == nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Authentication required",
+			})
+		}
+
+		if !user.HasRole(roles...) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "You do not have permission to access this resource",
+			})
+		}
+
+		return c.Next()
+	}
+}
+
+// getAuthAPIKeyFromContext retrieves the API key the request was authenticated with, if any
+func getAuthAPIKeyFromContext(c *fiber.Ctx) *store.APIKey {
+	key, ok := c.Locals("authAPIKey").(*store.APIKey)
+	if !ok {
+		return nil
+	}
+	return key
+}
+
+// getAuthUserFromContext retrieves the authenticated user from the request context
+func getAuthUserFromContext(c *fiber.Ctx) *store.User {
+	user, ok := c.Locals("authUser").(*store.User)
+	if !ok {
+		return nil
+	}
+	return user
+}
+
+// getAuthUserIDFromContext retrieves the authenticated user's ID from the request context
+func getAuthUserIDFromContext(c *fiber.Ctx) primitive.ObjectID {
+	authUserID, ok := c.Locals("authUserID").(primitive.ObjectID)
+	if !ok {
+		return primitive.NilObjectID
+	}
+	return authUserID
+}
+
+// getAuthSessionIDFromContext retrieves the session the access token was issued for
+func getAuthSessionIDFromContext(c *fiber.Ctx) string {
+	sessionID, ok := c.Locals("authSessionID").(string)
+	if !ok {
+		return ""
+	}
+	return sessionID
+}
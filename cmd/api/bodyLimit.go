@@ -0,0 +1,42 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/valyala/fasthttp"
+)
+
+// room for the multipart framing around an uploaded media file
+const mediaUploadOverhead = 1 << 20
+
+// requestBodyLimit raises the body limit for the routes that take uploads, the import
+// bundle and exercise media, and leaves every other request on the server's default.
+// fasthttp calls it once the headers are in, before it reads the body, so an oversized
+// body on any other route is refused without being buffered.
+func (app *application) requestBodyLimit(header *fasthttp.RequestHeader) fasthttp.RequestConfig {
+	if !header.IsPost() {
+		return fasthttp.RequestConfig{}
+	}
+
+	path, _, _ := strings.Cut(string(header.RequestURI()), "?")
+	segments := strings.Split(strings.Trim(strings.ToLower(path), "/"), "/")
+
+	// the user is either /users/:userID or the /me alias
+	switch {
+	case len(segments) > 3 && segments[0] == "api" && segments[1] == "v1" && segments[2] == "users":
+		segments = segments[4:]
+	case len(segments) > 2 && segments[0] == "api" && segments[1] == "v1" && segments[2] == "me":
+		segments = segments[3:]
+	default:
+		return fasthttp.RequestConfig{}
+	}
+
+	switch {
+	case len(segments) == 1 && segments[0] == "import":
+		return fasthttp.RequestConfig{MaxRequestBodySize: app.config.dataImport.maxSize}
+	case len(segments) == 3 && segments[0] == "exercise" && segments[2] == "media":
+		return fasthttp.RequestConfig{MaxRequestBodySize: max(app.config.media.maxImageSize, app.config.media.maxVideoSize) + mediaUploadOverhead}
+	}
+
+	return fasthttp.RequestConfig{}
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/FaustCelaj/GetFit.git/internal/fuzzy"
+	"github.com/FaustCelaj/GetFit.git/internal/store"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	searchDefaultPageSize = 50
+	searchMaxPageSize     = 200
+)
+
+// ListCatalog godoc
+//
+//	@Summary		List catalog exercises
+//	@Description	List the exercise catalog shared by every user, sorted by name. Catalog exercises can be used in routines and workouts like custom ones.
+//	@Tags			exercises
+//	@Accept			json
+//	@Produce		json
+//	@Param			category	query		string			false	"Filter by category"
+//	@Param			level		query		string			false	"Filter by level"
+//	@Param			equipment	query		string			false	"Filter by equipment"
+//	@Param			muscle		query		string			false	"Filter by primary muscle"
+//	@Param			cursor		query		string			false	"next_cursor of the previous page"
+//	@Param			limit		query		int				false	"Page size, up to 200"
+//	@Param			sort		query		string			false	"name (default), category, created_at or updated_at, prefix with - for descending"
+//	@Param			fields		query		string			false	"Comma separated fields to return"
+//	@Success		200			{array}		store.Exercise	"A page of catalog exercises, next_cursor and has_more"
+//	@Failure		400			{object}	error			"Invalid query"
+//	@Failure		500			{object}	error			"Failed to fetch exercises"
+//
+//	@Security		ApiKeyAuth
+//
+//	@Router			/exercises [get]
+func (app *application) listCatalogHandler(c *fiber.Ctx) error {
+	filter := store.CatalogFilter{
+		Category:  c.Query("category"),
+		Level:     c.Query("level"),
+		Equipment: c.Query("equipment"),
+		Muscle:    c.Query("muscle"),
+	}
+
+	query := parseListQuery(c)
+	page, err := app.store.Exercise.GetCatalog(c.Context(), filter, query)
+	if err != nil {
+		if errors.Is(err, store.ErrInvalidListQuery) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		app.logger.Errorf("Error listing catalog exercises: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch exercises",
+		})
+	}
+
+	return respondWithPage(c, "exercises", page, query)
+}
+
+// SearchExercises godoc
+//
+//	@Summary		Search exercises
+//	@Description	Search the catalog and the user's custom exercises by name and instructions, with filters. Every filter has facet counts, computed with the other filters applied.
+//	@Tags			exercises
+//	@Accept			json
+//	@Produce		json
+//	@Param			q					query		string						false	"Text to search for"
+//	@Param			primary_muscle		query		string						false	"Filter by primary muscle"
+//	@Param			secondary_muscle	query		string						false	"Filter by secondary muscle"
+//	@Param			equipment			query		string						false	"Filter by equipment"
+//	@Param			level				query		string						false	"Filter by level"
+//	@Param			force				query		string						false	"Filter by force"
+//	@Param			mechanic			query		string						false	"Filter by mechanic"
+//	@Param			category			query		string						false	"Filter by category"
+//	@Param			page				query		int							false	"Page number, starting at 1"
+//	@Param			limit				query		int							false	"Page size, up to 200"
+//	@Success		200					{object}	store.ExerciseSearchResult	"Matching exercises, total count and facets"
+//	@Failure		400					{object}	error						"Invalid query"
+//	@Failure		500					{object}	error						"Failed to search exercises"
+//
+//	@Security		ApiKeyAuth
+//
+//	@Router			/exercises/search [get]
+func (app *application) searchExercisesHandler(c *fiber.Ctx) error {
+	// results ranked by relevance have no stable key to continue from, so search pages by number
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", searchDefaultPageSize)
+	if page < 1 || limit < 1 || limit > searchMaxPageSize {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "page must be at least 1 and limit between 1 and 200",
+		})
+	}
+
+	search := store.ExerciseSearch{
+		UserID:  getAuthUserIDFromContext(c),
+		Query:   strings.TrimSpace(c.Query("q")),
+		Filters: make(map[string]string),
+		Skip:    int64((page - 1) * limit),
+		Limit:   int64(limit),
+	}
+	for name := range store.ExerciseFacets {
+		if value := c.Query(name); value != "" {
+			search.Filters[name] = value
+		}
+	}
+
+	result, err := app.store.Exercise.Search(c.Context(), search)
+	if err != nil {
+		app.logger.Errorf("Error searching exercises: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to search exercises",
+		})
+	}
+
+	// misspelled names find nothing in the text index, retry with the closest names
+	if result.Total == 0 && search.Query != "" {
+		matcher, err := app.exerciseMatcher(c, search.UserID)
+		if err != nil {
+			app.logger.Errorf("Error loading exercise names: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to search exercises",
+			})
+		}
+
+		for _, match := range matcher.Match(search.Query, searchMaxPageSize, fuzzy.DefaultThreshold) {
+			if id, err := primitive.ObjectIDFromHex(match.ID); err == nil {
+				search.IDs = append(search.IDs, id)
+			}
+		}
+
+		if len(search.IDs) > 0 {
+			if result, err = app.store.Exercise.Search(c.Context(), search); err != nil {
+				app.logger.Errorf("Error searching exercises: %v", err)
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": "Failed to search exercises",
+				})
+			}
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"exercises": result.Exercises,
+		"total":     result.Total,
+		"facets":    result.Facets,
+		"page":      page,
+		"limit":     limit,
+	})
+}
+
+// MatchExercise godoc
+//
+//	@Summary		Match an exercise name
+//	@Description	Resolve a free-text name like "RDL", "bench" or a misspelling to catalog and custom exercises, best first, each with a confidence score from 0 to 1
+//	@Tags			exercises
+//	@Accept			json
+//	@Produce		json
+//	@Param			q		query		string		true	"Name to resolve"
+//	@Param			limit	query		int			false	"Number of matches, up to 20"
+//	@Success		200		{array}		fuzzy.Match	"Matching exercises"
+//	@Failure		400		{object}	error		"Missing name"
+//	@Failure		500		{object}	error		"Failed to match exercise"
+//
+//	@Security		ApiKeyAuth
+//
+//	@Router			/exercises/match [get]
+func (app *application) matchExerciseHandler(c *fiber.Ctx) error {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "q is required",
+		})
+	}
+
+	limit := c.QueryInt("limit", 5)
+	if limit < 1 || limit > 20 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "limit must be between 1 and 20",
+		})
+	}
+
+	matcher, err := app.exerciseMatcher(c, getAuthUserIDFromContext(c))
+	if err != nil {
+		app.logger.Errorf("Error loading exercise names: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to match exercise",
+		})
+	}
+
+	matches := matcher.Match(query, limit, fuzzy.DefaultThreshold)
+	if matches == nil {
+		matches = []fuzzy.Match{}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"matches": matches,
+	})
+}
+
+// exerciseMatcher prepares the names and aliases of every exercise the user can see
+func (app *application) exerciseMatcher(c *fiber.Ctx, userID primitive.ObjectID) (*fuzzy.Matcher, error) {
+	exercises, err := app.store.Exercise.GetMatchCandidates(c.Context(), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]fuzzy.Candidate, 0, len(exercises))
+	for _, exercise := range exercises {
+		candidates = append(candidates, fuzzy.Candidate{
+			ID:      exercise.ID.Hex(),
+			Name:    exercise.Name,
+			Aliases: exercise.Aliases,
+		})
+	}
+
+	return fuzzy.NewMatcher(candidates), nil
+}
+
+// GetCatalogExercise godoc
+//
+//	@Summary		Get a catalog exercise
+//	@Description	Retrieve one exercise from the shared catalog
+//	@Tags			exercises
+//	@Accept			json
+//	@Produce		json
+//	@Param			exerciseID	path		string			true	"Exercise ID"
+//	@Success		200			{object}	store.Exercise	"Exercise information"
+//	@Failure		400			{object}	error			"Invalid exercise ID format"
+//	@Failure		404			{object}	error			"Exercise not found"
+//	@Failure		500			{object}	error			"Failed to fetch exercise"
+//
+//	@Security		ApiKeyAuth
+//
+//	@Router			/exercises/{exerciseID} [get]
+func (app *application) getCatalogExerciseHandler(c *fiber.Ctx) error {
+	exerciseID, err := primitive.ObjectIDFromHex(c.Params("exerciseID"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid exerciseID format",
+		})
+	}
+
+	exercise, err := app.store.Exercise.SearchExerciseByID(c.Context(), exerciseID)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		app.logger.Errorf("Error fetching catalog exercise: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch exercise",
+		})
+	}
+
+	// custom exercises are only reachable through their owner's routes
+	if err != nil || exercise.IsCustom {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Exercise not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"exercise": exercise,
+	})
+}
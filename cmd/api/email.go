@@ -0,0 +1,178 @@
+package main
+
+import (
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/FaustCelaj/GetFit.git/internal/auth"
+	"github.com/FaustCelaj/GetFit.git/internal/mailer"
+	"github.com/FaustCelaj/GetFit.git/internal/store"
+	"github.com/go-playground/validator"
+	"github.com/gofiber/fiber/v2"
+)
+
+type verifyEmailPayload struct {
+	Token *string `json:"token" validate:"required"`
+}
+
+// VerifyEmail godoc
+//
+//	@Summary		Verify an email address
+//	@Description	Consume an email verification token. Confirms a new account's address or applies a pending email change.
+//	@Tags			authentication
+//	@Accept			json
+//	@Produce		json
+//	@Param			token	body		verifyEmailPayload	true	"Verification token"
+//	@Success		200		{object}	string				"Email verified successfully"
+//	@Failure		400		{object}	error				"Invalid request body or invalid token"
+//	@Failure		409		{object}	error				"Email is already in use"
+//	@Failure		500		{object}	error				"Failed to verify email"
+//	@Router			/authentication/verify-email [post]
+func (app *application) verifyEmailHandler(c *fiber.Ctx) error {
+	var payload verifyEmailPayload
+
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request format",
+		})
+	}
+
+	if err := validate.Struct(payload); err != nil {
+		validationErrors := err.(validator.ValidationErrors)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Validation failed",
+			"details": formatValidationErrors(validationErrors),
+		})
+	}
+
+	token, err := app.store.UserTokens.Consume(c.Context(), auth.HashToken(*payload.Token), store.TokenPurposeEmailVerification)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Verification link is invalid or has expired",
+			})
+		}
+		app.logger.Errorf("Error consuming email verification token: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Internal server error",
+		})
+	}
+
+	// the address may have been claimed by another account while the change was pending
+	owner, err := app.store.Users.GetByEmail(c.Context(), token.Email)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		app.logger.Errorf("Error fetching user by email: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Internal server error",
+		})
+	}
+	if owner != nil && owner.ID != token.UserID {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "User with this email already exists",
+		})
+	}
+
+	if err := app.store.Users.VerifyEmail(c.Context(), token.UserID, token.Email); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Verification link no longer matches this account",
+			})
+		}
+		app.logger.Errorf("Error verifying email: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to verify email",
+		})
+	}
+
+	if err := app.store.UserTokens.DeleteAllForUser(c.Context(), token.UserID, store.TokenPurposeEmailVerification); err != nil {
+		app.logger.Errorf("Error deleting email verification tokens: %v", err)
+	}
+
+	app.recordAudit(c, store.AuditActionEmailChange, store.AuditResourceUser, token.UserID, token.UserID, nil, fiber.Map{
+		"email": token.Email,
+	})
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Email verified successfully",
+		"email":   token.Email,
+	})
+}
+
+// ResendEmailVerification godoc
+//
+//	@Summary		Resend the verification email
+//	@Description	Send a new verification link to the pending email address, or to the current one if it is not verified yet
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID	path		string	true	"User ID"
+//	@Success		202		{object}	string	"Verification email sent"
+//	@Failure		400		{object}	error	"Email is already verified"
+//	@Failure		500		{object}	error	"Failed to send verification email"
+//
+// @Security		ApiKeyAuth
+//
+//	@Router			/users/{userID}/email/verify [post]
+func (app *application) resendEmailVerificationHandler(c *fiber.Ctx) error {
+	user := getUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "user not found in context",
+		})
+	}
+
+	email := user.Email
+	if user.PendingEmail != nil {
+		email = *user.PendingEmail
+	} else if user.EmailVerified {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Email is already verified",
+		})
+	}
+
+	if err := app.sendEmailVerification(c, user, email); err != nil {
+		app.logger.Errorf("Error creating email verification token: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to send verification email",
+		})
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "Verification email sent",
+	})
+}
+
+// sendEmailVerification replaces any outstanding verification token for the user and
+// emails a link confirming the given address
+func (app *application) sendEmailVerification(c *fiber.Ctx, user *store.User, email string) error {
+	plaintext, hash, err := auth.NewOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	if err := app.store.UserTokens.DeleteAllForUser(c.Context(), user.ID, store.TokenPurposeEmailVerification); err != nil {
+		return err
+	}
+
+	token := &store.UserToken{
+		UserID:    user.ID,
+		TokenHash: hash,
+		Purpose:   store.TokenPurposeEmailVerification,
+		Email:     email,
+		ExpiresAt: time.Now().Add(app.config.auth.emailVerificationExp),
+	}
+
+	if err := app.store.UserTokens.Create(c.Context(), token); err != nil {
+		return err
+	}
+
+	app.sendEmail(email, mailer.EmailVerificationSubject, mailer.EmailVerificationTemplate, map[string]string{
+		"Username":  user.Username,
+		"Email":     email,
+		"Link":      app.config.frontendURL + "/verify-email?token=" + url.QueryEscape(plaintext),
+		"ExpiresIn": humanizeDuration(app.config.auth.emailVerificationExp),
+	})
+
+	return nil
+}
@@ -1,6 +1,12 @@
 package main
 
 import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/FaustCelaj/GetFit.git/internal/fuzzy"
 	"github.com/FaustCelaj/GetFit.git/internal/store"
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -49,6 +55,15 @@ func (app *application) createExerciseHandler(c *fiber.Ctx) error {
 			"error": "exercise category is required",
 		})
 	}
+	exercise.Aliases = cleanAliases(exercise.Aliases)
+	if exercise.TrackingType == "" {
+		exercise.TrackingType = store.DefaultTrackingType
+	}
+	if !exercise.TrackingType.Valid() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": trackingTypeError(),
+		})
+	}
 
 	if exercise.Force == nil {
 		exercise.Force = nil
@@ -72,8 +87,6 @@ func (app *application) createExerciseHandler(c *fiber.Ctx) error {
 		exercise.Instructions = &[]string{}
 	}
 
-	exercise.UserID = userID
-
 	if err := app.store.Exercise.Create(c.Context(), &exercise, userID); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"details": err.Error(),
@@ -88,6 +101,101 @@ func (app *application) createExerciseHandler(c *fiber.Ctx) error {
 	})
 }
 
+type forkExercisePayload struct {
+	Rewrite bool `json:"rewrite"`
+}
+
+// ForkExercise godoc
+//
+//	@Summary		Fork a catalog exercise
+//	@Description	Copy a catalog exercise into the user's custom exercises so it can be edited. The copy keeps a forked_from reference to the original. With rewrite, the user's routines and in progress workouts that use the original are pointed at the copy, completed workouts keep their history.
+//	@Tags			exercises
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID		path		string				true	"User ID"
+//	@Param			exerciseID	path		string				true	"Catalog exercise ID"
+//	@Param			payload		body		forkExercisePayload	false	"Whether to rewrite routines and in progress workouts"
+//	@Success		201			{object}	store.Exercise		"Exercise forked successfully"
+//	@Failure		400			{object}	error				"Invalid request body or not a catalog exercise"
+//	@Failure		404			{object}	error				"Exercise not found"
+//	@Failure		500			{object}	error				"Failed to fork exercise"
+//
+// @Security		ApiKeyAuth
+//
+//	@Router			/users/{userID}/exercise/fork/{exerciseID} [post]
+func (app *application) forkExerciseHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == primitive.NilObjectID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "userID not found in context",
+		})
+	}
+
+	original := getExerciseFromContext(c)
+	if original == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "exercise not found in context",
+		})
+	}
+	if original.IsCustom {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Only catalog exercises can be forked",
+		})
+	}
+
+	var payload forkExercisePayload
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&payload); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"details": err.Error(),
+				"error":   "invalid request body",
+			})
+		}
+	}
+
+	fork, result, err := app.store.Exercise.Fork(c.Context(), original, userID, payload.Rewrite)
+	if err != nil {
+		app.logger.Errorf("Error forking exercise: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fork exercise",
+		})
+	}
+
+	app.recordAudit(c, store.AuditActionCreate, store.AuditResourceExercise, fork.ID, userID, nil, fork)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"id":               fork.ID.Hex(),
+		"message":          "exercise forked successfully",
+		"exercise":         fork,
+		"routines_updated": result.RoutinesUpdated,
+		"workouts_updated": result.WorkoutsUpdated,
+	})
+}
+
+func trackingTypeError() string {
+	names := make([]string, len(store.TrackingTypes))
+	for i, t := range store.TrackingTypes {
+		names[i] = string(t)
+	}
+	return "tracking_type must be one of " + strings.Join(names, ", ")
+}
+
+// cleanAliases trims aliases and drops empty ones and repeats
+func cleanAliases(aliases []string) []string {
+	cleaned := make([]string, 0, len(aliases))
+	seen := make(map[string]bool, len(aliases))
+	for _, alias := range aliases {
+		alias = strings.TrimSpace(alias)
+		key := fuzzy.Normalize(alias)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		cleaned = append(cleaned, alias)
+	}
+	return cleaned
+}
+
 // GetAllUserExercises godoc
 //
 //	@Summary		Get all exercises for a user
@@ -95,10 +203,15 @@ func (app *application) createExerciseHandler(c *fiber.Ctx) error {
 //	@Tags			exercises
 //	@Accept			json
 //	@Produce		json
-//	@Param			userID	path		string			true	"User ID"
-//	@Success		200		{array}		store.Exercise	"List of exercises"
-//	@Failure		400		{object}	error			"Invalid user ID"
-//	@Failure		500		{object}	error			"Failed to fetch exercises"
+//	@Param			userID		path		string			true	"User ID"
+//	@Param			cursor		query		string			false	"next_cursor of the previous page"
+//	@Param			limit		query		int				false	"Page size, up to 200"
+//	@Param			sort		query		string			false	"name (default), category, created_at or updated_at, prefix with - for descending"
+//	@Param			fields		query		string			false	"Comma separated fields to return"
+//	@Param			archived	query		bool			false	"List archived exercises instead"
+//	@Success		200			{array}		store.Exercise	"A page of exercises, next_cursor and has_more"
+//	@Failure		400			{object}	error			"Invalid user ID or list query"
+//	@Failure		500			{object}	error			"Failed to fetch exercises"
 //
 // @Security		ApiKeyAuth
 //
@@ -111,18 +224,21 @@ func (app *application) getAllUserExerciseHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	exercises, err := app.store.Exercise.GetAllUserExercises(c.Context(), userID)
+	query := parseListQuery(c)
+	page, err := app.store.Exercise.List(c.Context(), userID, c.QueryBool("archived", false), query)
 	if err != nil {
+		if errors.Is(err, store.ErrInvalidListQuery) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error":   "failed to fetch exercises",
 			"details": err.Error(),
 		})
 	}
 
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"message":   "exercises retrieved successfully",
-		"exercises": exercises,
-	})
+	return respondWithPage(c, "exercises", page, query)
 }
 
 // GetExerciseByID godoc
@@ -182,6 +298,9 @@ type updateExercisePayload struct {
 	SecondaryMuscles *[]string `json:"secondaryMuscles"`
 	Instructions     *[]string `json:"instructions"`
 	Category         *string   `json:"category"`
+	Aliases          *[]string `json:"aliases"`
+	TrackingType     *string   `json:"tracking_type"`
+	Archived         *bool     `json:"archived"` // false brings back an archived exercise
 	ExpectedVersion  int16     `json:"expected_version"`
 }
 
@@ -236,6 +355,46 @@ func (app *application) updateExerciseHandler(c *fiber.Ctx) error {
 	if payload.Category != nil {
 		updates["category"] = &payload.Category
 	}
+	if payload.Force != nil {
+		updates["force"] = *payload.Force
+	}
+	if payload.Level != nil {
+		updates["level"] = *payload.Level
+	}
+	if payload.Mechanic != nil {
+		updates["mechanic"] = *payload.Mechanic
+	}
+	if payload.Equipment != nil {
+		updates["equipment"] = *payload.Equipment
+	}
+	if payload.PrimaryMuscles != nil {
+		updates["primaryMuscles"] = *payload.PrimaryMuscles
+	}
+	if payload.SecondaryMuscles != nil {
+		updates["secondaryMuscles"] = *payload.SecondaryMuscles
+	}
+	if payload.Instructions != nil {
+		updates["instructions"] = *payload.Instructions
+	}
+	if payload.Aliases != nil {
+		updates["aliases"] = cleanAliases(*payload.Aliases)
+	}
+	if payload.TrackingType != nil {
+		trackingType := store.TrackingType(*payload.TrackingType)
+		if !trackingType.Valid() {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": trackingTypeError(),
+			})
+		}
+		updates["tracking_type"] = trackingType
+	}
+	if payload.Archived != nil {
+		if *payload.Archived {
+			updates["archived_at"] = time.Now()
+		} else {
+			updates["archived_at"] = nil
+		}
+	}
 
 	if len(updates) == 0 {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -250,6 +409,12 @@ func (app *application) updateExerciseHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	after, err := app.store.Exercise.GetByID(c.Context(), exerciseID, userID)
+	if err != nil {
+		app.logger.Errorf("Error fetching updated exercise for audit: %v", err)
+	}
+	app.recordAudit(c, store.AuditActionUpdate, store.AuditResourceExercise, exerciseID, userID, getExerciseFromContext(c), after)
+
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "Exercise updated successfully",
 	})
@@ -258,15 +423,18 @@ func (app *application) updateExerciseHandler(c *fiber.Ctx) error {
 // DeleteExercise godoc
 //
 //	@Summary		Delete an exercise
-//	@Description	Remove an exercise from the system
+//	@Description	Remove a custom exercise. An exercise still used by routines or workouts is not deleted unless mode says how to handle them: cascade removes it from routines and in progress workouts, archive keeps it hidden from lists and search so workout history stays readable. Completed workouts can only keep their exercise through archive.
 //	@Tags			exercises
 //	@Accept			json
 //	@Produce		json
-//	@Param			userID		path		string	true	"User ID"
-//	@Param			exerciseID	path		string	true	"Exercise ID"
-//	@Success		200			{object}	string	"Exercise successfully deleted"
-//	@Failure		400			{object}	error	"Invalid ID format"
-//	@Failure		500			{object}	error	"Failed to delete exercise"
+//	@Param			userID		path		string						true	"User ID"
+//	@Param			exerciseID	path		string						true	"Exercise ID"
+//	@Param			mode		query		string						false	"restrict (default), cascade or archive"
+//	@Success		200			{object}	string						"Exercise successfully deleted or archived"
+//	@Failure		400			{object}	error						"Invalid ID format or mode"
+//	@Failure		404			{object}	error						"Exercise not found"
+//	@Failure		409			{object}	store.ExerciseDependents	"Routines and workouts still using the exercise"
+//	@Failure		500			{object}	error						"Failed to delete exercise"
 //
 // @Security		ApiKeyAuth
 //
@@ -283,18 +451,90 @@ func (app *application) deleteExerciseHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := app.store.Exercise.Delete(c.Context(), exerciseID, userID); err != nil {
+	mode := c.Query("mode", store.ExerciseDeleteRestrict)
+	if !slices.Contains(store.ExerciseDeleteModes, mode) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "mode must be one of " + strings.Join(store.ExerciseDeleteModes, ", "),
+		})
+	}
+
+	dependents, err := app.store.Exercise.Delete(c.Context(), exerciseID, userID, mode)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrExerciseInUse):
+			message := "Exercise is used by routines or workouts, delete with mode=cascade or mode=archive"
+			if mode == store.ExerciseDeleteCascade {
+				message = "Exercise is part of completed workouts, delete with mode=archive to keep their history"
+			}
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error":    message,
+				"routines": dependents.Routines,
+				"workouts": dependents.Workouts,
+			})
+		case errors.Is(err, store.ErrNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Exercise not found",
+			})
+		}
+		app.logger.Errorf("Error deleting exercise: %v", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"details": err.Error(),
-			"error":   "Failed to delete exercise",
+			"error": "Failed to delete exercise",
 		})
 	}
 
+	if mode == store.ExerciseDeleteArchive {
+		after, err := app.store.Exercise.GetByID(c.Context(), exerciseID, userID)
+		if err != nil {
+			app.logger.Errorf("Error fetching archived exercise for audit: %v", err)
+		}
+		app.recordAudit(c, store.AuditActionArchive, store.AuditResourceExercise, exerciseID, userID, getExerciseFromContext(c), after)
+
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"message": "Exercise was archived",
+		})
+	}
+
+	app.recordAudit(c, store.AuditActionDelete, store.AuditResourceExercise, exerciseID, userID, getExerciseFromContext(c), nil)
+	app.removeExerciseMedia(c.Context(), exerciseID, userID)
+
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "Exercise was successfully deleted",
 	})
 }
 
+// GetExerciseDependents godoc
+//
+//	@Summary		List what uses an exercise
+//	@Description	List the user's routines and workouts that use an exercise, the ones that keep it from being deleted
+//	@Tags			exercises
+//	@Produce		json
+//	@Param			userID		path		string						true	"User ID"
+//	@Param			exerciseID	path		string						true	"Exercise ID"
+//	@Success		200			{object}	store.ExerciseDependents	"Routines and workouts using the exercise"
+//	@Failure		500			{object}	error						"Failed to fetch dependents"
+//
+// @Security		ApiKeyAuth
+//
+//	@Router			/users/{userID}/exercise/{exerciseID}/dependents [get]
+func (app *application) getExerciseDependentsHandler(c *fiber.Ctx) error {
+	userID, exerciseID := getUserIDFromContext(c), getExerciseIDFromContext(c)
+	if userID == primitive.NilObjectID || exerciseID == primitive.NilObjectID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "userID or exerciseID not found in context",
+		})
+	}
+
+	dependents, err := app.store.Exercise.GetDependents(c.Context(), exerciseID, userID)
+	if err != nil {
+		app.logger.Errorf("Error fetching exercise dependents: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch dependents",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dependents)
+}
+
 // SearchExerciseByID godoc
 //
 //	@Summary		Search for an exercise by ID
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"errors"
+
 	"github.com/FaustCelaj/GetFit.git/internal/store"
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -32,7 +34,7 @@ func (app *application) exerciseContextMiddleware() fiber.Handler {
 
 		exercise, err := app.store.Exercise.SearchExerciseByID(c.Context(), exerciseID)
 		if err != nil {
-			if err == mongo.ErrNoDocuments {
+			if errors.Is(err, mongo.ErrNoDocuments) {
 				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 					"error": "Exercise not found or does not belong to the user",
 				})
@@ -42,6 +44,13 @@ func (app *application) exerciseContextMiddleware() fiber.Handler {
 			})
 		}
 
+		// catalog exercises are shared, custom exercises are private to the user who created them
+		if exercise.IsCustom && (exercise.UserID == nil || *exercise.UserID != userID) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "You do not have access to this exercise",
+			})
+		}
+
 		c.Locals("exercise", exercise)
 		c.Locals("exerciseID", exerciseID)
 
@@ -49,6 +58,26 @@ func (app *application) exerciseContextMiddleware() fiber.Handler {
 	}
 }
 
+// requireCustomExerciseMiddleware stops changes to catalog exercises, which belong to no user
+func (app *application) requireCustomExerciseMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		exercise := getExerciseFromContext(c)
+		if exercise == nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "exercise not found in context",
+			})
+		}
+
+		if !exercise.IsCustom {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Catalog exercises cannot be changed",
+			})
+		}
+
+		return c.Next()
+	}
+}
+
 func getExerciseFromContext(c *fiber.Ctx) *store.Exercise {
 	exercise, ok := c.Locals("exercise").(*store.Exercise)
 	if !ok {
@@ -64,3 +93,44 @@ func getExerciseIDFromContext(c *fiber.Ctx) primitive.ObjectID {
 	}
 	return exerciseID
 }
+
+// loadReferencedExercises fetches every exercise a routine or workout session being
+// created points at. Only the user's own custom exercises and the catalog can be used,
+// and none of them archived. Any other ID comes back as a *fiber.Error to respond with.
+func (app *application) loadReferencedExercises(c *fiber.Ctx, userID primitive.ObjectID, exerciseIDs []primitive.ObjectID) (map[primitive.ObjectID]*store.Exercise, error) {
+	exercises := make(map[primitive.ObjectID]*store.Exercise, len(exerciseIDs))
+	for _, exerciseID := range exerciseIDs {
+		if _, ok := exercises[exerciseID]; ok {
+			continue
+		}
+
+		exercise, err := app.store.Exercise.GetByID(c.Context(), exerciseID, userID)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return nil, fiber.NewError(fiber.StatusNotFound, "Exercise "+exerciseID.Hex()+" not found or does not belong to the user")
+			}
+			return nil, err
+		}
+		if exercise.ArchivedAt != nil {
+			return nil, fiber.NewError(fiber.StatusConflict, "Exercise "+exerciseID.Hex()+" is archived, unarchive it first")
+		}
+		exercises[exerciseID] = exercise
+	}
+
+	return exercises, nil
+}
+
+// referencedExercisesError responds to an error of loadReferencedExercises
+func (app *application) referencedExercisesError(c *fiber.Ctx, resource string, err error) error {
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return c.Status(fiberErr.Code).JSON(fiber.Map{
+			"error": fiberErr.Message,
+		})
+	}
+
+	app.logger.Errorf("Error fetching exercises of new %s: %v", resource, err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "failed to create " + resource,
+	})
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/FaustCelaj/GetFit.git/internal/bundle"
+	"github.com/FaustCelaj/GetFit.git/internal/store"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// how often the export worker looks for work it was not woken up for
+const exportPollInterval = time.Minute
+
+// CreateExport godoc
+//
+//	@Summary		Request a data export
+//	@Description	Queue an export of the user's profile, exercises, routines and workouts as a ZIP of JSON and CSV files. Poll the returned job until it is completed.
+//	@Tags			export
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID	path		string		true	"User ID"
+//	@Success		202		{object}	store.Job	"Export queued"
+//	@Failure		409		{object}	store.Job	"An export is already in progress"
+//	@Failure		500		{object}	error		"Failed to queue export"
+//
+// @Security		ApiKeyAuth
+//
+//	@Router			/users/{userID}/export [post]
+func (app *application) createExportHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == primitive.NilObjectID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "userID not found in context",
+		})
+	}
+
+	active, err := app.store.Jobs.GetActive(c.Context(), userID, store.JobTypeExport)
+	if err == nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "An export is already in progress",
+			"job":   active,
+		})
+	}
+	if !errors.Is(err, store.ErrNotFound) {
+		app.logger.Errorf("Error fetching active export: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to queue export",
+		})
+	}
+
+	job := &store.Job{
+		UserID: userID,
+		Type:   store.JobTypeExport,
+	}
+	if err := app.store.Jobs.Create(c.Context(), job); err != nil {
+		app.logger.Errorf("Error creating export job: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to queue export",
+		})
+	}
+
+	app.wakeExportWorker()
+	app.recordAudit(c, store.AuditActionExport, store.AuditResourceUser, userID, userID, nil, nil)
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "Export queued",
+		"job":     job,
+	})
+}
+
+// GetExport godoc
+//
+//	@Summary		Get a data export
+//	@Description	Poll the status of an export job. Completed jobs include a download URL until they expire.
+//	@Tags			export
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID	path		string		true	"User ID"
+//	@Param			jobID	path		string		true	"Export job ID"
+//	@Success		200		{object}	store.Job	"Export job"
+//	@Failure		400		{object}	error		"Invalid ID format"
+//	@Failure		404		{object}	error		"Export not found"
+//	@Failure		500		{object}	error		"Failed to fetch export"
+//
+// @Security		ApiKeyAuth
+//
+//	@Router			/users/{userID}/export/{jobID} [get]
+func (app *application) getExportHandler(c *fiber.Ctx) error {
+	job := getExportJobFromContext(c)
+	if job == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "export job not found in context",
+		})
+	}
+
+	response := fiber.Map{"job": job}
+	if job.Status == store.JobStatusCompleted {
+		response["download_url"] = c.BaseURL() + strings.TrimSuffix(c.Path(), "/") + "/download"
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// DownloadExport godoc
+//
+//	@Summary		Download a data export
+//	@Description	Download the ZIP archive of a completed export job
+//	@Tags			export
+//	@Produce		application/zip
+//	@Param			userID	path		string	true	"User ID"
+//	@Param			jobID	path		string	true	"Export job ID"
+//	@Success		200		{file}		file	"Export archive"
+//	@Failure		404		{object}	error	"Export not found"
+//	@Failure		409		{object}	error	"Export is not ready yet"
+//	@Failure		410		{object}	error	"Export has expired"
+//
+// @Security		ApiKeyAuth
+//
+//	@Router			/users/{userID}/export/{jobID}/download [get]
+func (app *application) downloadExportHandler(c *fiber.Ctx) error {
+	job := getExportJobFromContext(c)
+	if job == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "export job not found in context",
+		})
+	}
+
+	if job.Status != store.JobStatusCompleted {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":  "Export is not ready yet",
+			"status": job.Status,
+		})
+	}
+
+	if job.ExpiresAt != nil && !job.ExpiresAt.After(time.Now()) {
+		return c.Status(fiber.StatusGone).JSON(fiber.Map{
+			"error": "Export has expired, request a new one",
+		})
+	}
+
+	if _, err := os.Stat(job.FilePath); err != nil {
+		app.logger.Errorf("Export file for job %s is missing: %v", job.ID.Hex(), err)
+		return c.Status(fiber.StatusGone).JSON(fiber.Map{
+			"error": "Export has expired, request a new one",
+		})
+	}
+
+	return c.Download(job.FilePath, fmt.Sprintf("getfit-export-%s.zip", job.CreatedAt.UTC().Format("2006-01-02")))
+}
+
+// wakeExportWorker tells the worker there is a new job without waiting for it
+func (app *application) wakeExportWorker() {
+	select {
+	case app.exportQueue <- struct{}{}:
+	default:
+	}
+}
+
+// runExportWorker builds queued exports one at a time and removes expired ones, until ctx is cancelled
+func (app *application) runExportWorker(ctx context.Context) {
+	// jobs that were running when the process last stopped are started over
+	if err := app.store.Jobs.RequeueRunning(ctx, store.JobTypeExport); err != nil {
+		app.logger.Errorf("Error requeueing export jobs: %v", err)
+	}
+
+	ticker := time.NewTicker(exportPollInterval)
+	defer ticker.Stop()
+
+	for {
+		app.processExportJobs(ctx)
+		app.removeExpiredExports(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-app.exportQueue:
+		}
+	}
+}
+
+func (app *application) processExportJobs(ctx context.Context) {
+	for ctx.Err() == nil {
+		job, err := app.store.Jobs.ClaimNext(ctx, store.JobTypeExport)
+		if err != nil {
+			if !errors.Is(err, store.ErrNotFound) {
+				app.logger.Errorf("Error claiming export job: %v", err)
+			}
+			return
+		}
+
+		filePath, size, err := app.buildExport(ctx, job)
+		if err != nil {
+			app.logger.Errorf("Error building export %s: %v", job.ID.Hex(), err)
+			if err := app.store.Jobs.Fail(ctx, job.ID, "Export failed, please try again"); err != nil {
+				app.logger.Errorf("Error marking export %s as failed: %v", job.ID.Hex(), err)
+			}
+			continue
+		}
+
+		if err := app.store.Jobs.Complete(ctx, job.ID, filePath, size, time.Now().Add(app.config.export.ttl)); err != nil {
+			app.logger.Errorf("Error marking export %s as completed: %v", job.ID.Hex(), err)
+		}
+	}
+}
+
+// buildExport writes the user's data to <export dir>/<userID>/<jobID>.zip
+func (app *application) buildExport(ctx context.Context, job *store.Job) (string, int64, error) {
+	profile, err := app.store.Users.GetByID(ctx, job.UserID)
+	if err != nil {
+		return "", 0, err
+	}
+	exercises, err := app.store.Exercise.GetAllUserExercises(ctx, job.UserID)
+	if err != nil {
+		return "", 0, err
+	}
+	routines, err := app.store.Routine.GetAllUserRoutines(ctx, job.UserID)
+	if err != nil {
+		return "", 0, err
+	}
+	workouts, err := app.store.WorkoutSession.GetAllUserSessions(ctx, job.UserID)
+	if err != nil {
+		return "", 0, err
+	}
+
+	dir := filepath.Join(app.config.export.dir, job.UserID.Hex())
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", 0, err
+	}
+
+	// written under a temporary name so a half written archive is never served
+	filePath := filepath.Join(dir, job.ID.Hex()+".zip")
+	tmpPath := filePath + ".tmp"
+
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return "", 0, err
+	}
+
+	if err := bundle.Write(file, bundle.New(profile, exercises, routines, workouts)); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return "", 0, err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmpPath)
+		return "", 0, err
+	}
+
+	info, err := os.Stat(tmpPath)
+	if err != nil {
+		return "", 0, err
+	}
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		return "", 0, err
+	}
+
+	return filePath, info.Size(), nil
+}
+
+func (app *application) removeExpiredExports(ctx context.Context) {
+	jobs, err := app.store.Jobs.GetExpired(ctx, time.Now())
+	if err != nil {
+		app.logger.Errorf("Error fetching expired exports: %v", err)
+		return
+	}
+
+	for _, job := range jobs {
+		if err := os.Remove(job.FilePath); err != nil && !os.IsNotExist(err) {
+			app.logger.Errorf("Error removing export file %s: %v", job.FilePath, err)
+			continue
+		}
+		if err := app.store.Jobs.Delete(ctx, job.ID); err != nil {
+			app.logger.Errorf("Error deleting export job %s: %v", job.ID.Hex(), err)
+		}
+	}
+}
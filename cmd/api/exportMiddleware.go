@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/FaustCelaj/GetFit.git/internal/store"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (app *application) exportJobContextMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID := getUserIDFromContext(c)
+		if userID == primitive.NilObjectID {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "userID not found in context",
+			})
+		}
+
+		jobID, err := primitive.ObjectIDFromHex(c.Params("jobID"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid jobID format",
+			})
+		}
+
+		job, err := app.store.Jobs.GetByID(c.Context(), jobID, userID)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "Export not found",
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to fetch export",
+			})
+		}
+
+		if job.Type != store.JobTypeExport {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Export not found",
+			})
+		}
+
+		c.Locals("exportJob", job)
+
+		return c.Next()
+	}
+}
+
+func getExportJobFromContext(c *fiber.Ctx) *store.Job {
+	job, ok := c.Locals("exportJob").(*store.Job)
+	if !ok {
+		return nil
+	}
+	return job
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"io"
+
+	"github.com/FaustCelaj/GetFit.git/internal/bundle"
+	"github.com/FaustCelaj/GetFit.git/internal/store"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ImportData godoc
+//
+//	@Summary		Import a data bundle
+//	@Description	Restore a bundle produced by an export, either the ZIP archive or a single JSON document, into the user's account. IDs are remapped so routines and workouts keep pointing at the imported exercises and routines. Items matching existing ones (exercises by name, routines by title, workouts by title and start time) are handled according to mode. With dry_run nothing is written and the report shows what would happen.
+//	@Tags			export
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			userID	path		string			true	"User ID"
+//	@Param			file	formData	file			true	"Bundle to import, the request body may also be the bundle itself"
+//	@Param			mode	query		string			false	"skip (default), overwrite or keep_both"
+//	@Param			dry_run	query		bool			false	"Only report what would be imported"
+//	@Success		200		{object}	bundle.Report	"Import report"
+//	@Failure		400		{object}	error			"Invalid bundle or mode"
+//	@Failure		500		{object}	error			"Failed to import data"
+//
+// @Security		ApiKeyAuth
+//
+//	@Router			/users/{userID}/import [post]
+func (app *application) importDataHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == primitive.NilObjectID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "userID not found in context",
+		})
+	}
+
+	data, err := readImportUpload(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Failed to read the uploaded bundle",
+		})
+	}
+	if len(data) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "A bundle file is required",
+		})
+	}
+
+	b, err := bundle.Read(data)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	existing, err := app.loadImportTarget(c, userID, b)
+	if err != nil {
+		app.logger.Errorf("Error loading account for import: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to import data",
+		})
+	}
+
+	set, report, err := bundle.Merge(b, existing, userID, c.Query("mode", bundle.ModeSkip))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	report.DryRun = c.QueryBool("dry_run", false)
+	if report.DryRun {
+		return c.Status(fiber.StatusOK).JSON(report)
+	}
+
+	if err := app.store.Imports.Apply(c.Context(), userID, set); err != nil {
+		app.logger.Errorf("Error applying import: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to import data",
+		})
+	}
+
+	app.recordAudit(c, store.AuditActionImport, store.AuditResourceUser, userID, userID, nil, nil)
+
+	return c.Status(fiber.StatusOK).JSON(report)
+}
+
+// readImportUpload takes the bundle from the "file" form field, or the raw body when the request is not a form
+func readImportUpload(c *fiber.Ctx) ([]byte, error) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		// not a multipart request, or no file field
+		return c.Body(), nil
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return io.ReadAll(file)
+}
+
+// loadImportTarget fetches what the account already holds, for duplicate detection
+func (app *application) loadImportTarget(c *fiber.Ctx, userID primitive.ObjectID, b *bundle.Bundle) (*bundle.Existing, error) {
+	profile, err := app.store.Users.GetByID(c.Context(), userID)
+	if err != nil {
+		return nil, err
+	}
+	exercises, err := app.store.Exercise.GetAllUserExercises(c.Context(), userID)
+	if err != nil {
+		return nil, err
+	}
+	routines, err := app.store.Routine.GetAllUserRoutines(c.Context(), userID)
+	if err != nil {
+		return nil, err
+	}
+	workouts, err := app.store.WorkoutSession.GetAllUserSessions(c.Context(), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// exercises referenced by the bundle without being part of it may come from the catalog
+	inBundle := make(map[primitive.ObjectID]bool, len(b.Exercises))
+	for _, e := range b.Exercises {
+		if e != nil {
+			inBundle[e.ID] = true
+		}
+	}
+	var referenced []primitive.ObjectID
+	addReference := func(id primitive.ObjectID) {
+		if !inBundle[id] {
+			inBundle[id] = true
+			referenced = append(referenced, id)
+		}
+	}
+	for _, r := range b.Routines {
+		if r != nil {
+			for _, re := range r.Exercises {
+				addReference(re.ExerciseID)
+			}
+		}
+	}
+	for _, w := range b.Workouts {
+		if w != nil {
+			for _, se := range w.Exercises {
+				addReference(se.ExerciseID)
+			}
+		}
+	}
+
+	shared, err := app.store.Exercise.GetCatalogIDs(c.Context(), referenced)
+	if err != nil {
+		return nil, err
+	}
+
+	return &bundle.Existing{
+		Profile:         profile,
+		Exercises:       exercises,
+		Routines:        routines,
+		Workouts:        workouts,
+		SharedExercises: shared,
+	}, nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/FaustCelaj/GetFit.git/internal/store"
+	"github.com/gofiber/fiber/v2"
+)
+
+// parseListQuery reads the cursor, limit, sort and fields parameters shared by every
+// list endpoint. The store checks them against what the list allows.
+func parseListQuery(c *fiber.Ctx) store.ListQuery {
+	query := store.ListQuery{
+		Cursor: c.Query("cursor"),
+		Limit:  int64(c.QueryInt("limit", store.DefaultListLimit)),
+		Sort:   c.Query("sort"),
+	}
+
+	for _, field := range strings.Split(c.Query("fields"), ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			query.Fields = append(query.Fields, field)
+		}
+	}
+
+	return query
+}
+
+// respondWithPage writes a page of a list under key, together with the cursor of the
+// next page. When fields were asked for, only those and the id are written per item.
+func respondWithPage[T any](c *fiber.Ctx, key string, page *store.Page[T], query store.ListQuery) error {
+	var items interface{} = page.Items
+	if len(query.Fields) > 0 {
+		selected, err := selectFields(page.Items, query.Fields)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to encode " + key,
+			})
+		}
+		items = selected
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		key:           items,
+		"next_cursor": page.NextCursor,
+		"has_more":    page.HasMore,
+	})
+}
+
+func selectFields[T any](items []T, fields []string) ([]map[string]json.RawMessage, error) {
+	keep := map[string]bool{"id": true}
+	for _, field := range fields {
+		keep[field] = true
+	}
+
+	selected := make([]map[string]json.RawMessage, 0, len(items))
+	for _, item := range items {
+		data, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+
+		var all map[string]json.RawMessage
+		if err := json.Unmarshal(data, &all); err != nil {
+			return nil, err
+		}
+
+		for field := range all {
+			if !keep[field] {
+				delete(all, field)
+			}
+		}
+		selected = append(selected, all)
+	}
+
+	return selected, nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/FaustCelaj/GetFit.git/internal/mailer"
+)
+
+// sendEmail renders a template and sends it without holding up the request,
+// so response times do not reveal whether an email was sent
+func (app *application) sendEmail(to, subject, body string, data any) {
+	msg, err := mailer.Render(to, subject, body, data)
+	if err != nil {
+		app.logger.Errorf("Error rendering email %q: %v", subject, err)
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if err := app.mailer.Send(ctx, msg); err != nil {
+			app.logger.Errorf("Error sending email %q: %v", subject, err)
+		}
+	}()
+}
+
+// humanizeDuration formats whole hours or minutes for use in emails
+func humanizeDuration(d time.Duration) string {
+	if d >= time.Hour && d%time.Hour == 0 {
+		hours := int(d / time.Hour)
+		if hours == 1 {
+			return "1 hour"
+		}
+		return fmt.Sprintf("%d hours", hours)
+	}
+
+	minutes := int(d / time.Minute)
+	if minutes == 1 {
+		return "1 minute"
+	}
+	return fmt.Sprintf("%d minutes", minutes)
+}
@@ -2,15 +2,24 @@ package main
 
 import (
 	"context"
+	"os"
+	"path/filepath"
+	"time"
 
+	"github.com/FaustCelaj/GetFit.git/internal/auth"
+	"github.com/FaustCelaj/GetFit.git/internal/blob"
 	"github.com/FaustCelaj/GetFit.git/internal/db"
 	"github.com/FaustCelaj/GetFit.git/internal/env"
+	"github.com/FaustCelaj/GetFit.git/internal/mailer"
 	"github.com/FaustCelaj/GetFit.git/internal/store"
 	"go.uber.org/zap"
 )
 
 const version = "0.0.1"
 
+// shortest AUTH_TOKEN_SECRET accepted outside development, HS256 keys should be at least 256 bits
+const minTokenSecretLength = 32
+
 //	@title			GetFit API
 //	@version		0.0.1
 //	@description	API for tracking workouts, exercises, and fitness routines
@@ -29,26 +38,101 @@ const version = "0.0.1"
 //	@securityDefinitions.apikey	ApiKeyAuth
 //	@in							header
 //	@name						Authorization
-//	@description
-//	@schemes	http
+//	@description				"Bearer <access token>" from /authentication/token, or a personal API key starting with gf_
+//	@schemes					http
 
 func main() {
 	// Set up configuration
 	cfg := config{
-		addr:   env.GetString("ADDR", ":8080"),
-		apiURL: env.GetString("EXTERNAL_URL", "localHost:8080"),
+		addr:        env.GetString("ADDR", ":8080"),
+		apiURL:      env.GetString("EXTERNAL_URL", "localHost:8080"),
+		frontendURL: env.GetString("FRONTEND_URL", "http://localhost:5173"),
 		db: dbConfig{
 			addr:         env.GetString("DB_ADDR", "mongodb://localhost:27017"),
 			maxOpenConns: env.GetInt("DB_MAX_OPEN_CONNS", 30),
 			maxIdleTime:  env.GetString("DB_MAX_IDEL_TIME", "15m"),
 		},
 		env: env.GetString("ENV", "development"),
+		auth: authConfig{
+			token: tokenConfig{
+				secret:     env.GetString("AUTH_TOKEN_SECRET", ""),
+				exp:        time.Minute * time.Duration(env.GetInt("AUTH_TOKEN_EXP_MINUTES", 15)),
+				refreshExp: time.Hour * time.Duration(env.GetInt("AUTH_REFRESH_TOKEN_EXP_HOURS", 24*30)),
+				iss:        "getfit",
+			},
+			mfaTokenExp:          time.Minute * time.Duration(env.GetInt("AUTH_MFA_TOKEN_EXP_MINUTES", 5)),
+			passwordResetExp:     time.Minute * time.Duration(env.GetInt("AUTH_PASSWORD_RESET_EXP_MINUTES", 30)),
+			emailVerificationExp: time.Hour * time.Duration(env.GetInt("AUTH_EMAIL_VERIFICATION_EXP_HOURS", 72)),
+		},
+		mail: mailConfig{
+			driver:    env.GetString("MAIL_DRIVER", "outbox"),
+			fromEmail: env.GetString("MAIL_FROM_EMAIL", "GetFit <no-reply@getfit.local>"),
+			outboxDir: env.GetString("MAIL_OUTBOX_DIR", ""),
+			smtp: smtpConfig{
+				host:     env.GetString("SMTP_HOST", "localhost"),
+				port:     env.GetInt("SMTP_PORT", 587),
+				username: env.GetString("SMTP_USERNAME", ""),
+				password: env.GetString("SMTP_PASSWORD", ""),
+			},
+		},
+		admin: adminConfig{
+			email:    env.GetString("ADMIN_EMAIL", ""),
+			password: env.GetString("ADMIN_PASSWORD", ""),
+		},
+		account: accountConfig{
+			deletionGrace: env.GetDuration("ACCOUNT_DELETION_GRACE", 14*24*time.Hour),
+			purgeInterval: env.GetDuration("ACCOUNT_PURGE_INTERVAL", time.Hour),
+		},
+		export: exportConfig{
+			dir: env.GetString("EXPORT_DIR", filepath.Join(os.TempDir(), "getfit-exports")),
+			ttl: env.GetDuration("EXPORT_TTL", 7*24*time.Hour),
+		},
+		dataImport: importConfig{
+			maxSize: env.GetInt("IMPORT_MAX_SIZE_MB", 32) << 20,
+		},
+		media: mediaConfig{
+			driver: env.GetString("MEDIA_DRIVER", "local"),
+			dir:    env.GetString("MEDIA_DIR", filepath.Join(os.TempDir(), "getfit-media")),
+			s3: blob.S3Config{
+				Endpoint:  env.GetString("MEDIA_S3_ENDPOINT", "https://s3.us-east-1.amazonaws.com"),
+				Region:    env.GetString("MEDIA_S3_REGION", "us-east-1"),
+				Bucket:    env.GetString("MEDIA_S3_BUCKET", ""),
+				AccessKey: env.GetString("MEDIA_S3_ACCESS_KEY", ""),
+				SecretKey: env.GetString("MEDIA_S3_SECRET_KEY", ""),
+				PathStyle: env.GetBool("MEDIA_S3_PATH_STYLE", false),
+			},
+			maxImageSize:    env.GetInt("MEDIA_MAX_IMAGE_SIZE_MB", 5) << 20,
+			maxVideoSize:    env.GetInt("MEDIA_MAX_VIDEO_SIZE_MB", 50) << 20,
+			maxPerExercise:  int64(env.GetInt("MEDIA_MAX_PER_EXERCISE", 10)),
+			thumbnailSize:   env.GetInt("MEDIA_THUMBNAIL_SIZE", 320),
+			catalogImageURL: env.GetString("CATALOG_IMAGE_BASE_URL", "https://raw.githubusercontent.com/yuhonas/free-exercise-db/main/exercises/"),
+		},
+		rateLimit: rateLimitConfig{
+			ipMax:            env.GetInt("AUTH_RATE_LIMIT_IP_MAX", 20),
+			ipWindow:         env.GetDuration("AUTH_RATE_LIMIT_IP_WINDOW", time.Minute),
+			accountMax:       env.GetInt("AUTH_RATE_LIMIT_ACCOUNT_MAX", 10),
+			accountWindow:    env.GetDuration("AUTH_RATE_LIMIT_ACCOUNT_WINDOW", 15*time.Minute),
+			lockoutThreshold: env.GetInt("AUTH_LOCKOUT_THRESHOLD", 5),
+			lockoutBase:      env.GetDuration("AUTH_LOCKOUT_BASE", time.Minute),
+			lockoutMax:       env.GetDuration("AUTH_LOCKOUT_MAX", time.Hour),
+		},
 	}
 
 	// Logger
 	logger := zap.Must(zap.NewProduction()).Sugar()
 	defer logger.Sync()
 
+	// anyone who knows the signing key can mint access tokens for any user
+	switch secret := cfg.auth.token.secret; {
+	case secret == "":
+		logger.Fatal("AUTH_TOKEN_SECRET is not set")
+	case secret == "example" || len(secret) < minTokenSecretLength:
+		if cfg.env != "development" {
+			logger.Fatalf("AUTH_TOKEN_SECRET must be a random value of at least %d bytes", minTokenSecretLength)
+		}
+		logger.Warn("AUTH_TOKEN_SECRET is weak, which is only allowed with ENV=development")
+	}
+
 	client, err := db.New(
 		cfg.db.addr,
 		cfg.db.maxOpenConns,
@@ -60,15 +144,86 @@ func main() {
 	defer client.Disconnect(context.Background())
 	logger.Info("MongoDB connection established")
 
-	store := store.NewMongoDBStorage(client.Database("getfit"))
+	if ok, err := db.SupportsTransactions(context.Background(), client); err != nil {
+		logger.Fatal(err)
+	} else if !ok {
+		logger.Fatal("MongoDB must run as a replica set for transactions, start mongod with --replSet and run rs.initiate() once")
+	}
+
+	database := client.Database("getfit")
+	if err := store.CreateIndexes(context.Background(), database); err != nil {
+		logger.Fatal(err)
+	}
+	migrated, err := store.Migrate(context.Background(), database)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	for name, count := range migrated {
+		if count > 0 {
+			logger.Infof("Migration %s updated %d documents", name, count)
+		}
+	}
+
+	store := store.NewMongoDBStorage(database)
+
+	jwtAuthenticator := auth.NewJWTAuthenticator(
+		cfg.auth.token.secret,
+		cfg.auth.token.iss,
+		cfg.auth.token.iss,
+	)
+
+	// Mailer
+	var mail mailer.Mailer
+	switch cfg.mail.driver {
+	case "smtp":
+		mail = mailer.NewSMTPMailer(
+			cfg.mail.smtp.host,
+			cfg.mail.smtp.port,
+			cfg.mail.smtp.username,
+			cfg.mail.smtp.password,
+			cfg.mail.fromEmail,
+		)
+	default:
+		mail, err = mailer.NewOutboxMailer(cfg.mail.outboxDir, cfg.mail.fromEmail, logger)
+		if err != nil {
+			logger.Fatal(err)
+		}
+	}
+
+	// Media storage
+	var blobs blob.BlobStore
+	switch cfg.media.driver {
+	case "s3":
+		blobs, err = blob.NewS3Store(cfg.media.s3)
+	default:
+		blobs, err = blob.NewLocalStore(cfg.media.dir)
+	}
+	if err != nil {
+		logger.Fatal(err)
+	}
 
 	// Create an application instance
 	app := &application{
-		config: cfg,
-		store:  store,
-		logger: logger,
+		config:        cfg,
+		store:         store,
+		logger:        logger,
+		authenticator: jwtAuthenticator,
+		totp:          auth.NewTOTP("GetFit"),
+		mailer:        mail,
+		exportQueue:   make(chan struct{}, 1),
+		blobs:         blobs,
 	}
 
+	if err := app.seedAdmin(context.Background()); err != nil {
+		logger.Fatal(err)
+	}
+
+	// Background workers
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	go app.runAccountPurger(workerCtx)
+	go app.runExportWorker(workerCtx)
+
 	// Mount routes
 	fiberApp := app.mount()
 
@@ -0,0 +1,379 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"path"
+	"strings"
+
+	"github.com/FaustCelaj/GetFit.git/internal/blob"
+	"github.com/FaustCelaj/GetFit.git/internal/media"
+	"github.com/FaustCelaj/GetFit.git/internal/store"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type mediaItem struct {
+	*store.ExerciseMedia
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnail_url,omitempty"`
+}
+
+// catalogMediaItem is an image of a catalog exercise, served by the dataset's host
+type catalogMediaItem struct {
+	Kind        string `json:"kind"`
+	ContentType string `json:"content_type,omitempty"`
+	URL         string `json:"url"`
+}
+
+// UploadExerciseMedia godoc
+//
+//	@Summary		Upload exercise media
+//	@Description	Attach a demo image or short clip to a custom exercise. The content type is detected from the file: JPEG, PNG, GIF and WebP images and MP4 and WebM clips are accepted. A thumbnail is generated for JPEG, PNG and GIF images.
+//	@Tags			exercise-media
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			userID		path		string		true	"User ID"
+//	@Param			exerciseID	path		string		true	"Exercise ID"
+//	@Param			file		formData	file		true	"Image or clip"
+//	@Success		201			{object}	mediaItem	"Media uploaded successfully"
+//	@Failure		400			{object}	error		"Missing file or unreadable image"
+//	@Failure		403			{object}	error		"Catalog exercises cannot be changed"
+//	@Failure		409			{object}	error		"The exercise has as much media as allowed"
+//	@Failure		413			{object}	error		"File is too large"
+//	@Failure		415			{object}	error		"Unsupported content type"
+//	@Failure		500			{object}	error		"Failed to upload media"
+//
+// @Security		ApiKeyAuth
+//
+//	@Router			/users/{userID}/exercise/{exerciseID}/media [post]
+func (app *application) uploadExerciseMediaHandler(c *fiber.Ctx) error {
+	userID, exerciseID := getUserIDFromContext(c), getExerciseIDFromContext(c)
+	if userID == primitive.NilObjectID || exerciseID == primitive.NilObjectID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "userID or exerciseID not found in context",
+		})
+	}
+
+	count, err := app.store.Media.CountByExercise(c.Context(), exerciseID, userID)
+	if err != nil {
+		app.logger.Errorf("Error counting exercise media: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to upload media",
+		})
+	}
+	if count >= app.config.media.maxPerExercise {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": fmt.Sprintf("An exercise can have at most %d media, delete some first", app.config.media.maxPerExercise),
+		})
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "A file is required",
+		})
+	}
+	if fileHeader.Size > int64(max(app.config.media.maxImageSize, app.config.media.maxVideoSize)) {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"error": "File is too large",
+		})
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Failed to read the uploaded file",
+		})
+	}
+	data, err := io.ReadAll(file)
+	file.Close()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Failed to read the uploaded file",
+		})
+	}
+
+	contentType, kind, err := media.Detect(data)
+	if err != nil {
+		return c.Status(fiber.StatusUnsupportedMediaType).JSON(fiber.Map{
+			"error":   err.Error(),
+			"allowed": media.AllowedTypes(),
+		})
+	}
+
+	limit := app.config.media.maxImageSize
+	if kind == media.KindVideo {
+		limit = app.config.media.maxVideoSize
+	}
+	if len(data) > limit {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"error": fmt.Sprintf("%s files can be at most %d MB", kind, limit>>20),
+		})
+	}
+
+	item := &store.ExerciseMedia{
+		ID:          primitive.NewObjectID(),
+		UserID:      userID,
+		ExerciseID:  exerciseID,
+		Kind:        kind,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		FileName:    uploadFileName(fileHeader.Filename),
+	}
+	item.Key = mediaKey(item, "original")
+
+	var thumbnail []byte
+	if kind == media.KindImage {
+		thumbnail, item.Width, item.Height, err = media.Thumbnail(data, app.config.media.thumbnailSize)
+		switch {
+		case err == nil:
+			item.ThumbnailKey = mediaKey(item, "thumbnail")
+		case errors.Is(err, media.ErrUndecodable) && contentType == "image/webp":
+			// the standard library cannot decode WebP, it is kept without a thumbnail
+		default:
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "The image cannot be read",
+			})
+		}
+	}
+
+	if err := app.blobs.Put(c.Context(), item.Key, bytes.NewReader(data), item.Size, contentType); err != nil {
+		app.logger.Errorf("Error storing exercise media: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to upload media",
+		})
+	}
+	if item.ThumbnailKey != "" {
+		if err := app.blobs.Put(c.Context(), item.ThumbnailKey, bytes.NewReader(thumbnail), int64(len(thumbnail)), media.ThumbnailContentType); err != nil {
+			app.logger.Errorf("Error storing exercise media thumbnail: %v", err)
+			app.removeMediaBlobs(c.Context(), item)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to upload media",
+			})
+		}
+	}
+
+	if err := app.store.Media.Create(c.Context(), item); err != nil {
+		app.logger.Errorf("Error saving exercise media: %v", err)
+		app.removeMediaBlobs(c.Context(), item)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to upload media",
+		})
+	}
+
+	app.recordAudit(c, store.AuditActionCreate, store.AuditResourceMedia, item.ID, userID, nil, item)
+
+	return c.Status(fiber.StatusCreated).JSON(app.toMediaItem(c, item))
+}
+
+// ListExerciseMedia godoc
+//
+//	@Summary		List exercise media
+//	@Description	List the images and clips of an exercise. Custom exercises list uploaded media with links to the file and thumbnail, catalog exercises list the dataset's images.
+//	@Tags			exercise-media
+//	@Produce		json
+//	@Param			userID		path		string		true	"User ID"
+//	@Param			exerciseID	path		string		true	"Exercise ID"
+//	@Success		200			{array}		mediaItem	"Media of the exercise"
+//	@Failure		500			{object}	error		"Failed to fetch media"
+//
+// @Security		ApiKeyAuth
+//
+//	@Router			/users/{userID}/exercise/{exerciseID}/media [get]
+func (app *application) listExerciseMediaHandler(c *fiber.Ctx) error {
+	userID, exercise := getUserIDFromContext(c), getExerciseFromContext(c)
+	if userID == primitive.NilObjectID || exercise == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "userID or exercise not found in context",
+		})
+	}
+
+	if !exercise.IsCustom {
+		items := make([]catalogMediaItem, 0, len(exercise.Images))
+		for _, image := range exercise.Images {
+			items = append(items, catalogMediaItem{
+				Kind:        media.KindImage,
+				ContentType: mime.TypeByExtension(path.Ext(image)),
+				URL:         strings.TrimSuffix(app.config.media.catalogImageURL, "/") + "/" + strings.TrimPrefix(image, "/"),
+			})
+		}
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"media": items,
+		})
+	}
+
+	stored, err := app.store.Media.GetByExercise(c.Context(), exercise.ID, userID)
+	if err != nil {
+		app.logger.Errorf("Error fetching exercise media: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch media",
+		})
+	}
+
+	items := make([]mediaItem, 0, len(stored))
+	for _, item := range stored {
+		items = append(items, app.toMediaItem(c, item))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"media": items,
+	})
+}
+
+// GetExerciseMedia godoc
+//
+//	@Summary		Download exercise media
+//	@Description	Stream an uploaded image or clip, or its thumbnail
+//	@Tags			exercise-media
+//	@Produce		octet-stream
+//	@Param			userID		path		string	true	"User ID"
+//	@Param			exerciseID	path		string	true	"Exercise ID"
+//	@Param			mediaID		path		string	true	"Media ID"
+//	@Param			thumbnail	query		bool	false	"Return the thumbnail instead"
+//	@Success		200			{file}		file	"The media file"
+//	@Failure		404			{object}	error	"Media or thumbnail not found"
+//	@Failure		500			{object}	error	"Failed to fetch media"
+//
+// @Security		ApiKeyAuth
+//
+//	@Router			/users/{userID}/exercise/{exerciseID}/media/{mediaID} [get]
+func (app *application) getExerciseMediaHandler(c *fiber.Ctx) error {
+	item := getMediaFromContext(c)
+	if item == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "media not found in context",
+		})
+	}
+
+	key, contentType := item.Key, item.ContentType
+	if c.QueryBool("thumbnail", false) {
+		if item.ThumbnailKey == "" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "This media has no thumbnail",
+			})
+		}
+		key, contentType = item.ThumbnailKey, media.ThumbnailContentType
+	}
+
+	content, err := app.blobs.Get(c.Context(), key)
+	if err != nil {
+		if errors.Is(err, blob.ErrNotFound) {
+			app.logger.Errorf("Media file %s is missing", key)
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Media not found",
+			})
+		}
+		app.logger.Errorf("Error fetching media file: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch media",
+		})
+	}
+
+	// media never changes once uploaded, it is deleted and uploaded again instead
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderCacheControl, "private, max-age=86400, immutable")
+	return c.SendStream(content)
+}
+
+// DeleteExerciseMedia godoc
+//
+//	@Summary		Delete exercise media
+//	@Description	Remove an image or clip from a custom exercise
+//	@Tags			exercise-media
+//	@Produce		json
+//	@Param			userID		path		string	true	"User ID"
+//	@Param			exerciseID	path		string	true	"Exercise ID"
+//	@Param			mediaID		path		string	true	"Media ID"
+//	@Success		200			{object}	string	"Media deleted successfully"
+//	@Failure		404			{object}	error	"Media not found"
+//	@Failure		500			{object}	error	"Failed to delete media"
+//
+// @Security		ApiKeyAuth
+//
+//	@Router			/users/{userID}/exercise/{exerciseID}/media/{mediaID} [delete]
+func (app *application) deleteExerciseMediaHandler(c *fiber.Ctx) error {
+	userID, item := getUserIDFromContext(c), getMediaFromContext(c)
+	if userID == primitive.NilObjectID || item == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "userID or media not found in context",
+		})
+	}
+
+	if err := app.store.Media.Delete(c.Context(), item.ID, userID); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Media not found",
+			})
+		}
+		app.logger.Errorf("Error deleting exercise media: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete media",
+		})
+	}
+
+	app.removeMediaBlobs(c.Context(), item)
+	app.recordAudit(c, store.AuditActionDelete, store.AuditResourceMedia, item.ID, userID, item, nil)
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "media deleted successfully",
+	})
+}
+
+// removeExerciseMedia deletes every media of an exercise along with the files
+func (app *application) removeExerciseMedia(ctx context.Context, exerciseID, userID primitive.ObjectID) {
+	items, err := app.store.Media.GetByExercise(ctx, exerciseID, userID)
+	if err != nil {
+		app.logger.Errorf("Error fetching media of exercise %s: %v", exerciseID.Hex(), err)
+		return
+	}
+
+	for _, item := range items {
+		if err := app.store.Media.Delete(ctx, item.ID, userID); err != nil && !errors.Is(err, store.ErrNotFound) {
+			app.logger.Errorf("Error deleting media %s: %v", item.ID.Hex(), err)
+			continue
+		}
+		app.removeMediaBlobs(ctx, item)
+	}
+}
+
+// removeMediaBlobs deletes the files of media, a failure leaves an orphaned file and is only logged
+func (app *application) removeMediaBlobs(ctx context.Context, item *store.ExerciseMedia) {
+	for _, key := range []string{item.Key, item.ThumbnailKey} {
+		if key == "" {
+			continue
+		}
+		if err := app.blobs.Delete(ctx, key); err != nil {
+			app.logger.Errorf("Error deleting media file %s: %v", key, err)
+		}
+	}
+}
+
+func (app *application) toMediaItem(c *fiber.Ctx, item *store.ExerciseMedia) mediaItem {
+	url := fmt.Sprintf("%s/api/v1/users/%s/exercise/%s/media/%s",
+		c.BaseURL(), item.UserID.Hex(), item.ExerciseID.Hex(), item.ID.Hex())
+
+	result := mediaItem{ExerciseMedia: item, URL: url}
+	if item.ThumbnailKey != "" {
+		result.ThumbnailURL = url + "?thumbnail=true"
+	}
+	return result
+}
+
+// uploadFileName keeps the last element of a client supplied name, which may be a Windows path
+func uploadFileName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == "/" {
+		return ""
+	}
+	return name
+}
+
+// mediaKey places an exercise's files under its owner, so they are easy to find per account
+func mediaKey(item *store.ExerciseMedia, name string) string {
+	return fmt.Sprintf("users/%s/exercises/%s/media/%s/%s", item.UserID.Hex(), item.ExerciseID.Hex(), item.ID.Hex(), name)
+}
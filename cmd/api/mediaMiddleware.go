@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/FaustCelaj/GetFit.git/internal/store"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// mediaContextMiddleware loads media of the exercise in context, it runs after exerciseContextMiddleware
+func (app *application) mediaContextMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, exerciseID := getUserIDFromContext(c), getExerciseIDFromContext(c)
+		if userID == primitive.NilObjectID || exerciseID == primitive.NilObjectID {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "userID or exerciseID not found in context",
+			})
+		}
+
+		mediaID, err := primitive.ObjectIDFromHex(c.Params("mediaID"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid mediaID format",
+			})
+		}
+
+		media, err := app.store.Media.GetByID(c.Context(), mediaID, exerciseID, userID)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "Media not found",
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to fetch media",
+			})
+		}
+
+		c.Locals("media", media)
+
+		return c.Next()
+	}
+}
+
+func getMediaFromContext(c *fiber.Ctx) *store.ExerciseMedia {
+	media, ok := c.Locals("media").(*store.ExerciseMedia)
+	if !ok {
+		return nil
+	}
+	return media
+}
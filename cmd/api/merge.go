@@ -0,0 +1,254 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/FaustCelaj/GetFit.git/internal/dedupe"
+	"github.com/FaustCelaj/GetFit.git/internal/store"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// the most exercises one merge folds into a survivor
+const maxMergeDuplicates = 20
+
+type mergeExercisesPayload struct {
+	SurvivorID   string   `json:"survivor_id"`
+	DuplicateIDs []string `json:"duplicate_ids"`
+}
+
+// FindDuplicateExercises godoc
+//
+//	@Summary		Find duplicate exercises
+//	@Description	Pair up the user's custom exercises that are probably the same exercise, scored on name and alias similarity and on matching equipment and primary muscles. Exercises with different tracking types are never paired.
+//	@Tags			exercises
+//	@Produce		json
+//	@Param			userID		path		string				true	"User ID"
+//	@Param			min_score	query		number				false	"Lowest score to report, from 0 to 1, 0.7 by default"
+//	@Success		200			{array}		dedupe.Candidate	"Likely duplicates, most likely first"
+//	@Failure		400			{object}	error				"Invalid min_score"
+//	@Failure		500			{object}	error				"Failed to find duplicates"
+//
+// @Security		ApiKeyAuth
+//
+//	@Router			/users/{userID}/exercise/duplicates [get]
+func (app *application) findDuplicateExercisesHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == primitive.NilObjectID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "userID not found in context",
+		})
+	}
+
+	minScore := dedupe.DefaultMinScore
+	if value := c.Query("min_score"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed < 0 || parsed > 1 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "min_score must be a number between 0 and 1",
+			})
+		}
+		minScore = parsed
+	}
+
+	exercises, err := app.store.Exercise.GetAllUserExercises(c.Context(), userID)
+	if err != nil {
+		app.logger.Errorf("Error fetching exercises for duplicates: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to find duplicates",
+		})
+	}
+
+	active := make([]*store.Exercise, 0, len(exercises))
+	for _, exercise := range exercises {
+		if exercise.ArchivedAt == nil {
+			active = append(active, exercise)
+		}
+	}
+
+	candidates := dedupe.Find(active, minScore)
+	if candidates == nil {
+		candidates = []dedupe.Candidate{}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"duplicates": candidates,
+	})
+}
+
+// MergeExercises godoc
+//
+//	@Summary		Merge duplicate exercises
+//	@Description	Fold custom exercises into a survivor, which may be a custom or catalog exercise. Every routine and workout entry using a duplicate uses the survivor instead, the duplicates' names become aliases of a custom survivor and the duplicates are removed. Their media is kept for an undo. The merge is recorded and can be undone.
+//	@Tags			exercises
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID	path		string					true	"User ID"
+//	@Param			merge	body		mergeExercisesPayload	true	"Survivor and duplicates"
+//	@Success		201		{object}	store.ExerciseMerge		"Exercises merged"
+//	@Failure		400		{object}	error					"Invalid IDs or exercises tracked differently"
+//	@Failure		404		{object}	error					"Survivor or a duplicate not found"
+//	@Failure		500		{object}	error					"Failed to merge exercises"
+//
+// @Security		ApiKeyAuth
+//
+//	@Router			/users/{userID}/exercise/merge [post]
+func (app *application) mergeExercisesHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == primitive.NilObjectID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "userID not found in context",
+		})
+	}
+
+	var payload mergeExercisesPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	survivorID, err := primitive.ObjectIDFromHex(payload.SurvivorID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid survivor_id format",
+		})
+	}
+	if len(payload.DuplicateIDs) == 0 || len(payload.DuplicateIDs) > maxMergeDuplicates {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "duplicate_ids must list between 1 and " + strconv.Itoa(maxMergeDuplicates) + " exercises",
+		})
+	}
+
+	duplicateIDs := make([]primitive.ObjectID, 0, len(payload.DuplicateIDs))
+	seen := make(map[primitive.ObjectID]bool, len(payload.DuplicateIDs))
+	for _, hex := range payload.DuplicateIDs {
+		id, err := primitive.ObjectIDFromHex(hex)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid duplicate_ids format",
+			})
+		}
+		if id == survivorID {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "The survivor cannot also be a duplicate",
+			})
+		}
+		if !seen[id] {
+			seen[id] = true
+			duplicateIDs = append(duplicateIDs, id)
+		}
+	}
+
+	merge, err := app.store.Merges.Merge(c.Context(), userID, survivorID, duplicateIDs)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "The survivor or a duplicate was not found among your exercises",
+			})
+		case errors.Is(err, store.ErrInvalidMerge):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		app.logger.Errorf("Error merging exercises: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to merge exercises",
+		})
+	}
+
+	app.recordAudit(c, store.AuditActionMerge, store.AuditResourceExercise, survivorID, userID, merge.Duplicates, merge)
+
+	return c.Status(fiber.StatusCreated).JSON(merge)
+}
+
+// GetExerciseMerges godoc
+//
+//	@Summary		List exercise merges
+//	@Description	List the user's exercise merges, newest first, including undone ones
+//	@Tags			exercises
+//	@Produce		json
+//	@Param			userID	path		string				true	"User ID"
+//	@Success		200		{array}		store.ExerciseMerge	"Merges"
+//	@Failure		500		{object}	error				"Failed to fetch merges"
+//
+// @Security		ApiKeyAuth
+//
+//	@Router			/users/{userID}/exercise/merges [get]
+func (app *application) getExerciseMergesHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == primitive.NilObjectID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "userID not found in context",
+		})
+	}
+
+	merges, err := app.store.Merges.GetAllUserMerges(c.Context(), userID)
+	if err != nil {
+		app.logger.Errorf("Error fetching merges: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch merges",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"merges": merges,
+	})
+}
+
+// UndoExerciseMerge godoc
+//
+//	@Summary		Undo an exercise merge
+//	@Description	Restore the duplicates of a merge and point the routine and workout entries it rewrote back at them. Entries removed or changed since the merge are left alone.
+//	@Tags			exercises
+//	@Produce		json
+//	@Param			userID	path		string				true	"User ID"
+//	@Param			mergeID	path		string				true	"Merge ID"
+//	@Success		200		{object}	store.ExerciseMerge	"Merge undone"
+//	@Failure		400		{object}	error				"Invalid merge ID"
+//	@Failure		404		{object}	error				"Merge not found"
+//	@Failure		409		{object}	error				"Merge was already undone"
+//	@Failure		500		{object}	error				"Failed to undo merge"
+//
+// @Security		ApiKeyAuth
+//
+//	@Router			/users/{userID}/exercise/merges/{mergeID}/undo [post]
+func (app *application) undoExerciseMergeHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == primitive.NilObjectID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "userID not found in context",
+		})
+	}
+
+	mergeID, err := primitive.ObjectIDFromHex(c.Params("mergeID"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid mergeID format",
+		})
+	}
+
+	merge, err := app.store.Merges.Undo(c.Context(), mergeID, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Merge not found",
+			})
+		case errors.Is(err, store.ErrMergeUndone):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "Merge was already undone",
+			})
+		}
+		app.logger.Errorf("Error undoing merge: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to undo merge",
+		})
+	}
+
+	app.recordAudit(c, store.AuditActionUndoMerge, store.AuditResourceExercise, merge.SurvivorID, userID, nil, merge)
+
+	return c.Status(fiber.StatusOK).JSON(merge)
+}
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/FaustCelaj/GetFit.git/internal/auth"
+	"github.com/FaustCelaj/GetFit.git/internal/mailer"
+	"github.com/FaustCelaj/GetFit.git/internal/store"
+	"github.com/go-playground/validator"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type forgotPasswordPayload struct {
+	Email *string `json:"email" validate:"required,email,max=50"`
+}
+
+// ForgotPassword godoc
+//
+//	@Summary		Request a password reset
+//	@Description	Email a single-use, time-limited password reset link. Always responds the same way so it cannot be used to discover accounts.
+//	@Tags			authentication
+//	@Accept			json
+//	@Produce		json
+//	@Param			email	body		forgotPasswordPayload	true	"Account email"
+//	@Success		202		{object}	string					"Reset email sent if the account exists"
+//	@Failure		400		{object}	error					"Invalid request body"
+//	@Failure		500		{object}	error					"Internal server error"
+//	@Router			/authentication/forgot-password [post]
+func (app *application) forgotPasswordHandler(c *fiber.Ctx) error {
+	var payload forgotPasswordPayload
+
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request format",
+		})
+	}
+
+	if err := validate.Struct(payload); err != nil {
+		validationErrors := err.(validator.ValidationErrors)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Validation failed",
+			"details": formatValidationErrors(validationErrors),
+		})
+	}
+
+	accepted := fiber.Map{
+		"message": "If an account exists for this email, a password reset link has been sent",
+	}
+
+	user, err := app.store.Users.GetByEmail(c.Context(), *payload.Email)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return c.Status(fiber.StatusAccepted).JSON(accepted)
+		}
+		app.logger.Errorf("Error fetching user by email: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Internal server error",
+		})
+	}
+
+	if err := app.sendPasswordReset(c, user); err != nil {
+		app.logger.Errorf("Error creating password reset token: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Internal server error",
+		})
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(accepted)
+}
+
+type resetPasswordPayload struct {
+	Token    *string `json:"token" validate:"required"`
+	Password *string `json:"password" validate:"required,min=8,max=72"`
+}
+
+// ResetPassword godoc
+//
+//	@Summary		Reset a password
+//	@Description	Consume a password reset token and set a new password. Every session of the user is signed out and their API keys are revoked.
+//	@Tags			authentication
+//	@Accept			json
+//	@Produce		json
+//	@Param			reset	body		resetPasswordPayload	true	"Reset token and new password"
+//	@Success		200		{object}	string					"Password reset successfully"
+//	@Failure		400		{object}	error					"Invalid request body or invalid token"
+//	@Failure		500		{object}	error					"Failed to reset password"
+//	@Router			/authentication/reset-password [post]
+func (app *application) resetPasswordHandler(c *fiber.Ctx) error {
+	var payload resetPasswordPayload
+
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request format",
+		})
+	}
+
+	if err := validate.Struct(payload); err != nil {
+		validationErrors := err.(validator.ValidationErrors)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Validation failed",
+			"details": formatValidationErrors(validationErrors),
+		})
+	}
+
+	token, err := app.store.UserTokens.Consume(c.Context(), auth.HashToken(*payload.Token), store.TokenPurposePasswordReset)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Reset link is invalid or has expired",
+			})
+		}
+		app.logger.Errorf("Error consuming password reset token: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Internal server error",
+		})
+	}
+
+	user := &store.User{ID: token.UserID}
+	if err := user.SetPassword(*payload.Password); err != nil {
+		app.logger.Errorf("Error hashing password: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Internal server error",
+		})
+	}
+
+	if _, err := app.store.Users.UpdatePassword(c.Context(), user.ID, user.Password); err != nil {
+		app.logger.Errorf("Error updating password: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to reset password",
+		})
+	}
+
+	// proving ownership of the inbox lifts any lockout left by the failed attempts
+	if err := app.store.Users.ResetFailedLogins(c.Context(), user.ID); err != nil {
+		app.logger.Errorf("Error resetting failed logins: %v", err)
+	}
+
+	// any other outstanding reset link and every existing session is now stale
+	if err := app.store.UserTokens.DeleteAllForUser(c.Context(), user.ID, store.TokenPurposePasswordReset); err != nil {
+		app.logger.Errorf("Error deleting password reset tokens: %v", err)
+	}
+	if err := app.store.RefreshTokens.RevokeAllForUser(c.Context(), user.ID); err != nil {
+		app.logger.Errorf("Error revoking sessions: %v", err)
+	}
+	if err := app.store.APIKeys.DeleteAllForUser(c.Context(), user.ID); err != nil {
+		app.logger.Errorf("Error deleting api keys: %v", err)
+	}
+
+	app.recordAudit(c, store.AuditActionPasswordReset, store.AuditResourceUser, user.ID, user.ID, nil, nil)
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Password reset successfully",
+	})
+}
+
+type changePasswordPayload struct {
+	CurrentPassword *string `json:"current_password" validate:"required,max=72"`
+	NewPassword     *string `json:"new_password" validate:"required,min=8,max=72"`
+}
+
+// ChangePassword godoc
+//
+//	@Summary		Change password
+//	@Description	Change the password after confirming the current one. Every other session is signed out, API keys are revoked and a new access token is returned for this one.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID		path		string					true	"User ID"
+//	@Param			passwords	body		changePasswordPayload	true	"Current and new password"
+//	@Success		200			{object}	map[string]string		"Password changed and a new access token"
+//	@Failure		400			{object}	error					"Invalid request body"
+//	@Failure		401			{object}	error					"Current password is incorrect"
+//	@Failure		500			{object}	error					"Failed to change password"
+//
+// @Security		ApiKeyAuth
+//
+//	@Router			/users/{userID}/password [post]
+func (app *application) changePasswordHandler(c *fiber.Ctx) error {
+	user := getUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "user not found in context",
+		})
+	}
+
+	var payload changePasswordPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request format",
+		})
+	}
+
+	if err := validate.Struct(payload); err != nil {
+		validationErrors := err.(validator.ValidationErrors)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Validation failed",
+			"details": formatValidationErrors(validationErrors),
+		})
+	}
+
+	matches, err := user.CheckPassword(*payload.CurrentPassword)
+	if err != nil {
+		app.logger.Errorf("Error checking password: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Internal server error",
+		})
+	}
+	if !matches {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Current password is incorrect",
+		})
+	}
+
+	if err := user.SetPassword(*payload.NewPassword); err != nil {
+		app.logger.Errorf("Error hashing password: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Internal server error",
+		})
+	}
+
+	changedAt, err := app.store.Users.UpdatePassword(c.Context(), user.ID, user.Password)
+	if err != nil {
+		app.logger.Errorf("Error updating password: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to change password",
+		})
+	}
+
+	if err := app.store.UserTokens.DeleteAllForUser(c.Context(), user.ID, store.TokenPurposePasswordReset); err != nil {
+		app.logger.Errorf("Error deleting password reset tokens: %v", err)
+	}
+
+	// keep the session this request came from, sign out everything else
+	sessionID, err := primitive.ObjectIDFromHex(getAuthSessionIDFromContext(c))
+	if err != nil {
+		err = app.store.RefreshTokens.RevokeAllForUser(c.Context(), user.ID)
+	} else {
+		err = app.store.RefreshTokens.RevokeOtherSessions(c.Context(), user.ID, sessionID)
+	}
+	if err != nil {
+		app.logger.Errorf("Error revoking sessions: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to change password",
+		})
+	}
+
+	// api keys would otherwise outlive the old password
+	if err := app.store.APIKeys.DeleteAllForUser(c.Context(), user.ID); err != nil {
+		app.logger.Errorf("Error deleting api keys: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to change password",
+		})
+	}
+
+	app.recordAudit(c, store.AuditActionPasswordChange, store.AuditResourceUser, user.ID, user.ID, nil, nil)
+
+	// access tokens issued before the change are rejected from now on, including the one
+	// used for this request, so hand back a fresh one for the current session
+	claims := auth.NewClaims(user.ID.Hex(), app.config.auth.token.exp)
+	claims.SessionID = getAuthSessionIDFromContext(c)
+	claims.IssueAfter(changedAt)
+
+	token, err := app.authenticator.GenerateToken(claims)
+	if err != nil {
+		app.logger.Errorf("Error generating token: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create token",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":    "Password changed successfully",
+		"token":      token,
+		"token_type": "Bearer",
+		"expires_at": time.Unix(claims.ExpiresAt, 0).UTC(),
+	})
+}
+
+// sendPasswordReset replaces any outstanding reset token for the user and emails a new link
+func (app *application) sendPasswordReset(c *fiber.Ctx, user *store.User) error {
+	plaintext, hash, err := auth.NewOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	if err := app.store.UserTokens.DeleteAllForUser(c.Context(), user.ID, store.TokenPurposePasswordReset); err != nil {
+		return err
+	}
+
+	token := &store.UserToken{
+		UserID:    user.ID,
+		TokenHash: hash,
+		Purpose:   store.TokenPurposePasswordReset,
+		ExpiresAt: time.Now().Add(app.config.auth.passwordResetExp),
+	}
+
+	if err := app.store.UserTokens.Create(c.Context(), token); err != nil {
+		return err
+	}
+
+	app.sendEmail(user.Email, mailer.PasswordResetSubject, mailer.PasswordResetTemplate, map[string]string{
+		"Username":  user.Username,
+		"Link":      app.config.frontendURL + "/reset-password?token=" + url.QueryEscape(plaintext),
+		"ExpiresIn": humanizeDuration(app.config.auth.passwordResetExp),
+	})
+
+	return nil
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/FaustCelaj/GetFit.git/internal/store"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type restoreRevisionPayload struct {
+	ExpectedVersion int16 `json:"expected_version"`
+}
+
+// GetExerciseRevisions godoc
+//
+//	@Summary		List exercise revisions
+//	@Description	List the earlier versions of a custom exercise, each with the fields its next update changed
+//	@Tags			exercises
+//	@Produce		json
+//	@Param			userID		path		string			true	"User ID"
+//	@Param			exerciseID	path		string			true	"Exercise ID"
+//	@Param			cursor		query		string			false	"next_cursor of the previous page"
+//	@Param			limit		query		int				false	"Page size, up to 200"
+//	@Param			sort		query		string			false	"version or created_at (default -version), prefix with - for descending"
+//	@Success		200			{array}		store.Revision	"A page of revisions, next_cursor and has_more"
+//	@Failure		400			{object}	error			"Invalid ID format or list query"
+//	@Failure		500			{object}	error			"Failed to fetch revisions"
+//
+// @Security		ApiKeyAuth
+//
+//	@Router			/users/{userID}/exercise/{exerciseID}/revisions [get]
+func (app *application) getExerciseRevisionsHandler(c *fiber.Ctx) error {
+	return app.listRevisions(c, store.RevisionResourceExercise, getExerciseIDFromContext(c))
+}
+
+// RestoreExerciseRevision godoc
+//
+//	@Summary		Restore an exercise revision
+//	@Description	Put a custom exercise's fields back to how they were at an earlier version. The restore is saved as a new version, so it can be restored away again.
+//	@Tags			exercises
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID		path		string					true	"User ID"
+//	@Param			exerciseID	path		string					true	"Exercise ID"
+//	@Param			version		path		int						true	"Version to restore"
+//	@Param			restore		body		restoreRevisionPayload	true	"Current version of the exercise"
+//	@Success		200			{object}	store.Exercise			"Restored exercise"
+//	@Failure		400			{object}	error					"Invalid version or missing expected_version"
+//	@Failure		404			{object}	error					"Revision not found"
+//	@Failure		409			{object}	error					"Exercise was modified since"
+//	@Failure		500			{object}	error					"Failed to restore exercise"
+//
+// @Security		ApiKeyAuth
+//
+//	@Router			/users/{userID}/exercise/{exerciseID}/revisions/{version}/restore [post]
+func (app *application) restoreExerciseRevisionHandler(c *fiber.Ctx) error {
+	userID, exerciseID := getUserIDFromContext(c), getExerciseIDFromContext(c)
+	if userID == primitive.NilObjectID || exerciseID == primitive.NilObjectID {
+		missingID := "userID"
+		if exerciseID == primitive.NilObjectID {
+			missingID = "exerciseID"
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": missingID + " not found in context",
+		})
+	}
+
+	version, expectedVersion, err := parseRestoreRequest(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	exercise, err := app.store.Exercise.Restore(c.Context(), exerciseID, userID, version, expectedVersion)
+	if err != nil {
+		return app.restoreError(c, "exercise", err)
+	}
+
+	app.recordAudit(c, store.AuditActionRestore, store.AuditResourceExercise, exerciseID, userID, getExerciseFromContext(c), exercise)
+
+	return c.Status(fiber.StatusOK).JSON(exercise)
+}
+
+// GetRoutineRevisions godoc
+//
+//	@Summary		List routine revisions
+//	@Description	List the earlier versions of a routine, each with the fields its next update changed
+//	@Tags			routines
+//	@Produce		json
+//	@Param			userID		path		string			true	"User ID"
+//	@Param			routineID	path		string			true	"Routine ID"
+//	@Param			cursor		query		string			false	"next_cursor of the previous page"
+//	@Param			limit		query		int				false	"Page size, up to 200"
+//	@Param			sort		query		string			false	"version or created_at (default -version), prefix with - for descending"
+//	@Success		200			{array}		store.Revision	"A page of revisions, next_cursor and has_more"
+//	@Failure		400			{object}	error			"Invalid ID format or list query"
+//	@Failure		500			{object}	error			"Failed to fetch revisions"
+//
+// @Security		ApiKeyAuth
+//
+//	@Router			/users/{userID}/routine/{routineID}/revisions [get]
+func (app *application) getRoutineRevisionsHandler(c *fiber.Ctx) error {
+	return app.listRevisions(c, store.RevisionResourceRoutine, getRoutineIDFromContext(c))
+}
+
+// RestoreRoutineRevision godoc
+//
+//	@Summary		Restore a routine revision
+//	@Description	Put a routine's title, description and exercises back to how they were at an earlier version. A version using exercises that were deleted since cannot be restored. The restore is saved as a new version.
+//	@Tags			routines
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID		path		string					true	"User ID"
+//	@Param			routineID	path		string					true	"Routine ID"
+//	@Param			version		path		int						true	"Version to restore"
+//	@Param			restore		body		restoreRevisionPayload	true	"Current version of the routine"
+//	@Success		200			{object}	store.Routine			"Restored routine"
+//	@Failure		400			{object}	error					"Invalid version or missing expected_version"
+//	@Failure		404			{object}	error					"Revision not found"
+//	@Failure		409			{object}	error					"Routine was modified since, or the version uses deleted exercises"
+//	@Failure		500			{object}	error					"Failed to restore routine"
+//
+// @Security		ApiKeyAuth
+//
+//	@Router			/users/{userID}/routine/{routineID}/revisions/{version}/restore [post]
+func (app *application) restoreRoutineRevisionHandler(c *fiber.Ctx) error {
+	userID, routineID := getUserIDFromContext(c), getRoutineIDFromContext(c)
+	if userID == primitive.NilObjectID || routineID == primitive.NilObjectID {
+		missingID := "userID"
+		if routineID == primitive.NilObjectID {
+			missingID = "routineID"
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": missingID + " not found in context",
+		})
+	}
+
+	version, expectedVersion, err := parseRestoreRequest(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	routine, err := app.store.Routine.Restore(c.Context(), routineID, userID, version, expectedVersion)
+	if err != nil {
+		return app.restoreError(c, "routine", err)
+	}
+
+	app.recordAudit(c, store.AuditActionRestore, store.AuditResourceRoutine, routineID, userID, getRoutineFromContext(c), routine)
+
+	return c.Status(fiber.StatusOK).JSON(routine)
+}
+
+func (app *application) listRevisions(c *fiber.Ctx, resourceType string, resourceID primitive.ObjectID) error {
+	userID := getUserIDFromContext(c)
+	if userID == primitive.NilObjectID || resourceID == primitive.NilObjectID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "userID or " + resourceType + "ID not found in context",
+		})
+	}
+
+	query := parseListQuery(c)
+	page, err := app.store.Revisions.List(c.Context(), resourceType, resourceID, userID, query)
+	if err != nil {
+		if errors.Is(err, store.ErrInvalidListQuery) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		app.logger.Errorf("Error fetching %s revisions: %v", resourceType, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch revisions",
+		})
+	}
+
+	return respondWithPage(c, "revisions", page, query)
+}
+
+// parseRestoreRequest reads the version to restore from the path and the current one from the body
+func parseRestoreRequest(c *fiber.Ctx) (version, expectedVersion int16, err error) {
+	parsed, err := strconv.ParseInt(c.Params("version"), 10, 16)
+	if err != nil || parsed < 1 {
+		return 0, 0, errors.New("version must be a positive number")
+	}
+
+	var payload restoreRevisionPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return 0, 0, errors.New("invalid request body")
+	}
+	if payload.ExpectedVersion == 0 {
+		return 0, 0, errors.New("expected_version is required")
+	}
+
+	return int16(parsed), payload.ExpectedVersion, nil
+}
+
+func (app *application) restoreError(c *fiber.Ctx, resource string, err error) error {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Revision not found",
+		})
+	case errors.Is(err, store.ErrVersionMismatch):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "This record has been modified since you last viewed it. Please refresh and try again.",
+		})
+	case errors.Is(err, store.ErrStaleRevision):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "This version uses exercises that were deleted since and cannot be restored",
+		})
+	}
+
+	app.logger.Errorf("Error restoring %s: %v", resource, err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "Failed to restore " + resource,
+	})
+}
@@ -1,6 +1,9 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/FaustCelaj/GetFit.git/internal/store"
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -17,7 +20,9 @@ import (
 //	@Param			userID	path		string			true	"User ID"
 //	@Param			routine	body		store.Routine	true	"Routine information"
 //	@Success		201		{object}	string			"Routine created successfully"
-//	@Failure		400		{object}	error			"Invalid request body or missing fields"
+//	@Failure		400		{object}	error			"Invalid request body, missing fields or a set that does not fit its exercise"
+//	@Failure		404		{object}	error			"An exercise is not the user's or in the catalog"
+//	@Failure		409		{object}	error			"An exercise is archived"
 //	@Failure		500		{object}	error			"Failed to create routine"
 //
 // @Security		ApiKeyAuth
@@ -45,11 +50,32 @@ func (app *application) createRoutineHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	exerciseIDs := make([]primitive.ObjectID, len(routine.Exercises))
+	for i, exercise := range routine.Exercises {
+		exerciseIDs[i] = exercise.ExerciseID
+	}
+	exercises, err := app.loadReferencedExercises(c, userID, exerciseIDs)
+	if err != nil {
+		return app.referencedExercisesError(c, "routine", err)
+	}
+
+	// every template set must fit the tracking type of its exercise
+	for i, routineExercise := range routine.Exercises {
+		tracking := exercises[routineExercise.ExerciseID].Tracking()
+		for j, set := range routineExercise.Sets {
+			if err := set.SetMetrics.Validate(tracking); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": fmt.Sprintf("exercise %d, template set %d: %v", i+1, j+1, err),
+				})
+			}
+		}
+	}
+
 	// Set the userID for the routine
 	routine.UserID = userID
 
 	// Call the Create method in RoutineStore
-	err := app.store.Routine.Create(c.Context(), &routine, userID)
+	err = app.store.Routine.Create(c.Context(), &routine, userID)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error":   "failed to create routine",
@@ -71,8 +97,12 @@ func (app *application) createRoutineHandler(c *fiber.Ctx) error {
 //	@Accept			json
 //	@Produce		json
 //	@Param			userID	path		string			true	"User ID"
-//	@Success		200		{array}		store.Routine	"List of routines"
-//	@Failure		400		{object}	error			"Invalid user ID"
+//	@Param			cursor	query		string			false	"next_cursor of the previous page"
+//	@Param			limit	query		int				false	"Page size, up to 200"
+//	@Param			sort	query		string			false	"title, created_at or updated_at (default -updated_at), prefix with - for descending"
+//	@Param			fields	query		string			false	"Comma separated fields to return"
+//	@Success		200		{array}		store.Routine	"A page of routines, next_cursor and has_more"
+//	@Failure		400		{object}	error			"Invalid user ID or list query"
 //	@Failure		500		{object}	error			"Failed to fetch routines"
 //
 // @Security		ApiKeyAuth
@@ -86,18 +116,21 @@ func (app *application) getAllUserRoutinesIDHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	routines, err := app.store.Routine.GetAllUserRoutines(c.Context(), userID)
+	query := parseListQuery(c)
+	page, err := app.store.Routine.List(c.Context(), userID, query)
 	if err != nil {
+		if errors.Is(err, store.ErrInvalidListQuery) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error":   "failed to fetch routines",
 			"details": err.Error(),
 		})
 	}
 
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"message":  "routines retrieved successfully",
-		"routines": routines,
-	})
+	return respondWithPage(c, "routines", page, query)
 }
 
 // GetRoutineByID godoc
@@ -226,6 +259,8 @@ func (app *application) patchRoutineHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	app.auditRoutineUpdate(c, routineID, userID)
+
 	// Return a success response
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "Routine updated successfully",
@@ -267,7 +302,19 @@ func (app *application) deleteRoutineHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	app.recordAudit(c, store.AuditActionDelete, store.AuditResourceRoutine, routineID, userID, getRoutineFromContext(c), nil)
+
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "Routine was successfully deleted",
 	})
 }
+
+// auditRoutineUpdate records a routine change against the copy loaded by routineContextMiddleware
+func (app *application) auditRoutineUpdate(c *fiber.Ctx, routineID, userID primitive.ObjectID) {
+	after, err := app.store.Routine.GetByID(c.Context(), routineID, userID)
+	if err != nil {
+		app.logger.Errorf("Error fetching updated routine for audit: %v", err)
+	}
+
+	app.recordAudit(c, store.AuditActionUpdate, store.AuditResourceRoutine, routineID, userID, getRoutineFromContext(c), after)
+}
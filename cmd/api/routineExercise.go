@@ -1,6 +1,8 @@
 package main
 
 import (
+	"fmt"
+
 	"github.com/FaustCelaj/GetFit.git/internal/store"
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -37,7 +39,7 @@ func (app *application) addExerciseToRoutineHandler(c *fiber.Ctx) error {
 
 	if routineID == "" || exerciseID == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "userID, routineID, and exerciseID are required",
+			"error": "routineID and exerciseID are required",
 		})
 	}
 
@@ -73,6 +75,18 @@ func (app *application) addExerciseToRoutineHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	if err := validateTemplateSets(c, payload.TemplateSets); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if exercise := getExerciseFromContext(c); exercise != nil && exercise.ArchivedAt != nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Archived exercises cannot be added to routines, unarchive it first",
+		})
+	}
+
 	// Add the exercise with template sets to the routine
 	err = app.store.Routine.AddExerciseToRoutine(
 		c.Context(),
@@ -95,6 +109,22 @@ func (app *application) addExerciseToRoutineHandler(c *fiber.Ctx) error {
 	})
 }
 
+// validateTemplateSets checks each set against the tracking type of the exercise in context
+func validateTemplateSets(c *fiber.Ctx, sets []store.TemplateSet) error {
+	exercise := getExerciseFromContext(c)
+	if exercise == nil {
+		return fmt.Errorf("exercise not found in context")
+	}
+
+	for i, set := range sets {
+		if err := set.SetMetrics.Validate(exercise.Tracking()); err != nil {
+			return fmt.Errorf("template set %d: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
+
 // UpdateExerciseInRoutine godoc
 //
 //	@Summary		Update exercise in routine
@@ -114,20 +144,19 @@ func (app *application) addExerciseToRoutineHandler(c *fiber.Ctx) error {
 //
 //	@Router			/users/{userID}/routine/{routineID}/exercise/{exerciseID} [patch]
 func (app *application) updateExerciseInRoutineHandler(c *fiber.Ctx) error {
-	userID := c.Params("userID")
-	routineID := c.Params("routineID")
-	exerciseID := c.Params("exerciseID")
-
-	if userID == "" || routineID == "" || exerciseID == "" {
+	userObjectID := getUserIDFromContext(c)
+	if userObjectID == primitive.NilObjectID {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "userID, routineID, and exerciseID are required",
+			"error": "userID not found in context",
 		})
 	}
 
-	userObjectID, err := primitive.ObjectIDFromHex(userID)
-	if err != nil {
+	routineID := c.Params("routineID")
+	exerciseID := c.Params("exerciseID")
+
+	if routineID == "" || exerciseID == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "invalid userID format",
+			"error": "routineID and exerciseID are required",
 		})
 	}
 
@@ -163,6 +192,12 @@ func (app *application) updateExerciseInRoutineHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	if err := validateTemplateSets(c, payload.TemplateSets); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
 	// Update the exercise template sets in the routine
 	err = app.store.Routine.UpdateExerciseInRoutine(
 		c.Context(),
@@ -180,6 +215,8 @@ func (app *application) updateExerciseInRoutineHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	app.auditRoutineUpdate(c, routineObjectID, userObjectID)
+
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "exercise template sets updated successfully",
 	})
@@ -204,20 +241,19 @@ func (app *application) updateExerciseInRoutineHandler(c *fiber.Ctx) error {
 //
 //	@Router			/users/{userID}/routine/{routineID}/exercise/{exerciseID} [delete]
 func (app *application) removeExerciseFromRoutineHandler(c *fiber.Ctx) error {
-	userID := c.Params("userID")
-	routineID := c.Params("routineID")
-	exerciseID := c.Params("exerciseID")
-
-	if userID == "" || routineID == "" || exerciseID == "" {
+	userObjectID := getUserIDFromContext(c)
+	if userObjectID == primitive.NilObjectID {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "userID, routineID, and exerciseID are required",
+			"error": "userID not found in context",
 		})
 	}
 
-	userObjectID, err := primitive.ObjectIDFromHex(userID)
-	if err != nil {
+	routineID := c.Params("routineID")
+	exerciseID := c.Params("exerciseID")
+
+	if routineID == "" || exerciseID == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "invalid userID format",
+			"error": "routineID and exerciseID are required",
 		})
 	}
 
@@ -268,6 +304,8 @@ func (app *application) removeExerciseFromRoutineHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	app.auditRoutineUpdate(c, routineObjectID, userObjectID)
+
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "exercise removed from routine successfully",
 	})
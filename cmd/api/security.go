@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/FaustCelaj/GetFit.git/internal/store"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ipRateLimitMiddleware throttles every request from one IP to the authentication routes
+func (app *application) ipRateLimitMiddleware() fiber.Handler {
+	return limiter.New(limiter.Config{
+		Max:        app.config.rateLimit.ipMax,
+		Expiration: app.config.rateLimit.ipWindow,
+		KeyGenerator: func(c *fiber.Ctx) string {
+			return "ip:" + c.IP()
+		},
+		LimitReached: app.rateLimitReached,
+	})
+}
+
+// accountRateLimitMiddleware throttles credential checks against one email address,
+// no matter how many IPs they come from
+func (app *application) accountRateLimitMiddleware() fiber.Handler {
+	return limiter.New(limiter.Config{
+		Max:        app.config.rateLimit.accountMax,
+		Expiration: app.config.rateLimit.accountWindow,
+		KeyGenerator: func(c *fiber.Ctx) string {
+			var payload struct {
+				Email string `json:"email"`
+			}
+			if err := c.BodyParser(&payload); err != nil || payload.Email == "" {
+				return "ip:" + c.IP()
+			}
+			return "account:" + strings.ToLower(strings.TrimSpace(payload.Email))
+		},
+		LimitReached: app.rateLimitReached,
+	})
+}
+
+func (app *application) rateLimitReached(c *fiber.Ctx) error {
+	app.logSecurityEvent(c, store.SecurityEventRateLimited, nil, "", map[string]interface{}{
+		"path": c.Path(),
+	})
+
+	return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+		"error": "Too many requests, please try again later",
+	})
+}
+
+// lockedOut responds with 429 if the account is temporarily locked
+func (app *application) lockedOut(c *fiber.Ctx, user *store.User) (bool, error) {
+	if user.LockedUntil == nil || !user.LockedUntil.After(time.Now()) {
+		return false, nil
+	}
+
+	app.logSecurityEvent(c, store.SecurityEventLoginBlocked, &user.ID, user.Email, map[string]interface{}{
+		"locked_until": *user.LockedUntil,
+	})
+
+	retryAfter := int(time.Until(*user.LockedUntil).Seconds()) + 1
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+
+	return true, c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+		"error":        "Too many failed attempts, the account is temporarily locked",
+		"locked_until": user.LockedUntil.UTC(),
+	})
+}
+
+// recordFailedLogin counts a failed credential check and locks the account once the
+// threshold is reached. Each further failure doubles the lock, up to the configured maximum.
+func (app *application) recordFailedLogin(c *fiber.Ctx, user *store.User, eventType string) {
+	app.logSecurityEvent(c, eventType, &user.ID, user.Email, nil)
+
+	attempts, err := app.store.Users.IncrementFailedLogins(c.Context(), user.ID)
+	if err != nil {
+		app.logger.Errorf("Error recording failed login: %v", err)
+		return
+	}
+
+	lockout := lockoutDuration(attempts, app.config.rateLimit.lockoutThreshold, app.config.rateLimit.lockoutBase, app.config.rateLimit.lockoutMax)
+	if lockout == 0 {
+		return
+	}
+
+	until := time.Now().Add(lockout)
+	if err := app.store.Users.LockUntil(c.Context(), user.ID, until); err != nil {
+		app.logger.Errorf("Error locking user: %v", err)
+		return
+	}
+
+	app.logSecurityEvent(c, store.SecurityEventAccountLocked, &user.ID, user.Email, map[string]interface{}{
+		"failed_attempts": attempts,
+		"locked_until":    until,
+	})
+}
+
+// lockoutDuration returns how long to lock an account after the given number of failed attempts
+func lockoutDuration(attempts, threshold int, base, max time.Duration) time.Duration {
+	if threshold <= 0 || attempts < threshold {
+		return 0
+	}
+
+	lockout := base
+	for i := threshold; i < attempts && lockout < max; i++ {
+		lockout *= 2
+	}
+
+	if lockout > max {
+		lockout = max
+	}
+	return lockout
+}
+
+// logSecurityEvent writes to the security log without failing the request
+func (app *application) logSecurityEvent(c *fiber.Ctx, eventType string, userID *primitive.ObjectID, email string, details map[string]interface{}) {
+	event := &store.SecurityEvent{
+		Type:      eventType,
+		UserID:    userID,
+		Email:     email,
+		IP:        c.IP(),
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+		Details:   details,
+	}
+
+	// the request context is recycled by fiber once the handler returns
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := app.store.SecurityEvents.Create(ctx, event); err != nil {
+		app.logger.Errorf("Error writing security event %s: %v", eventType, err)
+	}
+}
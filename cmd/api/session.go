@@ -0,0 +1,160 @@
+package main
+
+import (
+	"errors"
+	"time"
+
+	"github.com/FaustCelaj/GetFit.git/internal/store"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type sessionResponse struct {
+	ID         string    `json:"id"`
+	Device     string    `json:"device"`
+	IP         string    `json:"ip"`
+	Current    bool      `json:"current"`
+	StartedAt  time.Time `json:"started_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// GetSessions godoc
+//
+//	@Summary		List active sessions
+//	@Description	List every active login session for the user with its device and IP
+//	@Tags			sessions
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID	path		string			true	"User ID"
+//	@Success		200		{array}		sessionResponse	"List of sessions"
+//	@Failure		400		{object}	error			"Invalid user ID"
+//	@Failure		500		{object}	error			"Failed to fetch sessions"
+//
+//	@Security		ApiKeyAuth
+//
+//	@Router			/users/{userID}/sessions [get]
+func (app *application) getSessionsHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == primitive.NilObjectID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "userID not found in context",
+		})
+	}
+
+	tokens, err := app.store.RefreshTokens.GetActiveSessions(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "failed to fetch sessions",
+			"details": err.Error(),
+		})
+	}
+
+	currentSessionID := getAuthSessionIDFromContext(c)
+
+	sessions := make([]sessionResponse, 0, len(tokens))
+	for _, token := range tokens {
+		sessions = append(sessions, sessionResponse{
+			ID:         token.FamilyID.Hex(),
+			Device:     token.UserAgent,
+			IP:         token.IP,
+			Current:    token.FamilyID.Hex() == currentSessionID,
+			StartedAt:  token.SessionStartedAt,
+			LastUsedAt: token.CreatedAt,
+			ExpiresAt:  token.ExpiresAt,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":  "sessions retrieved successfully",
+		"sessions": sessions,
+	})
+}
+
+// RevokeSession godoc
+//
+//	@Summary		Revoke a session
+//	@Description	Revoke a single login session, its refresh token and access tokens stop working at once
+//	@Tags			sessions
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID		path		string	true	"User ID"
+//	@Param			sessionID	path		string	true	"Session ID"
+//	@Success		200			{object}	string	"Session revoked successfully"
+//	@Failure		400			{object}	error	"Invalid ID format"
+//	@Failure		404			{object}	error	"Session not found"
+//	@Failure		500			{object}	error	"Failed to revoke session"
+//
+//	@Security		ApiKeyAuth
+//
+//	@Router			/users/{userID}/sessions/{sessionID} [delete]
+func (app *application) revokeSessionHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == primitive.NilObjectID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "userID not found in context",
+		})
+	}
+
+	sessionID, err := primitive.ObjectIDFromHex(c.Params("sessionID"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid sessionID format",
+		})
+	}
+
+	if err := app.store.RefreshTokens.RevokeSession(c.Context(), userID, sessionID); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "session not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "failed to revoke session",
+			"details": err.Error(),
+		})
+	}
+
+	app.recordAudit(c, store.AuditActionRevoke, store.AuditResourceSession, sessionID, userID, nil, nil)
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "session revoked successfully",
+	})
+}
+
+// RevokeAllSessions godoc
+//
+//	@Summary		Revoke all sessions
+//	@Description	Revoke every login session of the user, including the current one. Their refresh and access tokens stop working at once.
+//	@Tags			sessions
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID	path		string	true	"User ID"
+//	@Success		200		{object}	string	"Sessions revoked successfully"
+//	@Failure		400		{object}	error	"Invalid user ID"
+//	@Failure		500		{object}	error	"Failed to revoke sessions"
+//
+//	@Security		ApiKeyAuth
+//
+//	@Router			/users/{userID}/sessions [delete]
+func (app *application) revokeAllSessionsHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == primitive.NilObjectID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "userID not found in context",
+		})
+	}
+
+	if err := app.store.RefreshTokens.RevokeAllForUser(c.Context(), userID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "failed to revoke sessions",
+			"details": err.Error(),
+		})
+	}
+
+	app.recordAudit(c, store.AuditActionRevoke, store.AuditResourceSession, userID, userID, nil, nil)
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "sessions revoked successfully",
+	})
+}
@@ -0,0 +1,373 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/FaustCelaj/GetFit.git/internal/auth"
+	"github.com/FaustCelaj/GetFit.git/internal/store"
+	"github.com/go-playground/validator"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const recoveryCodeCount = 10
+
+// EnrollTwoFactor godoc
+//
+//	@Summary		Start 2FA enrollment
+//	@Description	Generate a new TOTP secret and the otpauth URI to show as a QR code. 2FA stays off until the secret is confirmed.
+//	@Tags			two-factor
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID	path		string				true	"User ID"
+//	@Success		200		{object}	map[string]string	"TOTP secret and otpauth URI"
+//	@Failure		409		{object}	error				"2FA is already enabled"
+//	@Failure		500		{object}	error				"Failed to start enrollment"
+//
+// @Security		ApiKeyAuth
+//
+//	@Router			/users/{userID}/2fa/enroll [post]
+func (app *application) enrollTwoFactorHandler(c *fiber.Ctx) error {
+	user := getUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "user not found in context",
+		})
+	}
+
+	if user.TOTPEnabled {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Two-factor authentication is already enabled",
+		})
+	}
+
+	secret, err := app.totp.GenerateSecret()
+	if err != nil {
+		app.logger.Errorf("Error generating totp secret: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to start enrollment",
+		})
+	}
+
+	if err := app.store.Users.SetTOTPSecret(c.Context(), user.ID, secret); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "Two-factor authentication is already enabled",
+			})
+		}
+		app.logger.Errorf("Error storing totp secret: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to start enrollment",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":     "Scan the QR code with your authenticator app and confirm with a code",
+		"secret":      secret,
+		"otpauth_uri": app.totp.URI(secret, user.Email),
+	})
+}
+
+type confirmTwoFactorPayload struct {
+	Code *string `json:"code" validate:"required"`
+}
+
+// ConfirmTwoFactor godoc
+//
+//	@Summary		Confirm 2FA enrollment
+//	@Description	Confirm the enrolled secret with a code from the authenticator app. Enables 2FA and returns one-time recovery codes, which are only shown once.
+//	@Tags			two-factor
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID	path		string					true	"User ID"
+//	@Param			code	body		confirmTwoFactorPayload	true	"TOTP code"
+//	@Success		200		{object}	map[string]string		"2FA enabled with recovery codes"
+//	@Failure		400		{object}	error					"Invalid code or no enrollment in progress"
+//	@Failure		409		{object}	error					"2FA is already enabled"
+//	@Failure		500		{object}	error					"Failed to enable 2FA"
+//
+// @Security		ApiKeyAuth
+//
+//	@Router			/users/{userID}/2fa/confirm [post]
+func (app *application) confirmTwoFactorHandler(c *fiber.Ctx) error {
+	user := getUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "user not found in context",
+		})
+	}
+
+	var payload confirmTwoFactorPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request format",
+		})
+	}
+
+	if err := validate.Struct(payload); err != nil {
+		validationErrors := err.(validator.ValidationErrors)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Validation failed",
+			"details": formatValidationErrors(validationErrors),
+		})
+	}
+
+	if user.TOTPEnabled {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Two-factor authentication is already enabled",
+		})
+	}
+
+	if user.TOTPSecret == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Start enrollment before confirming",
+		})
+	}
+
+	step, ok := app.totp.Validate(user.TOTPSecret, *payload.Code)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid code",
+		})
+	}
+
+	recoveryCodes, err := auth.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		app.logger.Errorf("Error generating recovery codes: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to enable two-factor authentication",
+		})
+	}
+
+	hashes := make([]string, 0, len(recoveryCodes))
+	for _, code := range recoveryCodes {
+		hashes = append(hashes, auth.HashToken(code))
+	}
+
+	if err := app.store.Users.EnableTOTP(c.Context(), user.ID, step, hashes); err != nil {
+		app.logger.Errorf("Error enabling totp: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to enable two-factor authentication",
+		})
+	}
+
+	app.auditUserUpdate(c, store.AuditActionTwoFactorEnable, user)
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":        "Two-factor authentication enabled, store your recovery codes somewhere safe",
+		"recovery_codes": recoveryCodes,
+	})
+}
+
+type disableTwoFactorPayload struct {
+	Password *string `json:"password" validate:"required,max=72"`
+}
+
+// DisableTwoFactor godoc
+//
+//	@Summary		Disable 2FA
+//	@Description	Turn off two-factor authentication after re-entering the account password
+//	@Tags			two-factor
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID		path		string					true	"User ID"
+//	@Param			password	body		disableTwoFactorPayload	true	"Account password"
+//	@Success		200			{object}	string					"2FA disabled"
+//	@Failure		400			{object}	error					"Invalid request body"
+//	@Failure		401			{object}	error					"Password is incorrect"
+//	@Failure		500			{object}	error					"Failed to disable 2FA"
+//
+// @Security		ApiKeyAuth
+//
+//	@Router			/users/{userID}/2fa/disable [post]
+func (app *application) disableTwoFactorHandler(c *fiber.Ctx) error {
+	user := getUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "user not found in context",
+		})
+	}
+
+	var payload disableTwoFactorPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request format",
+		})
+	}
+
+	if err := validate.Struct(payload); err != nil {
+		validationErrors := err.(validator.ValidationErrors)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Validation failed",
+			"details": formatValidationErrors(validationErrors),
+		})
+	}
+
+	matches, err := user.CheckPassword(*payload.Password)
+	if err != nil {
+		app.logger.Errorf("Error checking password: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Internal server error",
+		})
+	}
+	if !matches {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Password is incorrect",
+		})
+	}
+
+	if err := app.store.Users.DisableTOTP(c.Context(), user.ID); err != nil {
+		app.logger.Errorf("Error disabling totp: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to disable two-factor authentication",
+		})
+	}
+
+	app.auditUserUpdate(c, store.AuditActionTwoFactorDisable, user)
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Two-factor authentication disabled",
+	})
+}
+
+type createTokenTwoFactorPayload struct {
+	MFAToken     *string `json:"mfa_token" validate:"required"`
+	Code         *string `json:"code"`
+	RecoveryCode *string `json:"recovery_code"`
+}
+
+// CreateTokenTwoFactor godoc
+//
+//	@Summary		Complete a 2FA login
+//	@Description	Exchange the mfa_token from /authentication/token and a TOTP code, or a one-time recovery code, for access and refresh tokens. An mfa_token is good for a single attempt, after a wrong code log in again.
+//	@Tags			authentication
+//	@Accept			json
+//	@Produce		json
+//	@Param			credentials	body		createTokenTwoFactorPayload	true	"MFA token with a TOTP or recovery code"
+//	@Success		201			{object}	map[string]string			"Access token, refresh token and their expiry"
+//	@Failure		400			{object}	error						"Invalid request body"
+//	@Failure		401			{object}	error						"Invalid token or code"
+//	@Failure		500			{object}	error						"Failed to create token"
+//	@Router			/authentication/token/2fa [post]
+func (app *application) createTokenTwoFactorHandler(c *fiber.Ctx) error {
+	var payload createTokenTwoFactorPayload
+
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request format",
+		})
+	}
+
+	if err := validate.Struct(payload); err != nil {
+		validationErrors := err.(validator.ValidationErrors)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Validation failed",
+			"details": formatValidationErrors(validationErrors),
+		})
+	}
+
+	if (payload.Code == nil) == (payload.RecoveryCode == nil) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Provide either code or recovery_code",
+		})
+	}
+
+	claims, err := app.authenticator.ValidateToken(*payload.MFAToken)
+	if err != nil || claims.Purpose != auth.PurposeMFA {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid or expired mfa_token, log in again",
+		})
+	}
+
+	userID, err := primitive.ObjectIDFromHex(claims.Subject)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid or expired mfa_token, log in again",
+		})
+	}
+
+	user, err := app.store.Users.GetByID(c.Context(), userID)
+	if err != nil {
+		app.logger.Errorf("Error fetching user: %v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid or expired mfa_token, log in again",
+		})
+	}
+
+	if !user.TOTPEnabled || user.Disabled || user.PasswordResetRequired {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid or expired mfa_token, log in again",
+		})
+	}
+
+	// a password change since the first step voids the token like an access token
+	if user.PasswordChangedAt != nil && claims.IssuedAtOrBefore(*user.PasswordChangedAt) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid or expired mfa_token, log in again",
+		})
+	}
+
+	if locked, err := app.lockedOut(c, user); locked {
+		return err
+	}
+
+	// the token is good for one attempt, taken before the second factor so a replay
+	// cannot spend a TOTP step or recovery code
+	if _, err := app.store.UserTokens.Consume(c.Context(), auth.HashToken(*payload.MFAToken), store.TokenPurposeMFA); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Invalid or expired mfa_token, log in again",
+			})
+		}
+		app.logger.Errorf("Error consuming mfa token: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Internal server error",
+		})
+	}
+
+	if payload.Code != nil {
+		// the store refuses the step again, in case another login used it since
+		step, ok := app.totp.ValidateAfter(user.TOTPSecret, *payload.Code, user.TOTPLastUsedStep)
+		if ok {
+			err = app.store.Users.UseTOTPStep(c.Context(), user.ID, step)
+		}
+		if !ok || errors.Is(err, store.ErrNotFound) {
+			app.recordFailedLogin(c, user, store.SecurityEventMFAFailed)
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Invalid code",
+			})
+		}
+	} else {
+		_, hash, ok := auth.RedeemRecoveryCode(user.RecoveryCodes, *payload.RecoveryCode)
+		if ok {
+			err = app.store.Users.UseRecoveryCode(c.Context(), user.ID, hash)
+		}
+		if !ok || errors.Is(err, store.ErrNotFound) {
+			app.recordFailedLogin(c, user, store.SecurityEventMFAFailed)
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Invalid recovery code",
+			})
+		}
+	}
+	if err != nil {
+		app.logger.Errorf("Error recording second factor: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Internal server error",
+		})
+	}
+
+	if err := app.store.Users.ResetFailedLogins(c.Context(), user.ID); err != nil {
+		app.logger.Errorf("Error resetting failed logins: %v", err)
+	}
+	app.cancelScheduledDeletion(c, user)
+
+	refreshPlaintext, refreshToken, err := app.startSession(c, user.ID)
+	if err != nil {
+		app.logger.Errorf("Error creating refresh token: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create token",
+		})
+	}
+
+	return app.respondWithTokens(c, fiber.StatusCreated, user.ID, refreshPlaintext, refreshToken)
+}
@@ -2,11 +2,10 @@ package main
 
 import (
 	"errors"
+	"time"
 
 	"github.com/FaustCelaj/GetFit.git/internal/store"
 	"github.com/gofiber/fiber/v2"
-	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // CreateUser godoc
@@ -55,6 +54,7 @@ func (app *application) createUserHandler(c *fiber.Ctx) error {
 //	@Param			userID	path		string		true	"User ID"
 //	@Success		200		{object}	store.User	"User information"
 //	@Failure		400		{object}	error		"Invalid user ID format"
+//	@Failure		403		{object}	error		"User ID does not match the authenticated user"
 //	@Failure		404		{object}	error		"User not found"
 //	@Failure		500		{object}	error		"Failed to fetch user"
 //
@@ -62,31 +62,10 @@ func (app *application) createUserHandler(c *fiber.Ctx) error {
 //
 //	@Router			/user/{userID} [get]
 func (app *application) getUserHandler(c *fiber.Ctx) error {
-
-	userIDStr := c.Params("userID")
-	if userIDStr == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "userID is required",
-		})
-	}
-
-	userID, err := primitive.ObjectIDFromHex(userIDStr)
-	if err != nil {
+	user := getUserFromContext(c)
+	if user == nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "invalid user ID format",
-		})
-	}
-
-	user, err := app.store.Users.GetByID(c.Context(), userID)
-	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"error": "user not found",
-			})
-		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   "failed to fetch user",
-			"details": err.Error(),
+			"error": "user not found in context",
 		})
 	}
 
@@ -107,7 +86,7 @@ type updateUserPayload struct {
 // UpdateUser godoc
 //
 //	@Summary		Update user information
-//	@Description	Update one or more fields of a user's profile
+//	@Description	Update one or more fields of a user's profile. A new email is held as pending until it is confirmed, sending the current email again cancels a pending change.
 //	@Tags			users
 //	@Accept			json
 //	@Produce		json
@@ -115,6 +94,7 @@ type updateUserPayload struct {
 //	@Param			userData	body		updateUserPayload	true	"Updated user information"
 //	@Success		200			{object}	error				"User updated successfully"
 //	@Failure		400			{object}	error				"Invalid request body or missing fields"
+//	@Failure		403			{object}	error				"User ID does not match the authenticated user"
 //	@Failure		409			{object}	error				"Version conflict - record has been modified"
 //	@Failure		500			{object}	error				"Failed to update user"
 //
@@ -122,13 +102,13 @@ type updateUserPayload struct {
 //
 //	@Router			/user/{userID} [patch]
 func (app *application) patchUserHandler(c *fiber.Ctx) error {
-	userIDStr := c.Params("userID")
-	userID, err := primitive.ObjectIDFromHex(userIDStr)
-	if err != nil {
+	user := getUserFromContext(c)
+	if user == nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid user ID format",
+			"error": "user not found in context",
 		})
 	}
+	userID := user.ID
 
 	var payload updateUserPayload
 	if err := c.BodyParser(&payload); err != nil {
@@ -154,14 +134,6 @@ func (app *application) patchUserHandler(c *fiber.Ctx) error {
 		updates["username"] = *payload.Username
 	}
 
-	if payload.Email != nil {
-		if *payload.Email == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Email cannot be empty",
-			})
-		}
-		updates["email"] = *payload.Email
-	}
 	if payload.FirstName != nil {
 		updates["first_name"] = *payload.FirstName
 	}
@@ -178,60 +150,151 @@ func (app *application) patchUserHandler(c *fiber.Ctx) error {
 		updates["bio"] = *payload.Bio
 	}
 
-	if len(updates) == 0 {
+	// a new email address is held as pending until it has been confirmed
+	var pendingEmail string
+	if payload.Email != nil && *payload.Email != user.Email {
+		if err := validate.Var(*payload.Email, "required,email,max=50"); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Email must be a valid email address",
+			})
+		}
+
+		_, err := app.store.Users.GetByEmail(c.Context(), *payload.Email)
+		if err == nil {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "User with this email already exists",
+			})
+		}
+		if !errors.Is(err, store.ErrNotFound) {
+			app.logger.Errorf("Error fetching user by email: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Internal server error",
+			})
+		}
+
+		pendingEmail = *payload.Email
+	}
+
+	// setting the email back to the current address drops a pending change
+	cancelPendingEmail := payload.Email != nil && *payload.Email == user.Email && user.PendingEmail != nil
+
+	if len(updates) == 0 && pendingEmail == "" && !cancelPendingEmail {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "No fields to update",
 		})
 	}
 
-	if err := app.store.Users.Update(c.Context(), userID, updates, payload.ExpectedVersion); err != nil {
-		if errors.Is(err, store.ErrVersionMismatch) {
-			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
-				"error": "This record has been modified since you last viewed it. Please refresh and try again.",
+	if len(updates) > 0 {
+		if err := app.store.Users.Update(c.Context(), userID, updates, payload.ExpectedVersion); err != nil {
+			if errors.Is(err, store.ErrVersionMismatch) {
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+					"error": "This record has been modified since you last viewed it. Please refresh and try again.",
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to update user",
+			})
+		}
+	}
+
+	if cancelPendingEmail {
+		if err := app.store.Users.ClearPendingEmail(c.Context(), userID); err != nil {
+			app.logger.Errorf("Error clearing pending email: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to update email",
 			})
 		}
+
+		// the link already mailed to the dropped address must not complete the change
+		if err := app.store.UserTokens.DeleteAllForUser(c.Context(), userID, store.TokenPurposeEmailVerification); err != nil {
+			app.logger.Errorf("Error deleting email verification tokens: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to update email",
+			})
+		}
+	}
+
+	if pendingEmail == "" {
+		app.auditUserUpdate(c, store.AuditActionUpdate, user)
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"message": "User updated successfully",
+		})
+	}
+
+	if err := app.store.Users.SetPendingEmail(c.Context(), userID, pendingEmail); err != nil {
+		app.logger.Errorf("Error setting pending email: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update email",
+		})
+	}
+
+	app.auditUserUpdate(c, store.AuditActionUpdate, user)
+
+	if err := app.sendEmailVerification(c, user, pendingEmail); err != nil {
+		app.logger.Errorf("Error creating email verification token: %v", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to update user",
+			"error": "Failed to send verification email",
 		})
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"message": "User updated successfully",
+		"message":       "User updated successfully, confirm the new email address to complete the change",
+		"pending_email": pendingEmail,
 	})
 }
 
 // DeleteUser godoc
 //
 //	@Summary		Delete a user
-//	@Description	Permanently remove a user from the system
+//	@Description	Schedule the account and everything it owns for deletion. Logging in again before the grace period ends cancels the deletion.
 //	@Tags			users
 //	@Accept			json
 //	@Produce		json
 //	@Param			userID	path		string	true	"User ID"
-//	@Success		200		{object}	error	"User was successfully deleted"
+//	@Success		202		{object}	string	"Account scheduled for deletion"
 //	@Failure		400		{object}	error	"Invalid user ID format"
+//	@Failure		403		{object}	error	"User ID does not match the authenticated user"
 //	@Failure		500		{object}	error	"Failed to delete user"
 //
 // @Security		ApiKeyAuth
 //
 //	@Router			/user/{userID} [delete]
 func (app *application) deleteUserHandler(c *fiber.Ctx) error {
-	userIDStr := c.Params("userID")
-
-	userID, err := primitive.ObjectIDFromHex(userIDStr)
-	if err != nil {
+	user := getUserFromContext(c)
+	if user == nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid user ID format",
+			"error": "user not found in context",
 		})
 	}
 
-	if err := app.store.Users.Delete(c.Context(), userID); err != nil {
+	deleteAt := time.Now().Add(app.config.account.deletionGrace)
+	if err := app.store.Users.ScheduleDeletion(c.Context(), user.ID, deleteAt); err != nil {
+		app.logger.Errorf("Error scheduling account deletion: %v", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to delete user",
 		})
 	}
 
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"message": "User was successfully deleted",
+	// the account stays signed out until the user logs in again to cancel
+	if err := app.store.RefreshTokens.RevokeAllForUser(c.Context(), user.ID); err != nil {
+		app.logger.Errorf("Error revoking sessions: %v", err)
+	}
+
+	app.auditUserUpdate(c, store.AuditActionDeletionSchedule, user)
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message":                "Account scheduled for deletion, log in before then to cancel",
+		"deletion_scheduled_for": deleteAt.UTC(),
 	})
 }
+
+// auditUserUpdate records a change to the user's account, before is the user as loaded
+// at the start of the request
+func (app *application) auditUserUpdate(c *fiber.Ctx, action string, before *store.User) {
+	after, err := app.store.Users.GetByID(c.Context(), before.ID)
+	if err != nil {
+		app.logger.Errorf("Error fetching updated user for audit: %v", err)
+	}
+
+	app.recordAudit(c, action, store.AuditResourceUser, before.ID, before.ID, before, after)
+}
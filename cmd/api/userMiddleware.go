@@ -8,35 +8,36 @@ import (
 
 func (app *application) userContextMiddleware() fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		// fetching the userID from URL
-		userIDStr := c.Params("userID")
-		if userIDStr == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "userID is required",
-			})
-		}
-
-		// converting ID to objectID
-		userID, err := primitive.ObjectIDFromHex(userIDStr)
-		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid user ID format",
+		authUser := getAuthUserFromContext(c)
+		if authUser == nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Authentication required",
 			})
 		}
 
-		// get user from the store
-		user, err := app.store.Users.GetByID(c.Context(), userID)
-		if err != nil {
-			if err == store.ErrNotFound || err.Error() == "mongo: no documents in result" {
-				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-					"error": "User not found",
+		// fetching the userID from URL, "/me" routes fall back to the authenticated user
+		userID := authUser.ID
+		if userIDStr := c.Params("userID"); userIDStr != "" {
+			// converting ID to objectID
+			pathUserID, err := primitive.ObjectIDFromHex(userIDStr)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Invalid user ID format",
 				})
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to fetch user",
+			userID = pathUserID
+		}
+
+		// users may only act on their own resources
+		if userID != authUser.ID {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "You do not have access to this resource",
 			})
 		}
 
+		// the authenticated user was already loaded from the store
+		user := authUser
+
 		// add user and userID to request context
 		c.Locals("user", user)
 		c.Locals("userID", userID)
@@ -45,6 +46,26 @@ func (app *application) userContextMiddleware() fiber.Handler {
 	}
 }
 
+// requireVerifiedEmailMiddleware blocks users whose email address has not been verified yet
+func (app *application) requireVerifiedEmailMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user := getUserFromContext(c)
+		if user == nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "user not found in context",
+			})
+		}
+
+		if !user.EmailVerified {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Please verify your email address to continue",
+			})
+		}
+
+		return c.Next()
+	}
+}
+
 // getUserFromContext retrieves the user from the request context
 func getUserFromContext(c *fiber.Ctx) *store.User {
 	user, ok := c.Locals("user").(*store.User)
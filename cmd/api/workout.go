@@ -1,6 +1,8 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/FaustCelaj/GetFit.git/internal/store"
@@ -19,7 +21,9 @@ import (
 //	@Param			userID	path		string					true	"User ID"
 //	@Param			session	body		store.WorkoutSession	true	"Workout session information"
 //	@Success		201		{object}	string					"Workout session created successfully"
-//	@Failure		400		{object}	error					"Invalid request body or missing fields"
+//	@Failure		400		{object}	error					"Invalid request body, missing fields or a set that does not fit its exercise"
+//	@Failure		404		{object}	error					"An exercise is not the user's or in the catalog"
+//	@Failure		409		{object}	error					"An exercise is archived"
 //	@Failure		500		{object}	error					"Failed to create workout session"
 //
 // @Security		ApiKeyAuth
@@ -47,11 +51,32 @@ func (app *application) createWorkoutSessionHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	exerciseIDs := make([]primitive.ObjectID, len(session.Exercises))
+	for i, exercise := range session.Exercises {
+		exerciseIDs[i] = exercise.ExerciseID
+	}
+	exercises, err := app.loadReferencedExercises(c, userID, exerciseIDs)
+	if err != nil {
+		return app.referencedExercisesError(c, "workout session", err)
+	}
+
+	// every logged set must fit the tracking type of its exercise
+	for i, sessionExercise := range session.Exercises {
+		tracking := exercises[sessionExercise.ExerciseID].Tracking()
+		for j, set := range sessionExercise.CompletedSets {
+			if err := set.SetMetrics.Validate(tracking); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": fmt.Sprintf("exercise %d, set %d: %v", i+1, j+1, err),
+				})
+			}
+		}
+	}
+
 	// Set the userID for the session
 	session.UserID = userID
 
 	// Call the Create method
-	err := app.store.WorkoutSession.Create(c.Context(), &session, userID)
+	err = app.store.WorkoutSession.Create(c.Context(), &session, userID)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error":   "failed to create workout session",
@@ -72,10 +97,19 @@ func (app *application) createWorkoutSessionHandler(c *fiber.Ctx) error {
 //	@Tags			workouts
 //	@Accept			json
 //	@Produce		json
-//	@Param			userID	path		string					true	"User ID"
-//	@Success		200		{array}		store.WorkoutSession	"List of workout sessions"
-//	@Failure		400		{object}	error					"Invalid user ID"
-//	@Failure		500		{object}	error					"Failed to fetch workout sessions"
+//	@Param			userID		path		string					true	"User ID"
+//	@Param			from		query		string					false	"Only sessions started at or after this RFC 3339 time"
+//	@Param			to			query		string					false	"Only sessions started before this RFC 3339 time"
+//	@Param			routine_id	query		string					false	"Only sessions started from this routine"
+//	@Param			exercise_id	query		string					false	"Only sessions containing this exercise"
+//	@Param			status		query		string					false	"in_progress or completed"
+//	@Param			cursor		query		string					false	"next_cursor of the previous page"
+//	@Param			limit		query		int						false	"Page size, up to 200"
+//	@Param			sort		query		string					false	"start_time, title, created_at or updated_at (default -start_time), prefix with - for descending"
+//	@Param			fields		query		string					false	"Comma separated fields to return"
+//	@Success		200			{array}		store.WorkoutSession	"A page of workout sessions, next_cursor and has_more"
+//	@Failure		400			{object}	error					"Invalid user ID or list query"
+//	@Failure		500			{object}	error					"Failed to fetch workout sessions"
 //
 // @Security		ApiKeyAuth
 //
@@ -88,18 +122,52 @@ func (app *application) getAllWorkoutSessionsHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	sessions, err := app.store.WorkoutSession.GetAllUserSessions(c.Context(), userID)
+	filter, err := parseWorkoutFilter(c)
 	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	query := parseListQuery(c)
+	page, err := app.store.WorkoutSession.List(c.Context(), userID, filter, query)
+	if err != nil {
+		if errors.Is(err, store.ErrInvalidListQuery) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error":   "failed to fetch workout sessions",
 			"details": err.Error(),
 		})
 	}
 
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"message":  "workout sessions retrieved successfully",
-		"sessions": sessions,
-	})
+	return respondWithPage(c, "sessions", page, query)
+}
+
+// parseWorkoutFilter reads the workout history filters from the query string
+func parseWorkoutFilter(c *fiber.Ctx) (store.WorkoutFilter, error) {
+	filter := store.WorkoutFilter{Status: c.Query("status")}
+	if filter.Status != "" && filter.Status != store.WorkoutStatusInProgress && filter.Status != store.WorkoutStatusCompleted {
+		return store.WorkoutFilter{}, fiber.NewError(fiber.StatusBadRequest, "status must be in_progress or completed")
+	}
+
+	var err error
+	if filter.From, err = parseTimeQuery(c, "from"); err != nil {
+		return store.WorkoutFilter{}, err
+	}
+	if filter.To, err = parseTimeQuery(c, "to"); err != nil {
+		return store.WorkoutFilter{}, err
+	}
+	if filter.RoutineID, err = parseObjectIDQuery(c, "routine_id"); err != nil {
+		return store.WorkoutFilter{}, err
+	}
+	if filter.ExerciseID, err = parseObjectIDQuery(c, "exercise_id"); err != nil {
+		return store.WorkoutFilter{}, err
+	}
+
+	return filter, nil
 }
 
 // CreateWorkoutFromRoutine godoc
@@ -269,15 +337,16 @@ func (app *application) deleteWorkoutSessionHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	app.recordAudit(c, store.AuditActionDelete, store.AuditResourceWorkout, sessionID, userID, getSessionFromContext(c), nil)
+
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "workout session deleted successfully",
 	})
 }
 
 type addSetPayload struct {
-	Weight    float32 `json:"weight"`
-	Reps      int16   `json:"reps"`
-	SetNumber int16   `json:"set_number"`
+	store.SetMetrics
+	SetNumber int16 `json:"set_number"`
 }
 
 // AddSetToWorkout godoc
@@ -293,6 +362,7 @@ type addSetPayload struct {
 //	@Param			set			body		addSetPayload	true	"Set information"
 //	@Success		200			{object}	string			"Set added to workout successfully"
 //	@Failure		400			{object}	error			"Invalid request body or IDs"
+//	@Failure		409			{object}	error			"Exercise is archived"
 //	@Failure		500			{object}	error			"Failed to add set to workout"
 //
 // @Security		ApiKeyAuth
@@ -320,10 +390,26 @@ func (app *application) addSetToWorkoutHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	exercise := getExerciseFromContext(c)
+	if exercise == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "exercise not found in context",
+		})
+	}
+	if exercise.ArchivedAt != nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Sets cannot be logged for archived exercises, unarchive it first",
+		})
+	}
+	if err := payload.SetMetrics.Validate(exercise.Tracking()); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
 	// Create the session set
 	set := store.SessionSet{
-		Weight:      payload.Weight,
-		Reps:        payload.Reps,
+		SetMetrics:  payload.SetMetrics,
 		SetNumber:   payload.SetNumber,
 		CompletedAt: time.Now(),
 	}
@@ -0,0 +1,92 @@
+// Command catalog loads the free-exercise-db dataset into the shared exercise catalog.
+// It can be run any number of times, exercises already loaded are only updated when
+// the dataset changed.
+//
+//	go run ./cmd/catalog -source https://raw.githubusercontent.com/yuhonas/free-exercise-db/main/dist/exercises.json
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/FaustCelaj/GetFit.git/internal/catalog"
+	"github.com/FaustCelaj/GetFit.git/internal/db"
+	"github.com/FaustCelaj/GetFit.git/internal/env"
+	"github.com/FaustCelaj/GetFit.git/internal/store"
+	"go.uber.org/zap"
+)
+
+func main() {
+	source := flag.String("source", env.GetString("CATALOG_SOURCE", ""), "path or http(s) URL of the dataset's exercises.json")
+	flag.Parse()
+
+	logger := zap.Must(zap.NewProduction()).Sugar()
+	defer logger.Sync()
+
+	if *source == "" {
+		logger.Fatal("a dataset is required, pass -source or set CATALOG_SOURCE")
+	}
+
+	exercises, err := readDataset(*source)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	client, err := db.New(
+		env.GetString("DB_ADDR", "mongodb://localhost:27017"),
+		env.GetInt("DB_MAX_OPEN_CONNS", 30),
+		env.GetString("DB_MAX_IDEL_TIME", "15m"))
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer client.Disconnect(context.Background())
+
+	database := client.Database("getfit")
+	if err := store.CreateIndexes(context.Background(), database); err != nil {
+		logger.Fatal(err)
+	}
+
+	storage := store.NewMongoDBStorage(database)
+	inserted, updated, err := storage.Exercise.UpsertCatalog(context.Background(), exercises)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	logger.Infow("Exercise catalog loaded",
+		"exercises", len(exercises),
+		"inserted", inserted,
+		"updated", updated,
+	)
+}
+
+func readDataset(source string) ([]*store.Exercise, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		file, err := os.Open(source)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		return catalog.Parse(file)
+	}
+
+	client := &http.Client{Timeout: time.Minute}
+	resp, err := client.Get(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download dataset: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return nil, fmt.Errorf("failed to download dataset: %s", resp.Status)
+	}
+
+	return catalog.Parse(resp.Body)
+}
@@ -24,40 +24,14 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/health": {
-            "get": {
-                "description": "Check if the API is up and running",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "health"
-                ],
-                "summary": "Health check endpoint",
-                "responses": {
-                    "200": {
-                        "description": "API status and version",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    }
-                }
-            }
-        },
-        "/search/{exerciseID}": {
+        "/admin/audit": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Find an exercise using its ID without user context",
+                "description": "List audit entries across every user, newest first. Admin only.",
                 "consumes": [
                     "application/json"
                 ],
@@ -65,48 +39,98 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "exercises"
+                    "admin"
                 ],
-                "summary": "Search for an exercise by ID",
+                "summary": "Search the audit log",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Exercise ID",
-                        "name": "exerciseID",
-                        "in": "path",
-                        "required": true
+                        "description": "Filter by the user whose data was affected",
+                        "name": "owner_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by the user who made the change",
+                        "name": "actor_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by action",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by resource type",
+                        "name": "resource_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by resource ID",
+                        "name": "resource_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only entries at or after this RFC 3339 time",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only entries before this RFC 3339 time",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, starting at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, up to 200",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Exercise information",
+                        "description": "Audit entries and total count",
                         "schema": {
-                            "$ref": "#/definitions/store.Exercise"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.AuditEntry"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Invalid exercise ID format",
+                        "description": "Invalid query",
                         "schema": {}
                     },
-                    "404": {
-                        "description": "Exercise not found",
+                    "403": {
+                        "description": "Not an admin",
                         "schema": {}
                     },
                     "500": {
-                        "description": "Failed to fetch exercise",
+                        "description": "Failed to fetch audit log",
                         "schema": {}
                     }
                 }
             }
         },
-        "/user": {
-            "post": {
+        "/admin/stats": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Register a new user in the system with username, email, and other basic information",
+                "description": "Count users, exercises, routines and workouts across the whole system. Admin only.",
                 "consumes": [
                     "application/json"
                 ],
@@ -114,44 +138,35 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "admin"
                 ],
-                "summary": "Create a new user",
-                "parameters": [
-                    {
-                        "description": "User information including username, email, and password",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
+                "summary": "System statistics",
+                "responses": {
+                    "200": {
+                        "description": "Counts per collection",
                         "schema": {
-                            "$ref": "#/definitions/store.User"
+                            "type": "string"
                         }
-                    }
-                ],
-                "responses": {
-                    "201": {
-                        "description": "Returns the created user ID",
-                        "schema": {}
                     },
-                    "400": {
-                        "description": "Invalid request body",
+                    "403": {
+                        "description": "Not an admin",
                         "schema": {}
                     },
                     "500": {
-                        "description": "Failed to create user",
+                        "description": "Failed to fetch statistics",
                         "schema": {}
                     }
                 }
             }
         },
-        "/user/{userID}": {
+        "/admin/users": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Retrieve a user's information by their ID",
+                "description": "List and search every user account, newest first. Admin only.",
                 "consumes": [
                     "application/json"
                 ],
@@ -159,46 +174,74 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "admin"
                 ],
-                "summary": "Get user by ID",
+                "summary": "List users",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User ID",
-                        "name": "userID",
-                        "in": "path",
-                        "required": true
+                        "description": "Matches part of the username or email",
+                        "name": "search",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by role (user, coach, admin)",
+                        "name": "role",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filter by disabled status",
+                        "name": "disabled",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, starting at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, up to 100",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "User information",
+                        "description": "Users and total count",
                         "schema": {
-                            "$ref": "#/definitions/store.User"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.User"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Invalid user ID format",
+                        "description": "Invalid query",
                         "schema": {}
                     },
-                    "404": {
-                        "description": "User not found",
+                    "403": {
+                        "description": "Not an admin",
                         "schema": {}
                     },
                     "500": {
-                        "description": "Failed to fetch user",
+                        "description": "Failed to fetch users",
                         "schema": {}
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/admin/users/{targetUserID}": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Permanently remove a user from the system",
+                "description": "Retrieve any user's account. Admin only.",
                 "consumes": [
                     "application/json"
                 ],
@@ -206,40 +249,48 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "admin"
                 ],
-                "summary": "Delete a user",
+                "summary": "Get a user",
                 "parameters": [
                     {
                         "type": "string",
                         "description": "User ID",
-                        "name": "userID",
+                        "name": "targetUserID",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "User was successfully deleted",
-                        "schema": {}
+                        "description": "User information",
+                        "schema": {
+                            "$ref": "#/definitions/store.User"
+                        }
                     },
                     "400": {
                         "description": "Invalid user ID format",
                         "schema": {}
                     },
-                    "500": {
-                        "description": "Failed to delete user",
+                    "403": {
+                        "description": "Not an admin",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "User not found",
                         "schema": {}
                     }
                 }
-            },
-            "patch": {
+            }
+        },
+        "/admin/users/{targetUserID}/disable": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Update one or more fields of a user's profile",
+                "description": "Block a user from logging in and end all of their sessions. Admin only.",
                 "consumes": [
                     "application/json"
                 ],
@@ -247,55 +298,52 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "admin"
                 ],
-                "summary": "Update user information",
+                "summary": "Disable a user",
                 "parameters": [
                     {
                         "type": "string",
                         "description": "User ID",
-                        "name": "userID",
+                        "name": "targetUserID",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "Updated user information",
-                        "name": "userData",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/main.updateUserPayload"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "User updated successfully",
-                        "schema": {}
+                        "description": "User disabled",
+                        "schema": {
+                            "type": "string"
+                        }
                     },
                     "400": {
-                        "description": "Invalid request body or missing fields",
+                        "description": "Admins cannot disable themselves",
                         "schema": {}
                     },
-                    "409": {
-                        "description": "Version conflict - record has been modified",
+                    "403": {
+                        "description": "Not an admin",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "User not found",
                         "schema": {}
                     },
                     "500": {
-                        "description": "Failed to update user",
+                        "description": "Failed to disable user",
                         "schema": {}
                     }
                 }
             }
         },
-        "/users/{userID}/exercise": {
-            "get": {
+        "/admin/users/{targetUserID}/enable": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Retrieve all exercises created by a specific user",
+                "description": "Allow a disabled user to log in again. Admin only.",
                 "consumes": [
                     "application/json"
                 ],
@@ -303,45 +351,48 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "exercises"
+                    "admin"
                 ],
-                "summary": "Get all exercises for a user",
+                "summary": "Re-enable a user",
                 "parameters": [
                     {
                         "type": "string",
                         "description": "User ID",
-                        "name": "userID",
+                        "name": "targetUserID",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "List of exercises",
+                        "description": "User enabled",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/store.Exercise"
-                            }
+                            "type": "string"
                         }
                     },
-                    "400": {
-                        "description": "Invalid user ID",
+                    "403": {
+                        "description": "Not an admin",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "User not found",
                         "schema": {}
                     },
                     "500": {
-                        "description": "Failed to fetch exercises",
+                        "description": "Failed to enable user",
                         "schema": {}
                     }
                 }
-            },
+            }
+        },
+        "/admin/users/{targetUserID}/force-password-reset": {
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Create a custom exercise for a user",
+                "description": "Sign the user out everywhere, block password logins and API keys and email them a reset link. Admin only.",
                 "consumes": [
                     "application/json"
                 ],
@@ -349,53 +400,48 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "exercises"
+                    "admin"
                 ],
-                "summary": "Create a new exercise",
+                "summary": "Force a password reset",
                 "parameters": [
                     {
                         "type": "string",
                         "description": "User ID",
-                        "name": "userID",
+                        "name": "targetUserID",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "Exercise information",
-                        "name": "exercise",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/store.Exercise"
-                        }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Exercise created successfully",
+                    "202": {
+                        "description": "Reset email sent",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "400": {
-                        "description": "Invalid request body or missing required fields",
+                    "403": {
+                        "description": "Not an admin",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "User not found",
                         "schema": {}
                     },
                     "500": {
-                        "description": "Failed to create exercise",
+                        "description": "Failed to force password reset",
                         "schema": {}
                     }
                 }
             }
         },
-        "/users/{userID}/exercise/{exerciseID}": {
-            "get": {
+        "/admin/users/{targetUserID}/role": {
+            "put": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Retrieve a specific exercise by its ID",
+                "description": "Set the role of a user to user, coach or admin. Admin only.",
                 "consumes": [
                     "application/json"
                 ],
@@ -403,53 +449,56 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "exercises"
+                    "admin"
                 ],
-                "summary": "Get exercise by ID",
+                "summary": "Change a user's role",
                 "parameters": [
                     {
                         "type": "string",
                         "description": "User ID",
-                        "name": "userID",
+                        "name": "targetUserID",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Exercise ID",
-                        "name": "exerciseID",
-                        "in": "path",
-                        "required": true
+                        "description": "New role",
+                        "name": "role",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.setRolePayload"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Exercise information",
+                        "description": "Role updated",
                         "schema": {
-                            "$ref": "#/definitions/store.Exercise"
+                            "type": "string"
                         }
                     },
                     "400": {
-                        "description": "Invalid ID format",
+                        "description": "Invalid role",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Not an admin",
                         "schema": {}
                     },
                     "404": {
-                        "description": "Exercise not found",
+                        "description": "User not found",
                         "schema": {}
                     },
                     "500": {
-                        "description": "Failed to fetch exercise",
+                        "description": "Failed to update role",
                         "schema": {}
                     }
                 }
-            },
-            "delete": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "Remove an exercise from the system",
+            }
+        },
+        "/authentication/forgot-password": {
+            "post": {
+                "description": "Email a single-use, time-limited password reset link. Always responds the same way so it cannot be used to discover accounts.",
                 "consumes": [
                     "application/json"
                 ],
@@ -457,49 +506,41 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "exercises"
+                    "authentication"
                 ],
-                "summary": "Delete an exercise",
+                "summary": "Request a password reset",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "User ID",
-                        "name": "userID",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Exercise ID",
-                        "name": "exerciseID",
-                        "in": "path",
-                        "required": true
+                        "description": "Account email",
+                        "name": "email",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.forgotPasswordPayload"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Exercise successfully deleted",
+                    "202": {
+                        "description": "Reset email sent if the account exists",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "400": {
-                        "description": "Invalid ID format",
+                        "description": "Invalid request body",
                         "schema": {}
                     },
                     "500": {
-                        "description": "Failed to delete exercise",
+                        "description": "Internal server error",
                         "schema": {}
                     }
                 }
-            },
-            "patch": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "Update an existing exercise's details",
+            }
+        },
+        "/authentication/refresh": {
+            "post": {
+                "description": "Exchange a refresh token for a new access token and a rotated refresh token. Replaying a rotated refresh token revokes the whole session.",
                 "consumes": [
                     "application/json"
                 ],
@@ -507,64 +548,48 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "exercises"
+                    "authentication"
                 ],
-                "summary": "Update an exercise",
+                "summary": "Refresh an access token",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "User ID",
-                        "name": "userID",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Exercise ID",
-                        "name": "exerciseID",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Updated exercise information",
-                        "name": "exercise",
+                        "description": "Refresh token",
+                        "name": "token",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/main.updateExercisePayload"
+                            "$ref": "#/definitions/main.refreshTokenPayload"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Exercise updated successfully",
+                        "description": "New access and refresh tokens",
                         "schema": {
-                            "type": "string"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Invalid request body or missing fields",
+                        "description": "Invalid request body",
                         "schema": {}
                     },
-                    "404": {
-                        "description": "Exercise not found",
+                    "401": {
+                        "description": "Invalid, expired or revoked refresh token",
                         "schema": {}
                     },
                     "500": {
-                        "description": "Failed to update exercise",
+                        "description": "Failed to refresh token",
                         "schema": {}
                     }
                 }
             }
         },
-        "/users/{userID}/routine": {
-            "get": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "Retrieve all workout routines created by a user",
+        "/authentication/reset-password": {
+            "post": {
+                "description": "Consume a password reset token and set a new password. Every session of the user is signed out and their API keys are revoked.",
                 "consumes": [
                     "application/json"
                 ],
@@ -572,45 +597,41 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "routines"
+                    "authentication"
                 ],
-                "summary": "Get all user routines",
+                "summary": "Reset a password",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "User ID",
-                        "name": "userID",
-                        "in": "path",
-                        "required": true
+                        "description": "Reset token and new password",
+                        "name": "reset",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.resetPasswordPayload"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "List of routines",
+                        "description": "Password reset successfully",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/store.Routine"
-                            }
+                            "type": "string"
                         }
                     },
                     "400": {
-                        "description": "Invalid user ID",
+                        "description": "Invalid request body or invalid token",
                         "schema": {}
                     },
                     "500": {
-                        "description": "Failed to fetch routines",
+                        "description": "Failed to reset password",
                         "schema": {}
                     }
                 }
-            },
+            }
+        },
+        "/authentication/token": {
             "post": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "Create a structured workout routine with exercise templates",
+                "description": "Verify a user's email and password and return a signed, expiring JWT. Users with 2FA enabled get an mfa_token to use with /authentication/token/2fa instead.",
                 "consumes": [
                     "application/json"
                 ],
@@ -618,53 +639,57 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "routines"
+                    "authentication"
                 ],
-                "summary": "Create a new workout routine",
+                "summary": "Log in and obtain an access token",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "User ID",
-                        "name": "userID",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Routine information",
-                        "name": "routine",
+                        "description": "User credentials",
+                        "name": "credentials",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/store.Routine"
+                            "$ref": "#/definitions/main.createTokenPayload"
                         }
                     }
                 ],
                 "responses": {
+                    "200": {
+                        "description": "2FA is required, returns an mfa_token",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
                     "201": {
-                        "description": "Routine created successfully",
+                        "description": "Access token, refresh token and their expiry",
                         "schema": {
-                            "type": "string"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Invalid request body or missing fields",
+                        "description": "Invalid request body",
+                        "schema": {}
+                    },
+                    "401": {
+                        "description": "Invalid email or password",
                         "schema": {}
                     },
                     "500": {
-                        "description": "Failed to create routine",
+                        "description": "Failed to create token",
                         "schema": {}
                     }
                 }
             }
         },
-        "/users/{userID}/routine/{routineID}": {
-            "get": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "Retrieve a specific workout routine by its ID",
+        "/authentication/token/2fa": {
+            "post": {
+                "description": "Exchange the mfa_token from /authentication/token and a TOTP code, or a one-time recovery code, for access and refresh tokens. An mfa_token is good for a single attempt, after a wrong code log in again.",
                 "consumes": [
                     "application/json"
                 ],
@@ -672,53 +697,48 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "routines"
+                    "authentication"
                 ],
-                "summary": "Get routine by ID",
+                "summary": "Complete a 2FA login",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "User ID",
-                        "name": "userID",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Routine ID",
-                        "name": "routineID",
-                        "in": "path",
-                        "required": true
+                        "description": "MFA token with a TOTP or recovery code",
+                        "name": "credentials",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.createTokenTwoFactorPayload"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Routine information",
+                    "201": {
+                        "description": "Access token, refresh token and their expiry",
                         "schema": {
-                            "$ref": "#/definitions/store.Routine"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Invalid ID format",
+                        "description": "Invalid request body",
                         "schema": {}
                     },
-                    "404": {
-                        "description": "Routine not found",
+                    "401": {
+                        "description": "Invalid token or code",
                         "schema": {}
                     },
                     "500": {
-                        "description": "Failed to fetch routine",
+                        "description": "Failed to create token",
                         "schema": {}
                     }
                 }
-            },
-            "delete": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "Remove a workout routine from the system",
+            }
+        },
+        "/authentication/verify-email": {
+            "post": {
+                "description": "Consume an email verification token. Confirms a new account's address or applies a pending email change.",
                 "consumes": [
                     "application/json"
                 ],
@@ -726,49 +746,50 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "routines"
+                    "authentication"
                 ],
-                "summary": "Delete a routine",
+                "summary": "Verify an email address",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "User ID",
-                        "name": "userID",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Routine ID",
-                        "name": "routineID",
-                        "in": "path",
-                        "required": true
+                        "description": "Verification token",
+                        "name": "token",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.verifyEmailPayload"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Routine successfully deleted",
+                        "description": "Email verified successfully",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "400": {
-                        "description": "Invalid ID format",
+                        "description": "Invalid request body or invalid token",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Email is already in use",
                         "schema": {}
                     },
                     "500": {
-                        "description": "Failed to delete routine",
+                        "description": "Failed to verify email",
                         "schema": {}
                     }
                 }
-            },
-            "patch": {
+            }
+        },
+        "/exercises": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Update details of an existing workout routine",
+                "description": "List the exercise catalog shared by every user, sorted by name. Catalog exercises can be used in routines and workouts like custom ones.",
                 "consumes": [
                     "application/json"
                 ],
@@ -776,64 +797,88 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "routines"
+                    "exercises"
                 ],
-                "summary": "Update a routine",
+                "summary": "List catalog exercises",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User ID",
-                        "name": "userID",
-                        "in": "path",
-                        "required": true
+                        "description": "Filter by category",
+                        "name": "category",
+                        "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Routine ID",
-                        "name": "routineID",
-                        "in": "path",
-                        "required": true
+                        "description": "Filter by level",
+                        "name": "level",
+                        "in": "query"
                     },
                     {
-                        "description": "Updated routine information",
-                        "name": "routine",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/main.updateRoutinePayload"
-                        }
+                        "type": "string",
+                        "description": "Filter by equipment",
+                        "name": "equipment",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by primary muscle",
+                        "name": "muscle",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, up to 200",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "name (default), category, created_at or updated_at, prefix with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields to return",
+                        "name": "fields",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Routine updated successfully",
+                        "description": "A page of catalog exercises, next_cursor and has_more",
                         "schema": {
-                            "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.Exercise"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Invalid request body or missing fields",
-                        "schema": {}
-                    },
-                    "404": {
-                        "description": "Routine not found",
+                        "description": "Invalid query",
                         "schema": {}
                     },
                     "500": {
-                        "description": "Failed to update routine",
+                        "description": "Failed to fetch exercises",
                         "schema": {}
                     }
                 }
             }
         },
-        "/users/{userID}/routine/{routineID}/exercise/{exerciseID}": {
-            "post": {
+        "/exercises/match": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Add an exercise with template sets to a workout routine",
+                "description": "Resolve a free-text name like \"RDL\", \"bench\" or a misspelling to catalog and custom exercises, best first, each with a confidence score from 0 to 1",
                 "consumes": [
                     "application/json"
                 ],
@@ -841,65 +886,53 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "routine-exercises"
+                    "exercises"
                 ],
-                "summary": "Add exercise to routine",
+                "summary": "Match an exercise name",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User ID",
-                        "name": "userID",
-                        "in": "path",
+                        "description": "Name to resolve",
+                        "name": "q",
+                        "in": "query",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Routine ID",
-                        "name": "routineID",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Exercise ID",
-                        "name": "exerciseID",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Template sets information with version",
-                        "name": "data",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "object"
-                        }
+                        "type": "integer",
+                        "description": "Number of matches, up to 20",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Exercise added to routine successfully",
+                        "description": "Matching exercises",
                         "schema": {
-                            "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/fuzzy.Match"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Invalid request body or IDs",
+                        "description": "Missing name",
                         "schema": {}
                     },
                     "500": {
-                        "description": "Failed to add exercise to routine",
+                        "description": "Failed to match exercise",
                         "schema": {}
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/exercises/search": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Remove an exercise from a workout routine",
+                "description": "Search the catalog and the user's custom exercises by name and instructions, with filters. Every filter has facet counts, computed with the other filters applied.",
                 "consumes": [
                     "application/json"
                 ],
@@ -907,65 +940,97 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "routine-exercises"
+                    "exercises"
                 ],
-                "summary": "Remove exercise from routine",
+                "summary": "Search exercises",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User ID",
-                        "name": "userID",
-                        "in": "path",
-                        "required": true
+                        "description": "Text to search for",
+                        "name": "q",
+                        "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Routine ID",
-                        "name": "routineID",
-                        "in": "path",
-                        "required": true
+                        "description": "Filter by primary muscle",
+                        "name": "primary_muscle",
+                        "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Exercise ID",
-                        "name": "exerciseID",
-                        "in": "path",
-                        "required": true
+                        "description": "Filter by secondary muscle",
+                        "name": "secondary_muscle",
+                        "in": "query"
                     },
                     {
-                        "description": "Expected version for optimistic concurrency",
-                        "name": "version",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "object"
-                        }
+                        "type": "string",
+                        "description": "Filter by equipment",
+                        "name": "equipment",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by level",
+                        "name": "level",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by force",
+                        "name": "force",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by mechanic",
+                        "name": "mechanic",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by category",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, starting at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, up to 200",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Exercise removed from routine successfully",
+                        "description": "Matching exercises, total count and facets",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/store.ExerciseSearchResult"
                         }
                     },
                     "400": {
-                        "description": "Invalid IDs or version",
+                        "description": "Invalid query",
                         "schema": {}
                     },
                     "500": {
-                        "description": "Failed to remove exercise from routine",
+                        "description": "Failed to search exercises",
                         "schema": {}
                     }
                 }
-            },
-            "patch": {
+            }
+        },
+        "/exercises/{exerciseID}": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Update template sets for an exercise in a routine",
+                "description": "Retrieve one exercise from the shared catalog",
                 "consumes": [
                     "application/json"
                 ],
@@ -973,67 +1038,74 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "routine-exercises"
+                    "exercises"
                 ],
-                "summary": "Update exercise in routine",
+                "summary": "Get a catalog exercise",
                 "parameters": [
-                    {
-                        "type": "string",
-                        "description": "User ID",
-                        "name": "userID",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Routine ID",
-                        "name": "routineID",
-                        "in": "path",
-                        "required": true
-                    },
                     {
                         "type": "string",
                         "description": "Exercise ID",
                         "name": "exerciseID",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "Updated template sets with version",
-                        "name": "data",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "object"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Exercise template sets updated successfully",
+                        "description": "Exercise information",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/store.Exercise"
                         }
                     },
                     "400": {
-                        "description": "Invalid request body or IDs",
+                        "description": "Invalid exercise ID format",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Exercise not found",
                         "schema": {}
                     },
                     "500": {
-                        "description": "Failed to update exercise in routine",
+                        "description": "Failed to fetch exercise",
                         "schema": {}
                     }
                 }
             }
         },
-        "/users/{userID}/workout": {
+        "/health": {
+            "get": {
+                "description": "Check if the API is up and running",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Health check endpoint",
+                "responses": {
+                    "200": {
+                        "description": "API status and version",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/search/{exerciseID}": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Retrieve all workout sessions for a user",
+                "description": "Find an exercise using its ID without user context",
                 "consumes": [
                     "application/json"
                 ],
@@ -1041,45 +1113,48 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "workouts"
+                    "exercises"
                 ],
-                "summary": "Get all workout sessions",
+                "summary": "Search for an exercise by ID",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User ID",
-                        "name": "userID",
+                        "description": "Exercise ID",
+                        "name": "exerciseID",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "List of workout sessions",
+                        "description": "Exercise information",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/store.WorkoutSession"
-                            }
+                            "$ref": "#/definitions/store.Exercise"
                         }
                     },
                     "400": {
-                        "description": "Invalid user ID",
+                        "description": "Invalid exercise ID format",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Exercise not found",
                         "schema": {}
                     },
                     "500": {
-                        "description": "Failed to fetch workout sessions",
+                        "description": "Failed to fetch exercise",
                         "schema": {}
                     }
                 }
-            },
+            }
+        },
+        "/user": {
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Start a new workout session from scratch",
+                "description": "Register a new user in the system with username, email, and other basic information",
                 "consumes": [
                     "application/json"
                 ],
@@ -1087,53 +1162,44 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "workouts"
+                    "users"
                 ],
-                "summary": "Create a new workout session",
+                "summary": "Create a new user",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "User ID",
-                        "name": "userID",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Workout session information",
-                        "name": "session",
+                        "description": "User information including username, email, and password",
+                        "name": "user",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/store.WorkoutSession"
+                            "$ref": "#/definitions/store.User"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Workout session created successfully",
-                        "schema": {
-                            "type": "string"
-                        }
+                        "description": "Returns the created user ID",
+                        "schema": {}
                     },
                     "400": {
-                        "description": "Invalid request body or missing fields",
+                        "description": "Invalid request body",
                         "schema": {}
                     },
                     "500": {
-                        "description": "Failed to create workout session",
+                        "description": "Failed to create user",
                         "schema": {}
                     }
                 }
             }
         },
-        "/users/{userID}/workout/from-routine/{routineID}": {
-            "post": {
+        "/user/{userID}": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Create a new workout session based on an existing routine",
+                "description": "Retrieve a user's information by their ID",
                 "consumes": [
                     "application/json"
                 ],
@@ -1141,9 +1207,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "workouts"
+                    "users"
                 ],
-                "summary": "Create workout from routine",
+                "summary": "Get user by ID",
                 "parameters": [
                     {
                         "type": "string",
@@ -1151,41 +1217,40 @@ const docTemplate = `{
                         "name": "userID",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Routine ID",
-                        "name": "routineID",
-                        "in": "path",
-                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Workout created from routine successfully",
+                    "200": {
+                        "description": "User information",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/store.User"
                         }
                     },
                     "400": {
-                        "description": "Invalid IDs",
+                        "description": "Invalid user ID format",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "User ID does not match the authenticated user",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "User not found",
                         "schema": {}
                     },
                     "500": {
-                        "description": "Failed to create workout from routine",
+                        "description": "Failed to fetch user",
                         "schema": {}
                     }
                 }
-            }
-        },
-        "/users/{userID}/workout/{sessionID}": {
-            "get": {
+            },
+            "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Retrieve a specific workout session by its ID",
+                "description": "Schedule the account and everything it owns for deletion. Logging in again before the grace period ends cancels the deletion.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1193,9 +1258,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "workouts"
+                    "users"
                 ],
-                "summary": "Get workout session by ID",
+                "summary": "Delete a user",
                 "parameters": [
                     {
                         "type": "string",
@@ -1203,43 +1268,36 @@ const docTemplate = `{
                         "name": "userID",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Session ID",
-                        "name": "sessionID",
-                        "in": "path",
-                        "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Workout session information",
+                    "202": {
+                        "description": "Account scheduled for deletion",
                         "schema": {
-                            "$ref": "#/definitions/store.WorkoutSession"
+                            "type": "string"
                         }
                     },
                     "400": {
-                        "description": "Invalid ID format",
+                        "description": "Invalid user ID format",
                         "schema": {}
                     },
-                    "404": {
-                        "description": "Workout session not found",
+                    "403": {
+                        "description": "User ID does not match the authenticated user",
                         "schema": {}
                     },
                     "500": {
-                        "description": "Failed to fetch workout session",
+                        "description": "Failed to delete user",
                         "schema": {}
                     }
                 }
             },
-            "delete": {
+            "patch": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Remove a workout session from the system",
+                "description": "Update one or more fields of a user's profile. A new email is held as pending until it is confirmed, sending the current email again cancels a pending change.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1247,9 +1305,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "workouts"
+                    "users"
                 ],
-                "summary": "Delete a workout session",
+                "summary": "Update user information",
                 "parameters": [
                     {
                         "type": "string",
@@ -1258,40 +1316,109 @@ const docTemplate = `{
                         "in": "path",
                         "required": true
                     },
+                    {
+                        "description": "Updated user information",
+                        "name": "userData",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.updateUserPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User updated successfully",
+                        "schema": {}
+                    },
+                    "400": {
+                        "description": "Invalid request body or missing fields",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "User ID does not match the authenticated user",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Version conflict - record has been modified",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Failed to update user",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/{userID}/2fa/confirm": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Confirm the enrolled secret with a code from the authenticator app. Enables 2FA and returns one-time recovery codes, which are only shown once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "two-factor"
+                ],
+                "summary": "Confirm 2FA enrollment",
+                "parameters": [
                     {
                         "type": "string",
-                        "description": "Session ID",
-                        "name": "sessionID",
+                        "description": "User ID",
+                        "name": "userID",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "TOTP code",
+                        "name": "code",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.confirmTwoFactorPayload"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Workout session deleted successfully",
+                        "description": "2FA enabled with recovery codes",
                         "schema": {
-                            "type": "string"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Invalid ID format",
+                        "description": "Invalid code or no enrollment in progress",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "2FA is already enabled",
                         "schema": {}
                     },
                     "500": {
-                        "description": "Failed to delete workout session",
+                        "description": "Failed to enable 2FA",
                         "schema": {}
                     }
                 }
             }
         },
-        "/users/{userID}/workout/{sessionID}/complete": {
+        "/users/{userID}/2fa/disable": {
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Mark a workout session as completed and calculate metrics",
+                "description": "Turn off two-factor authentication after re-entering the account password",
                 "consumes": [
                     "application/json"
                 ],
@@ -1299,9 +1426,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "workouts"
+                    "two-factor"
                 ],
-                "summary": "Complete a workout session",
+                "summary": "Disable 2FA",
                 "parameters": [
                     {
                         "type": "string",
@@ -1311,39 +1438,45 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Session ID",
-                        "name": "sessionID",
-                        "in": "path",
-                        "required": true
+                        "description": "Account password",
+                        "name": "password",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.disableTwoFactorPayload"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Workout completed successfully",
+                        "description": "2FA disabled",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "400": {
-                        "description": "Invalid ID format",
+                        "description": "Invalid request body",
+                        "schema": {}
+                    },
+                    "401": {
+                        "description": "Password is incorrect",
                         "schema": {}
                     },
                     "500": {
-                        "description": "Failed to complete workout",
+                        "description": "Failed to disable 2FA",
                         "schema": {}
                     }
                 }
             }
         },
-        "/users/{userID}/workout/{sessionID}/exercise/{exerciseID}/sets": {
+        "/users/{userID}/2fa/enroll": {
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Record a completed set for an exercise in a workout",
+                "description": "Generate a new TOTP secret and the otpauth URI to show as a QR code. 2FA stays off until the secret is confirmed.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1351,9 +1484,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "workout-sets"
+                    "two-factor"
                 ],
-                "summary": "Add a set to a workout exercise",
+                "summary": "Start 2FA enrollment",
                 "parameters": [
                     {
                         "type": "string",
@@ -1361,86 +1494,3622 @@ const docTemplate = `{
                         "name": "userID",
                         "in": "path",
                         "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "TOTP secret and otpauth URI",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "2FA is already enabled",
+                        "schema": {}
                     },
+                    "500": {
+                        "description": "Failed to start enrollment",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/{userID}/api-keys": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the user's API keys. Only the key prefix is shown.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "api-keys"
+                ],
+                "summary": "List personal API keys",
+                "parameters": [
                     {
                         "type": "string",
-                        "description": "Session ID",
-                        "name": "sessionID",
+                        "description": "User ID",
+                        "name": "userID",
                         "in": "path",
                         "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of API keys",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.APIKey"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid user ID",
+                        "schema": {}
                     },
+                    "500": {
+                        "description": "Failed to fetch API keys",
+                        "schema": {}
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create a scoped API key for scripts and integrations. The key is only returned once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "api-keys"
+                ],
+                "summary": "Create a personal API key",
+                "parameters": [
                     {
                         "type": "string",
-                        "description": "Exercise ID",
-                        "name": "exerciseID",
+                        "description": "User ID",
+                        "name": "userID",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Set information",
-                        "name": "set",
+                        "description": "Key name, scopes and optional expiry",
+                        "name": "key",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/main.addSetPayload"
+                            "$ref": "#/definitions/main.createAPIKeyPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "API key created, includes the plaintext key",
+                        "schema": {
+                            "$ref": "#/definitions/store.APIKey"
                         }
+                    },
+                    "400": {
+                        "description": "Invalid request body or scopes",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Failed to create API key",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/{userID}/api-keys/{keyID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieve a single API key's metadata",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "api-keys"
+                ],
+                "summary": "Get a personal API key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "API key ID",
+                        "name": "keyID",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Set added to workout successfully",
+                        "description": "API key",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/store.APIKey"
                         }
                     },
                     "400": {
-                        "description": "Invalid request body or IDs",
+                        "description": "Invalid ID format",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "API key not found",
                         "schema": {}
                     },
                     "500": {
-                        "description": "Failed to add set to workout",
+                        "description": "Failed to fetch API key",
                         "schema": {}
                     }
                 }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Revoke an API key so it can no longer be used",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "api-keys"
+                ],
+                "summary": "Delete a personal API key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "API key ID",
+                        "name": "keyID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "API key deleted successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID format",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "API key not found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Failed to delete API key",
+                        "schema": {}
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Rename a key or change its scopes or expiry",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "api-keys"
+                ],
+                "summary": "Update a personal API key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "API key ID",
+                        "name": "keyID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Updated key information",
+                        "name": "key",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.updateAPIKeyPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "API key updated successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body or scopes",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Version conflict - record has been modified",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Failed to update API key",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/{userID}/audit": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List audit entries for changes made to the user's account and data, newest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "audit"
+                ],
+                "summary": "List account history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by action",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by resource type",
+                        "name": "resource_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by resource ID",
+                        "name": "resource_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only entries at or after this RFC 3339 time",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only entries before this RFC 3339 time",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, starting at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, up to 200",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Audit entries and total count",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.AuditEntry"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Failed to fetch audit log",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/{userID}/email/verify": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Send a new verification link to the pending email address, or to the current one if it is not verified yet",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Resend the verification email",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Verification email sent",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Email is already verified",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Failed to send verification email",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/{userID}/exercise": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieve all exercises created by a specific user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "exercises"
+                ],
+                "summary": "Get all exercises for a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, up to 200",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "name (default), category, created_at or updated_at, prefix with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields to return",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "List archived exercises instead",
+                        "name": "archived",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "A page of exercises, next_cursor and has_more",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.Exercise"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid user ID or list query",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Failed to fetch exercises",
+                        "schema": {}
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create a custom exercise for a user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "exercises"
+                ],
+                "summary": "Create a new exercise",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Exercise information",
+                        "name": "exercise",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/store.Exercise"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Exercise created successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body or missing required fields",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Failed to create exercise",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/{userID}/exercise/duplicates": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Pair up the user's custom exercises that are probably the same exercise, scored on name and alias similarity and on matching equipment and primary muscles. Exercises with different tracking types are never paired.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "exercises"
+                ],
+                "summary": "Find duplicate exercises",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "Lowest score to report, from 0 to 1, 0.7 by default",
+                        "name": "min_score",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Likely duplicates, most likely first",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dedupe.Candidate"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid min_score",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Failed to find duplicates",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/{userID}/exercise/fork/{exerciseID}": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Copy a catalog exercise into the user's custom exercises so it can be edited. The copy keeps a forked_from reference to the original. With rewrite, the user's routines and in progress workouts that use the original are pointed at the copy, completed workouts keep their history.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "exercises"
+                ],
+                "summary": "Fork a catalog exercise",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Catalog exercise ID",
+                        "name": "exerciseID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Whether to rewrite routines and in progress workouts",
+                        "name": "payload",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/main.forkExercisePayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Exercise forked successfully",
+                        "schema": {
+                            "$ref": "#/definitions/store.Exercise"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body or not a catalog exercise",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Exercise not found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Failed to fork exercise",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/{userID}/exercise/merge": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Fold custom exercises into a survivor, which may be a custom or catalog exercise. Every routine and workout entry using a duplicate uses the survivor instead, the duplicates' names become aliases of a custom survivor and the duplicates are removed. Their media is kept for an undo. The merge is recorded and can be undone.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "exercises"
+                ],
+                "summary": "Merge duplicate exercises",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Survivor and duplicates",
+                        "name": "merge",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.mergeExercisesPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Exercises merged",
+                        "schema": {
+                            "$ref": "#/definitions/store.ExerciseMerge"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid IDs or exercises tracked differently",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Survivor or a duplicate not found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Failed to merge exercises",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/{userID}/exercise/merges": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the user's exercise merges, newest first, including undone ones",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "exercises"
+                ],
+                "summary": "List exercise merges",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Merges",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.ExerciseMerge"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to fetch merges",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/{userID}/exercise/merges/{mergeID}/undo": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Restore the duplicates of a merge and point the routine and workout entries it rewrote back at them. Entries removed or changed since the merge are left alone.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "exercises"
+                ],
+                "summary": "Undo an exercise merge",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Merge ID",
+                        "name": "mergeID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Merge undone",
+                        "schema": {
+                            "$ref": "#/definitions/store.ExerciseMerge"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid merge ID",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Merge not found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Merge was already undone",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Failed to undo merge",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/{userID}/exercise/{exerciseID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieve a specific exercise by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "exercises"
+                ],
+                "summary": "Get exercise by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Exercise ID",
+                        "name": "exerciseID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Exercise information",
+                        "schema": {
+                            "$ref": "#/definitions/store.Exercise"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID format",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Exercise not found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Failed to fetch exercise",
+                        "schema": {}
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Remove a custom exercise. An exercise still used by routines or workouts is not deleted unless mode says how to handle them: cascade removes it from routines and in progress workouts, archive keeps it hidden from lists and search so workout history stays readable. Completed workouts can only keep their exercise through archive.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "exercises"
+                ],
+                "summary": "Delete an exercise",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Exercise ID",
+                        "name": "exerciseID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "restrict (default), cascade or archive",
+                        "name": "mode",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Exercise successfully deleted or archived",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID format or mode",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Exercise not found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Routines and workouts still using the exercise",
+                        "schema": {
+                            "$ref": "#/definitions/store.ExerciseDependents"
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to delete exercise",
+                        "schema": {}
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update an existing exercise's details",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "exercises"
+                ],
+                "summary": "Update an exercise",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Exercise ID",
+                        "name": "exerciseID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Updated exercise information",
+                        "name": "exercise",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.updateExercisePayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Exercise updated successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body or missing fields",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Exercise not found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Failed to update exercise",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/{userID}/exercise/{exerciseID}/dependents": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the user's routines and workouts that use an exercise, the ones that keep it from being deleted",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "exercises"
+                ],
+                "summary": "List what uses an exercise",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Exercise ID",
+                        "name": "exerciseID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Routines and workouts using the exercise",
+                        "schema": {
+                            "$ref": "#/definitions/store.ExerciseDependents"
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to fetch dependents",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/{userID}/exercise/{exerciseID}/media": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the images and clips of an exercise. Custom exercises list uploaded media with links to the file and thumbnail, catalog exercises list the dataset's images.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "exercise-media"
+                ],
+                "summary": "List exercise media",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Exercise ID",
+                        "name": "exerciseID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Media of the exercise",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.mediaItem"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to fetch media",
+                        "schema": {}
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Attach a demo image or short clip to a custom exercise. The content type is detected from the file: JPEG, PNG, GIF and WebP images and MP4 and WebM clips are accepted. A thumbnail is generated for JPEG, PNG and GIF images.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "exercise-media"
+                ],
+                "summary": "Upload exercise media",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Exercise ID",
+                        "name": "exerciseID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Image or clip",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Media uploaded successfully",
+                        "schema": {
+                            "$ref": "#/definitions/main.mediaItem"
+                        }
+                    },
+                    "400": {
+                        "description": "Missing file or unreadable image",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Catalog exercises cannot be changed",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "The exercise has as much media as allowed",
+                        "schema": {}
+                    },
+                    "413": {
+                        "description": "File is too large",
+                        "schema": {}
+                    },
+                    "415": {
+                        "description": "Unsupported content type",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Failed to upload media",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/{userID}/exercise/{exerciseID}/media/{mediaID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Stream an uploaded image or clip, or its thumbnail",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "exercise-media"
+                ],
+                "summary": "Download exercise media",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Exercise ID",
+                        "name": "exerciseID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Media ID",
+                        "name": "mediaID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return the thumbnail instead",
+                        "name": "thumbnail",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The media file",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "Media or thumbnail not found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Failed to fetch media",
+                        "schema": {}
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Remove an image or clip from a custom exercise",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "exercise-media"
+                ],
+                "summary": "Delete exercise media",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Exercise ID",
+                        "name": "exerciseID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Media ID",
+                        "name": "mediaID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Media deleted successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Media not found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Failed to delete media",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/{userID}/exercise/{exerciseID}/revisions": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the earlier versions of a custom exercise, each with the fields its next update changed",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "exercises"
+                ],
+                "summary": "List exercise revisions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Exercise ID",
+                        "name": "exerciseID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, up to 200",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "version or created_at (default -version), prefix with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "A page of revisions, next_cursor and has_more",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.Revision"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID format or list query",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Failed to fetch revisions",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/{userID}/exercise/{exerciseID}/revisions/{version}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Put a custom exercise's fields back to how they were at an earlier version. The restore is saved as a new version, so it can be restored away again.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "exercises"
+                ],
+                "summary": "Restore an exercise revision",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Exercise ID",
+                        "name": "exerciseID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Version to restore",
+                        "name": "version",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Current version of the exercise",
+                        "name": "restore",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.restoreRevisionPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Restored exercise",
+                        "schema": {
+                            "$ref": "#/definitions/store.Exercise"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid version or missing expected_version",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Revision not found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Exercise was modified since",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Failed to restore exercise",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/{userID}/export": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Queue an export of the user's profile, exercises, routines and workouts as a ZIP of JSON and CSV files. Poll the returned job until it is completed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "export"
+                ],
+                "summary": "Request a data export",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Export queued",
+                        "schema": {
+                            "$ref": "#/definitions/store.Job"
+                        }
+                    },
+                    "409": {
+                        "description": "An export is already in progress",
+                        "schema": {
+                            "$ref": "#/definitions/store.Job"
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to queue export",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/{userID}/export/{jobID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Poll the status of an export job. Completed jobs include a download URL until they expire.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "export"
+                ],
+                "summary": "Get a data export",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Export job ID",
+                        "name": "jobID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Export job",
+                        "schema": {
+                            "$ref": "#/definitions/store.Job"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID format",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Export not found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Failed to fetch export",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/{userID}/export/{jobID}/download": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Download the ZIP archive of a completed export job",
+                "produces": [
+                    "application/zip"
+                ],
+                "tags": [
+                    "export"
+                ],
+                "summary": "Download a data export",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Export job ID",
+                        "name": "jobID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Export archive",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "Export not found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Export is not ready yet",
+                        "schema": {}
+                    },
+                    "410": {
+                        "description": "Export has expired",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/{userID}/import": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Restore a bundle produced by an export, either the ZIP archive or a single JSON document, into the user's account. IDs are remapped so routines and workouts keep pointing at the imported exercises and routines. Items matching existing ones (exercises by name, routines by title, workouts by title and start time) are handled according to mode. With dry_run nothing is written and the report shows what would happen.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "export"
+                ],
+                "summary": "Import a data bundle",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Bundle to import, the request body may also be the bundle itself",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "skip (default), overwrite or keep_both",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only report what would be imported",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Import report",
+                        "schema": {
+                            "$ref": "#/definitions/bundle.Report"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid bundle or mode",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Failed to import data",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/{userID}/password": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Change the password after confirming the current one. Every other session is signed out, API keys are revoked and a new access token is returned for this one.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Change password",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Current and new password",
+                        "name": "passwords",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.changePasswordPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Password changed and a new access token",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {}
+                    },
+                    "401": {
+                        "description": "Current password is incorrect",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Failed to change password",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/{userID}/routine": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieve all workout routines created by a user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "routines"
+                ],
+                "summary": "Get all user routines",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, up to 200",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "title, created_at or updated_at (default -updated_at), prefix with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields to return",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "A page of routines, next_cursor and has_more",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.Routine"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid user ID or list query",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Failed to fetch routines",
+                        "schema": {}
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create a structured workout routine with exercise templates",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "routines"
+                ],
+                "summary": "Create a new workout routine",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Routine information",
+                        "name": "routine",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/store.Routine"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Routine created successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body, missing fields or a set that does not fit its exercise",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "An exercise is not the user's or in the catalog",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "An exercise is archived",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Failed to create routine",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/{userID}/routine/{routineID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieve a specific workout routine by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "routines"
+                ],
+                "summary": "Get routine by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Routine ID",
+                        "name": "routineID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Routine information",
+                        "schema": {
+                            "$ref": "#/definitions/store.Routine"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID format",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Routine not found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Failed to fetch routine",
+                        "schema": {}
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Remove a workout routine from the system",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "routines"
+                ],
+                "summary": "Delete a routine",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Routine ID",
+                        "name": "routineID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Routine successfully deleted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID format",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Failed to delete routine",
+                        "schema": {}
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update details of an existing workout routine",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "routines"
+                ],
+                "summary": "Update a routine",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Routine ID",
+                        "name": "routineID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Updated routine information",
+                        "name": "routine",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.updateRoutinePayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Routine updated successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body or missing fields",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Routine not found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Failed to update routine",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/{userID}/routine/{routineID}/exercise/{exerciseID}": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Add an exercise with template sets to a workout routine",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "routine-exercises"
+                ],
+                "summary": "Add exercise to routine",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Routine ID",
+                        "name": "routineID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Exercise ID",
+                        "name": "exerciseID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Template sets information with version",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Exercise added to routine successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body or IDs",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Failed to add exercise to routine",
+                        "schema": {}
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Remove an exercise from a workout routine",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "routine-exercises"
+                ],
+                "summary": "Remove exercise from routine",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Routine ID",
+                        "name": "routineID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Exercise ID",
+                        "name": "exerciseID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Expected version for optimistic concurrency",
+                        "name": "version",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Exercise removed from routine successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid IDs or version",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Failed to remove exercise from routine",
+                        "schema": {}
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update template sets for an exercise in a routine",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "routine-exercises"
+                ],
+                "summary": "Update exercise in routine",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Routine ID",
+                        "name": "routineID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Exercise ID",
+                        "name": "exerciseID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Updated template sets with version",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Exercise template sets updated successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body or IDs",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Failed to update exercise in routine",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/{userID}/routine/{routineID}/revisions": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the earlier versions of a routine, each with the fields its next update changed",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "routines"
+                ],
+                "summary": "List routine revisions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Routine ID",
+                        "name": "routineID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, up to 200",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "version or created_at (default -version), prefix with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "A page of revisions, next_cursor and has_more",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.Revision"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID format or list query",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Failed to fetch revisions",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/{userID}/routine/{routineID}/revisions/{version}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Put a routine's title, description and exercises back to how they were at an earlier version. A version using exercises that were deleted since cannot be restored. The restore is saved as a new version.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "routines"
+                ],
+                "summary": "Restore a routine revision",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Routine ID",
+                        "name": "routineID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Version to restore",
+                        "name": "version",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Current version of the routine",
+                        "name": "restore",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.restoreRevisionPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Restored routine",
+                        "schema": {
+                            "$ref": "#/definitions/store.Routine"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid version or missing expected_version",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Revision not found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Routine was modified since, or the version uses deleted exercises",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Failed to restore routine",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/{userID}/sessions": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List every active login session for the user with its device and IP",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "List active sessions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of sessions",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.sessionResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid user ID",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Failed to fetch sessions",
+                        "schema": {}
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Revoke every login session of the user, including the current one. Their refresh and access tokens stop working at once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "Revoke all sessions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Sessions revoked successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid user ID",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Failed to revoke sessions",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/{userID}/sessions/{sessionID}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Revoke a single login session, its refresh token and access tokens stop working at once",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "Revoke a session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "sessionID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Session revoked successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID format",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Session not found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Failed to revoke session",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/{userID}/workout": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieve all workout sessions for a user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workouts"
+                ],
+                "summary": "Get all workout sessions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only sessions started at or after this RFC 3339 time",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only sessions started before this RFC 3339 time",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only sessions started from this routine",
+                        "name": "routine_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only sessions containing this exercise",
+                        "name": "exercise_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "in_progress or completed",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, up to 200",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "start_time, title, created_at or updated_at (default -start_time), prefix with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields to return",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "A page of workout sessions, next_cursor and has_more",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.WorkoutSession"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid user ID or list query",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Failed to fetch workout sessions",
+                        "schema": {}
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Start a new workout session from scratch",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workouts"
+                ],
+                "summary": "Create a new workout session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Workout session information",
+                        "name": "session",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/store.WorkoutSession"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Workout session created successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body, missing fields or a set that does not fit its exercise",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "An exercise is not the user's or in the catalog",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "An exercise is archived",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Failed to create workout session",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/{userID}/workout/from-routine/{routineID}": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create a new workout session based on an existing routine",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workouts"
+                ],
+                "summary": "Create workout from routine",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Routine ID",
+                        "name": "routineID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Workout created from routine successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid IDs",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Failed to create workout from routine",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/{userID}/workout/{sessionID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieve a specific workout session by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workouts"
+                ],
+                "summary": "Get workout session by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "sessionID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Workout session information",
+                        "schema": {
+                            "$ref": "#/definitions/store.WorkoutSession"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID format",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Workout session not found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Failed to fetch workout session",
+                        "schema": {}
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Remove a workout session from the system",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workouts"
+                ],
+                "summary": "Delete a workout session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "sessionID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Workout session deleted successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID format",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Failed to delete workout session",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/{userID}/workout/{sessionID}/complete": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Mark a workout session as completed and calculate metrics",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workouts"
+                ],
+                "summary": "Complete a workout session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "sessionID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Workout completed successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID format",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Failed to complete workout",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/{userID}/workout/{sessionID}/exercise/{exerciseID}/sets": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Record a completed set for an exercise in a workout",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workout-sets"
+                ],
+                "summary": "Add a set to a workout exercise",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "sessionID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Exercise ID",
+                        "name": "exerciseID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Set information",
+                        "name": "set",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.addSetPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Set added to workout successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body or IDs",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Exercise is archived",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Failed to add set to workout",
+                        "schema": {}
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "bundle.Report": {
+            "type": "object",
+            "properties": {
+                "counts": {
+                    "description": "type -\u003e action -\u003e count",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "object",
+                        "additionalProperties": {
+                            "type": "integer"
+                        }
+                    }
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/bundle.ReportItem"
+                    }
+                },
+                "mode": {
+                    "type": "string"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "bundle.ReportItem": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "source_id": {
+                    "type": "string"
+                },
+                "target_id": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "dedupe.Candidate": {
+            "type": "object",
+            "properties": {
+                "first": {
+                    "$ref": "#/definitions/store.Exercise"
+                },
+                "name_score": {
+                    "description": "how alike the closest names or aliases are",
+                    "type": "number"
+                },
+                "reasons": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "score": {
+                    "description": "overall confidence, from 0 to 1",
+                    "type": "number"
+                },
+                "second": {
+                    "$ref": "#/definitions/store.Exercise"
+                }
+            }
+        },
+        "fuzzy.Match": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "matched": {
+                    "description": "the name or alias that matched best",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "score": {
+                    "type": "number"
+                }
+            }
+        },
+        "main.addSetPayload": {
+            "type": "object",
+            "properties": {
+                "added_weight": {
+                    "description": "on top of bodyweight",
+                    "type": "number"
+                },
+                "assist_weight": {
+                    "description": "taken off bodyweight",
+                    "type": "number"
+                },
+                "distance_meters": {
+                    "type": "number"
+                },
+                "duration_seconds": {
+                    "type": "integer"
+                },
+                "reps": {
+                    "type": "integer"
+                },
+                "set_number": {
+                    "type": "integer"
+                },
+                "weight": {
+                    "type": "number"
+                }
+            }
+        },
+        "main.changePasswordPayload": {
+            "type": "object",
+            "required": [
+                "current_password",
+                "new_password"
+            ],
+            "properties": {
+                "current_password": {
+                    "type": "string",
+                    "maxLength": 72
+                },
+                "new_password": {
+                    "type": "string",
+                    "maxLength": 72,
+                    "minLength": 8
+                }
+            }
+        },
+        "main.confirmTwoFactorPayload": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.createAPIKeyPayload": {
+            "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "expires_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 50
+                },
+                "scopes": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "main.createTokenPayload": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "maxLength": 50
+                },
+                "password": {
+                    "type": "string",
+                    "maxLength": 72,
+                    "minLength": 8
+                }
+            }
+        },
+        "main.createTokenTwoFactorPayload": {
+            "type": "object",
+            "required": [
+                "mfa_token"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "mfa_token": {
+                    "type": "string"
+                },
+                "recovery_code": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.disableTwoFactorPayload": {
+            "type": "object",
+            "required": [
+                "password"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string",
+                    "maxLength": 72
+                }
+            }
+        },
+        "main.forgotPasswordPayload": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "maxLength": 50
+                }
+            }
+        },
+        "main.forkExercisePayload": {
+            "type": "object",
+            "properties": {
+                "rewrite": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "main.mediaItem": {
+            "type": "object",
+            "properties": {
+                "content_type": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "exercise_id": {
+                    "type": "string"
+                },
+                "file_name": {
+                    "type": "string"
+                },
+                "height": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "kind": {
+                    "description": "image or video",
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "thumbnail_url": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "width": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.mergeExercisesPayload": {
+            "type": "object",
+            "properties": {
+                "duplicate_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "survivor_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.refreshTokenPayload": {
+            "type": "object",
+            "required": [
+                "refresh_token"
+            ],
+            "properties": {
+                "refresh_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.resetPasswordPayload": {
+            "type": "object",
+            "required": [
+                "password",
+                "token"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string",
+                    "maxLength": 72,
+                    "minLength": 8
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.restoreRevisionPayload": {
+            "type": "object",
+            "properties": {
+                "expected_version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.sessionResponse": {
+            "type": "object",
+            "properties": {
+                "current": {
+                    "type": "boolean"
+                },
+                "device": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "started_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.setRolePayload": {
+            "type": "object",
+            "required": [
+                "role"
+            ],
+            "properties": {
+                "role": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.updateAPIKeyPayload": {
+            "type": "object",
+            "properties": {
+                "expected_version": {
+                    "type": "integer"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 50
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "main.updateExercisePayload": {
+            "type": "object",
+            "properties": {
+                "aliases": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "archived": {
+                    "description": "false brings back an archived exercise",
+                    "type": "boolean"
+                },
+                "category": {
+                    "type": "string"
+                },
+                "equipment": {
+                    "type": "string"
+                },
+                "expected_version": {
+                    "type": "integer"
+                },
+                "force": {
+                    "type": "string"
+                },
+                "instructions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "level": {
+                    "type": "string"
+                },
+                "mechanic": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "primaryMuscles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "secondaryMuscles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "tracking_type": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.updateRoutinePayload": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "exercise_id": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "expected_version": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.updateUserPayload": {
+            "type": "object",
+            "properties": {
+                "age": {
+                    "type": "integer"
+                },
+                "bio": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "expected_version": {
+                    "type": "integer"
+                },
+                "first_name": {
+                    "type": "string"
+                },
+                "last_name": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.verifyEmailPayload": {
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.APIKey": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
             }
-        }
-    },
-    "definitions": {
-        "main.addSetPayload": {
+        },
+        "store.AuditEntry": {
             "type": "object",
             "properties": {
-                "reps": {
-                    "type": "integer"
+                "action": {
+                    "type": "string"
                 },
-                "set_number": {
-                    "type": "integer"
+                "actor_id": {
+                    "type": "string"
                 },
-                "weight": {
-                    "type": "number"
+                "api_key_id": {
+                    "description": "set when the actor used an API key",
+                    "type": "string"
+                },
+                "changes": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/store.Change"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "owner_id": {
+                    "description": "user whose data was affected",
+                    "type": "string"
+                },
+                "request_id": {
+                    "type": "string"
+                },
+                "resource_id": {
+                    "type": "string"
+                },
+                "resource_type": {
+                    "type": "string"
+                },
+                "user_agent": {
+                    "type": "string"
                 }
             }
         },
-        "main.updateExercisePayload": {
+        "store.Change": {
+            "type": "object",
+            "properties": {
+                "after": {},
+                "before": {}
+            }
+        },
+        "store.Exercise": {
             "type": "object",
             "properties": {
+                "aliases": {
+                    "description": "other names people use, like \"RDL\"",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "archived_at": {
+                    "description": "hidden from lists and search, kept for workout history",
+                    "type": "string"
+                },
                 "category": {
                     "type": "string"
                 },
-                "equipment": {
+                "created_at": {
                     "type": "string"
                 },
-                "expected_version": {
-                    "type": "integer"
+                "equipment": {
+                    "type": "string"
                 },
                 "force": {
                     "type": "string"
                 },
+                "forked_from": {
+                    "description": "catalog exercise a custom one was copied from",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "images": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "instructions": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
                 },
+                "is_custom": {
+                    "description": "false for the catalog shared by every user",
+                    "type": "boolean"
+                },
                 "level": {
                     "type": "string"
                 },
@@ -1461,113 +5130,232 @@ const docTemplate = `{
                     "items": {
                         "type": "string"
                     }
+                },
+                "source_id": {
+                    "description": "ID in the dataset a catalog exercise was loaded from",
+                    "type": "string"
+                },
+                "tracking_type": {
+                    "description": "empty means DefaultTrackingType",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/store.TrackingType"
+                        }
+                    ]
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "description": "nil for catalog exercises",
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
                 }
             }
         },
-        "main.updateRoutinePayload": {
+        "store.ExerciseDependents": {
             "type": "object",
             "properties": {
-                "description": {
-                    "type": "string"
-                },
-                "exercise_id": {
+                "routines": {
                     "type": "array",
                     "items": {
-                        "type": "string"
+                        "$ref": "#/definitions/store.ExerciseReference"
                     }
                 },
-                "expected_version": {
-                    "type": "integer"
-                },
-                "title": {
-                    "type": "string"
+                "workouts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.ExerciseReference"
+                    }
                 }
             }
         },
-        "main.updateUserPayload": {
+        "store.ExerciseMerge": {
             "type": "object",
             "properties": {
-                "age": {
-                    "type": "integer"
+                "added_aliases": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "bio": {
+                "created_at": {
                     "type": "string"
                 },
-                "email": {
+                "duplicates": {
+                    "description": "as they were before the merge",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.Exercise"
+                    }
+                },
+                "id": {
                     "type": "string"
                 },
-                "expected_version": {
-                    "type": "integer"
+                "references": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.MergeReference"
+                    }
                 },
-                "first_name": {
+                "survivor_id": {
                     "type": "string"
                 },
-                "last_name": {
+                "undone_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.ExerciseReference": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "start_time": {
+                    "type": "string"
+                },
+                "status": {
                     "type": "string"
                 },
                 "title": {
                     "type": "string"
+                }
+            }
+        },
+        "store.ExerciseSearchResult": {
+            "type": "object",
+            "properties": {
+                "exercises": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.Exercise"
+                    }
                 },
-                "username": {
+                "facets": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "array",
+                        "items": {
+                            "$ref": "#/definitions/store.FacetCount"
+                        }
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "store.FacetCount": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "value": {
                     "type": "string"
                 }
             }
         },
-        "store.Exercise": {
+        "store.Job": {
             "type": "object",
             "properties": {
-                "category": {
+                "created_at": {
                     "type": "string"
                 },
-                "created_at": {
+                "error": {
                     "type": "string"
                 },
-                "equipment": {
+                "expires_at": {
+                    "description": "the result is removed after this time",
                     "type": "string"
                 },
-                "force": {
+                "file_size": {
+                    "type": "integer"
+                },
+                "finished_at": {
                     "type": "string"
                 },
                 "id": {
                     "type": "string"
                 },
-                "instructions": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                "started_at": {
+                    "type": "string"
                 },
-                "is_custom": {
-                    "type": "boolean"
+                "status": {
+                    "type": "string"
                 },
-                "level": {
+                "type": {
                     "type": "string"
                 },
-                "mechanic": {
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.MergeEntry": {
+            "type": "object",
+            "properties": {
+                "entry_id": {
                     "type": "string"
                 },
-                "name": {
+                "exercise_id": {
+                    "description": "the duplicate it pointed at",
+                    "type": "string"
+                }
+            }
+        },
+        "store.MergeReference": {
+            "type": "object",
+            "properties": {
+                "collection": {
+                    "description": "routine or workout",
                     "type": "string"
                 },
-                "primaryMuscles": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                "document_id": {
+                    "type": "string"
                 },
-                "secondaryMuscles": {
+                "entries": {
                     "type": "array",
                     "items": {
-                        "type": "string"
+                        "$ref": "#/definitions/store.MergeEntry"
+                    }
+                }
+            }
+        },
+        "store.Revision": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "description": "field by field, from Version to the next",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/store.Change"
                     }
                 },
-                "updated_at": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "resource_id": {
+                    "type": "string"
+                },
+                "resource_type": {
+                    "description": "exercise or routine",
                     "type": "string"
                 },
                 "user_id": {
                     "type": "string"
                 },
                 "version": {
+                    "description": "the version the update replaced",
                     "type": "integer"
                 }
             }
@@ -1607,6 +5395,10 @@ const docTemplate = `{
         "store.RoutineExercise": {
             "type": "object",
             "properties": {
+                "entry_id": {
+                    "description": "set by the store, unlike order it never repeats or changes",
+                    "type": "string"
+                },
                 "exercise_id": {
                     "type": "string"
                 },
@@ -1630,6 +5422,10 @@ const docTemplate = `{
                         "$ref": "#/definitions/store.SessionSet"
                     }
                 },
+                "entry_id": {
+                    "description": "set by the store, unlike order it never repeats or changes",
+                    "type": "string"
+                },
                 "exercise_id": {
                     "type": "string"
                 },
@@ -1642,9 +5438,23 @@ const docTemplate = `{
         "store.SessionSet": {
             "type": "object",
             "properties": {
+                "added_weight": {
+                    "description": "on top of bodyweight",
+                    "type": "number"
+                },
+                "assist_weight": {
+                    "description": "taken off bodyweight",
+                    "type": "number"
+                },
                 "completed_at": {
                     "type": "string"
                 },
+                "distance_meters": {
+                    "type": "number"
+                },
+                "duration_seconds": {
+                    "type": "integer"
+                },
                 "reps": {
                     "type": "integer"
                 },
@@ -1659,6 +5469,20 @@ const docTemplate = `{
         "store.TemplateSet": {
             "type": "object",
             "properties": {
+                "added_weight": {
+                    "description": "on top of bodyweight",
+                    "type": "number"
+                },
+                "assist_weight": {
+                    "description": "taken off bodyweight",
+                    "type": "number"
+                },
+                "distance_meters": {
+                    "type": "number"
+                },
+                "duration_seconds": {
+                    "type": "integer"
+                },
                 "reps": {
                     "type": "integer"
                 },
@@ -1670,6 +5494,38 @@ const docTemplate = `{
                 }
             }
         },
+        "store.TrackingType": {
+            "type": "string",
+            "enum": [
+                "weight_reps",
+                "bodyweight_reps",
+                "weighted_bodyweight",
+                "assisted_bodyweight",
+                "duration",
+                "distance_duration",
+                "weight_distance",
+                "weight_reps"
+            ],
+            "x-enum-comments": {
+                "TrackingAssistedBodyweight": "assisted pull up: reps with assist weight",
+                "TrackingBodyweightReps": "push up: reps only",
+                "TrackingDistanceDuration": "run or row: distance and time",
+                "TrackingDuration": "plank: time",
+                "TrackingWeightDistance": "farmer carry: weight and distance",
+                "TrackingWeightReps": "barbell bench press: weight and reps",
+                "TrackingWeightedBodyweight": "weighted dip: reps with added weight"
+            },
+            "x-enum-varnames": [
+                "TrackingWeightReps",
+                "TrackingBodyweightReps",
+                "TrackingWeightedBodyweight",
+                "TrackingAssistedBodyweight",
+                "TrackingDuration",
+                "TrackingDistanceDuration",
+                "TrackingWeightDistance",
+                "DefaultTrackingType"
+            ]
+        },
         "store.User": {
             "type": "object",
             "properties": {
@@ -1682,9 +5538,19 @@ const docTemplate = `{
                 "created_at": {
                     "type": "string"
                 },
+                "deletion_scheduled_for": {
+                    "description": "account is purged after this time",
+                    "type": "string"
+                },
+                "disabled": {
+                    "type": "boolean"
+                },
                 "email": {
                     "type": "string"
                 },
+                "email_verified": {
+                    "type": "boolean"
+                },
                 "first_name": {
                     "type": "string"
                 },
@@ -1694,12 +5560,22 @@ const docTemplate = `{
                 "last_name": {
                     "type": "string"
                 },
-                "password_hash": {
+                "password_reset_required": {
+                    "type": "boolean"
+                },
+                "pending_email": {
+                    "description": "new address waiting to be confirmed",
+                    "type": "string"
+                },
+                "role": {
                     "type": "string"
                 },
                 "title": {
                     "type": "string"
                 },
+                "totp_enabled": {
+                    "type": "boolean"
+                },
                 "updated_at": {
                     "type": "string"
                 },
@@ -1768,6 +5644,7 @@ const docTemplate = `{
     },
     "securityDefinitions": {
         "ApiKeyAuth": {
+            "description": "\"Bearer \u003caccess token\u003e\" from /authentication/token, or a personal API key starting with gf_",
             "type": "apiKey",
             "name": "Authorization",
             "in": "header"
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrExpiredToken = errors.New("token has expired")
+)
+
+// PurposeMFA marks the short-lived token handed out between the password and TOTP login steps
+const PurposeMFA = "mfa"
+
+type Authenticator interface {
+	GenerateToken(Claims) (string, error)
+	ValidateToken(string) (*Claims, error)
+}
+
+// Claims are the registered JWT claims GetFit issues and checks
+type Claims struct {
+	Subject   string  `json:"sub"`
+	SessionID string  `json:"sid,omitempty"`
+	Purpose   string  `json:"pur,omitempty"` // empty for access tokens
+	Issuer    string  `json:"iss"`
+	Audience  string  `json:"aud"`
+	ExpiresAt int64   `json:"exp"`
+	NotBefore int64   `json:"nbf"`
+	IssuedAt  float64 `json:"iat"` // to the millisecond, to tell tokens apart from a password change in the same second
+}
+
+// NewClaims builds the claims for a token that is valid from now until now+exp
+func NewClaims(subject string, exp time.Duration) Claims {
+	now := time.Now()
+	return Claims{
+		Subject:   subject,
+		ExpiresAt: now.Add(exp).Unix(),
+		NotBefore: now.Unix(),
+		IssuedAt:  numericDate(now),
+	}
+}
+
+// IssuedAtOrBefore reports whether the token was issued no later than t, to the millisecond
+func (c *Claims) IssuedAtOrBefore(t time.Time) bool {
+	return c.IssuedAt <= numericDate(t)
+}
+
+// IssueAfter moves the issue time past t, for a token handed out right after a
+// cut-off at t that must not be caught by it
+func (c *Claims) IssueAfter(t time.Time) {
+	if after := numericDate(t.Add(time.Millisecond)); c.IssuedAt < after {
+		c.IssuedAt = after
+	}
+}
+
+// numericDate is t in seconds since the epoch, to the millisecond
+func numericDate(t time.Time) float64 {
+	return float64(t.UnixMilli()) / 1000
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIssuedAtSurvivesSigning(t *testing.T) {
+	authenticator := NewJWTAuthenticator("0123456789abcdef0123456789abcdef", "getfit", "getfit")
+
+	claims := NewClaims("user", time.Minute)
+	token, err := authenticator.GenerateToken(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	validated, err := authenticator.ValidateToken(token)
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+	if validated.IssuedAt != claims.IssuedAt {
+		t.Errorf("iat = %v after signing, want %v", validated.IssuedAt, claims.IssuedAt)
+	}
+}
+
+func TestIssuedAtOrBefore(t *testing.T) {
+	changedAt := time.Date(2026, 3, 1, 12, 0, 0, 250*int(time.Millisecond), time.UTC)
+
+	tests := []struct {
+		name     string
+		issuedAt time.Time
+		want     bool
+	}{
+		{"a second earlier", changedAt.Add(-time.Second), true},
+		{"earlier in the same second", changedAt.Add(-200 * time.Millisecond), true},
+		{"the same millisecond", changedAt.Add(400 * time.Microsecond), true},
+		{"a millisecond later", changedAt.Add(time.Millisecond), false},
+		{"later in the same second", changedAt.Add(500 * time.Millisecond), false},
+	}
+
+	for _, tt := range tests {
+		claims := Claims{IssuedAt: numericDate(tt.issuedAt)}
+		if got := claims.IssuedAtOrBefore(changedAt); got != tt.want {
+			t.Errorf("%s: IssuedAtOrBefore = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIssueAfter(t *testing.T) {
+	changedAt := time.Date(2026, 3, 1, 12, 0, 0, 250*int(time.Millisecond), time.UTC)
+
+	// issued in the same millisecond as the change, it would be cut off
+	claims := Claims{IssuedAt: numericDate(changedAt)}
+	claims.IssueAfter(changedAt)
+	if claims.IssuedAtOrBefore(changedAt) {
+		t.Error("a token moved past the change is still cut off by it")
+	}
+
+	// a token issued well after is left as it is
+	later := Claims{IssuedAt: numericDate(changedAt.Add(time.Minute))}
+	want := later.IssuedAt
+	later.IssueAfter(changedAt)
+	if later.IssuedAt != want {
+		t.Errorf("IssueAfter moved a later token from %v to %v", want, later.IssuedAt)
+	}
+}
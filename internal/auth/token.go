@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// NewOpaqueToken returns a random token to hand to the client along with the hash to persist
+func NewOpaqueToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	plaintext := base64.RawURLEncoding.EncodeToString(b)
+	return plaintext, HashToken(plaintext), nil
+}
+
+// HashToken hashes an opaque token so only its digest is ever stored
+func HashToken(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
+
+// APIKeyPrefix starts every personal API key so they are easy to recognise and scan for
+const APIKeyPrefix = "gf_"
+
+// NewAPIKey returns a new personal API key, the short prefix that identifies it and the hash to persist
+func NewAPIKey() (string, string, string, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", err
+	}
+	prefix := APIKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	secret, _, err := NewOpaqueToken()
+	if err != nil {
+		return "", "", "", err
+	}
+
+	key := prefix + "_" + secret
+	return key, prefix, HashToken(key), nil
+}
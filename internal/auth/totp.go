@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TOTP generates and checks RFC 6238 time-based one-time passwords (SHA1, 6 digits, 30s)
+// as understood by common authenticator apps. Now can be replaced with a fixed clock.
+type TOTP struct {
+	Issuer string
+	Period time.Duration
+	Digits int
+	Skew   int64 // number of periods either side of now that are still accepted
+	Now    func() time.Time
+}
+
+func NewTOTP(issuer string) *TOTP {
+	return &TOTP{
+		Issuer: issuer,
+		Period: 30 * time.Second,
+		Digits: 6,
+		Skew:   1,
+		Now:    time.Now,
+	}
+}
+
+// GenerateSecret returns a random base32 encoded secret
+func (t *TOTP) GenerateSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(b), nil
+}
+
+// URI returns the otpauth:// URI authenticator apps read from a QR code
+func (t *TOTP) URI(secret, account string) string {
+	label := url.PathEscape(t.Issuer + ":" + account)
+
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", t.Issuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(t.Digits))
+	params.Set("period", fmt.Sprint(int(t.Period.Seconds())))
+
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// Step returns the time step the given time falls into
+func (t *TOTP) Step(at time.Time) int64 {
+	return at.Unix() / int64(t.Period.Seconds())
+}
+
+// Code returns the code for a time step
+func (t *TOTP) Code(secret string, step int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", fmt.Errorf("invalid totp secret: %w", err)
+	}
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// dynamic truncation, RFC 4226 section 5.3
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < t.Digits; i++ {
+		mod *= 10
+	}
+
+	return fmt.Sprintf("%0*d", t.Digits, value%mod), nil
+}
+
+// Validate checks a code against the current time step and the allowed skew.
+// It returns the matching step so callers can refuse to accept the same step twice.
+func (t *TOTP) Validate(secret, code string) (int64, bool) {
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if len(code) != t.Digits {
+		return 0, false
+	}
+
+	current := t.Step(t.Now())
+	for step := current - t.Skew; step <= current+t.Skew; step++ {
+		expected, err := t.Code(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// ValidateAfter is Validate for a code that must be newer than the last step used,
+// so a code that was accepted once is refused when it is presented again
+func (t *TOTP) ValidateAfter(secret, code string, lastUsed int64) (int64, bool) {
+	step, ok := t.Validate(secret, code)
+	if !ok || step <= lastUsed {
+		return 0, false
+	}
+	return step, true
+}
+
+// GenerateRecoveryCodes returns n random one-time recovery codes formatted as xxxxx-xxxxx
+func GenerateRecoveryCodes(n int) ([]string, error) {
+	codes := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		code := strings.ToLower(totpEncoding.EncodeToString(b))[:10]
+		codes = append(codes, code[:5]+"-"+code[5:])
+	}
+	return codes, nil
+}
+
+// NormalizeRecoveryCode lets users type recovery codes with any casing or spacing
+func NormalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), " ", ""))
+	if len(code) == 10 && !strings.Contains(code, "-") {
+		code = code[:5] + "-" + code[5:]
+	}
+	return code
+}
+
+// RedeemRecoveryCode looks a typed recovery code up among the hashes of the unused
+// ones, and returns its hash with the hashes left once it is used up
+func RedeemRecoveryCode(hashes []string, code string) (remaining []string, hash string, ok bool) {
+	hash = HashToken(NormalizeRecoveryCode(code))
+	for i, candidate := range hashes {
+		if subtle.ConstantTimeCompare([]byte(candidate), []byte(hash)) == 1 {
+			remaining = append(append(remaining, hashes[:i]...), hashes[i+1:]...)
+			return remaining, hash, true
+		}
+	}
+	return hashes, "", false
+}
@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// the SHA1 secret of RFC 6238 appendix B, "12345678901234567890" in base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func fixedTOTP(at time.Time) *TOTP {
+	totp := NewTOTP("GetFit")
+	totp.Now = func() time.Time { return at }
+	return totp
+}
+
+func TestTOTPRFC6238Vectors(t *testing.T) {
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+
+	for _, tt := range tests {
+		at := time.Unix(tt.unix, 0)
+		totp := fixedTOTP(at)
+		totp.Digits = 8
+
+		code, err := totp.Code(rfcSecret, totp.Step(at))
+		if err != nil {
+			t.Fatalf("Code at %d: %v", tt.unix, err)
+		}
+		if code != tt.code {
+			t.Errorf("Code at %d = %s, want %s", tt.unix, code, tt.code)
+		}
+
+		if _, ok := totp.Validate(rfcSecret, tt.code); !ok {
+			t.Errorf("Validate at %d refused %s", tt.unix, tt.code)
+		}
+	}
+}
+
+func TestTOTPSkewWindow(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	totp := fixedTOTP(now)
+	current := totp.Step(now)
+
+	for offset := int64(-2); offset <= 2; offset++ {
+		code, err := totp.Code(rfcSecret, current+offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		step, ok := totp.Validate(rfcSecret, code)
+		wantOK := offset >= -1 && offset <= 1
+		if ok != wantOK {
+			t.Errorf("offset %d: accepted = %v, want %v", offset, ok, wantOK)
+		}
+		if ok && step != current+offset {
+			t.Errorf("offset %d: step = %d, want %d", offset, step, current+offset)
+		}
+	}
+}
+
+func TestTOTPRejectsMalformedCodes(t *testing.T) {
+	totp := fixedTOTP(time.Unix(1234567890, 0))
+
+	for _, code := range []string{"", "12345", "1234567", "abcdef"} {
+		if _, ok := totp.Validate(rfcSecret, code); ok {
+			t.Errorf("Validate accepted %q", code)
+		}
+	}
+}
+
+func TestTOTPRejectsReplay(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	totp := fixedTOTP(now)
+
+	code, err := totp.Code(rfcSecret, totp.Step(now))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	step, ok := totp.ValidateAfter(rfcSecret, code, 0)
+	if !ok {
+		t.Fatal("first use of the code was refused")
+	}
+	if _, ok := totp.ValidateAfter(rfcSecret, code, step); ok {
+		t.Error("the same code was accepted twice")
+	}
+
+	// an older code inside the skew window is refused once a newer step was used
+	previous, err := totp.Code(rfcSecret, step-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := totp.ValidateAfter(rfcSecret, previous, step); ok {
+		t.Error("a code older than the last used step was accepted")
+	}
+
+	// the next period's code is still fine
+	totp.Now = func() time.Time { return now.Add(totp.Period) }
+	next, err := totp.Code(rfcSecret, step+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := totp.ValidateAfter(rfcSecret, next, step); !ok {
+		t.Error("the code of the next period was refused")
+	}
+}
+
+func TestRecoveryCodesAreSingleUse(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = HashToken(NormalizeRecoveryCode(code))
+	}
+
+	// typed without the dash and in capitals, as users do
+	typed := " " + strings.ToUpper(codes[1][:5]+codes[1][6:]) + " "
+	remaining, hash, ok := RedeemRecoveryCode(hashes, typed)
+	if !ok {
+		t.Fatalf("recovery code %q was refused", typed)
+	}
+	if hash != hashes[1] {
+		t.Errorf("redeemed hash = %s, want %s", hash, hashes[1])
+	}
+	if len(remaining) != 2 || remaining[0] != hashes[0] || remaining[1] != hashes[2] {
+		t.Errorf("remaining = %v, want the other two codes", remaining)
+	}
+
+	if _, _, ok := RedeemRecoveryCode(remaining, codes[1]); ok {
+		t.Error("a used recovery code was accepted again")
+	}
+	if _, _, ok := RedeemRecoveryCode(remaining, codes[0]); !ok {
+		t.Error("an unused recovery code was refused")
+	}
+	if _, _, ok := RedeemRecoveryCode(hashes, "aaaaa-bbbbb"); ok {
+		t.Error("an unknown recovery code was accepted")
+	}
+}
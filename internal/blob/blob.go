@@ -0,0 +1,35 @@
+// Package blob stores uploaded files, like exercise media, outside the database.
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrNotFound is returned when no blob is stored under a key
+var ErrNotFound = errors.New("blob not found")
+
+// BlobStore keeps files under slash separated keys, like "users/<id>/media/<id>"
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get returns the blob's content, the caller closes it
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes a blob, deleting a missing key is not an error
+	Delete(ctx context.Context, key string) error
+}
+
+// validKey rejects keys that could escape the store's root, on disk or in a bucket
+func validKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.HasSuffix(key, "/") {
+		return fmt.Errorf("invalid blob key %q", key)
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." || strings.ContainsRune(part, '\\') {
+			return fmt.Errorf("invalid blob key %q", key)
+		}
+	}
+	return nil
+}
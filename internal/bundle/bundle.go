@@ -0,0 +1,150 @@
+// Package bundle reads and writes the ZIP archive used for personal data exports
+// and imports.
+// The JSON files are the source of truth, the CSV files are flattened copies for
+// spreadsheets.
+package bundle
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/FaustCelaj/GetFit.git/internal/store"
+)
+
+// FormatVersion is bumped whenever the layout or meaning of a file changes
+const FormatVersion = 1
+
+const (
+	ManifestFile  = "manifest.json"
+	ProfileFile   = "profile.json"
+	ExercisesFile = "exercises.json"
+	RoutinesFile  = "routines.json"
+	WorkoutsFile  = "workouts.json"
+
+	ExercisesCSVFile   = "exercises.csv"
+	RoutineSetsCSVFile = "routine_sets.csv"
+	WorkoutsCSVFile    = "workouts.csv"
+	WorkoutSetsCSVFile = "workout_sets.csv"
+)
+
+// Manifest describes the archive and is always its first file
+type Manifest struct {
+	FormatVersion int            `json:"format_version"`
+	ExportedAt    time.Time      `json:"exported_at"`
+	UserID        string         `json:"user_id"`
+	Counts        map[string]int `json:"counts"`
+}
+
+// Bundle is everything a user owns. It is written as separate files inside the
+// archive, the json tags are used when a bundle is uploaded as a single document.
+type Bundle struct {
+	Manifest  Manifest                `json:"manifest"`
+	Profile   *store.User             `json:"profile"`
+	Exercises []*store.Exercise       `json:"exercises"`
+	Routines  []*store.Routine        `json:"routines"`
+	Workouts  []*store.WorkoutSession `json:"workouts"`
+}
+
+// New wraps a user's data in a bundle with a filled in manifest
+func New(profile *store.User, exercises []*store.Exercise, routines []*store.Routine, workouts []*store.WorkoutSession) *Bundle {
+	return &Bundle{
+		Manifest: Manifest{
+			FormatVersion: FormatVersion,
+			ExportedAt:    time.Now().UTC(),
+			UserID:        profile.ID.Hex(),
+			Counts: map[string]int{
+				"exercises": len(exercises),
+				"routines":  len(routines),
+				"workouts":  len(workouts),
+			},
+		},
+		Profile:   profile,
+		Exercises: exercises,
+		Routines:  routines,
+		Workouts:  workouts,
+	}
+}
+
+// Write writes the bundle to w as a ZIP archive
+func Write(w io.Writer, b *Bundle) error {
+	zw := zip.NewWriter(w)
+
+	jsonFiles := []struct {
+		name  string
+		value interface{}
+	}{
+		{ManifestFile, b.Manifest},
+		{ProfileFile, b.Profile},
+		{ExercisesFile, nonNil(b.Exercises)},
+		{RoutinesFile, nonNil(b.Routines)},
+		{WorkoutsFile, nonNil(b.Workouts)},
+	}
+	for _, file := range jsonFiles {
+		if err := writeJSON(zw, file.name, file.value); err != nil {
+			return err
+		}
+	}
+
+	csvFiles := []struct {
+		name  string
+		write func(io.Writer) error
+	}{
+		{ExercisesCSVFile, func(w io.Writer) error { return writeExercisesCSV(w, b.Exercises) }},
+		{RoutineSetsCSVFile, func(w io.Writer) error { return writeRoutineSetsCSV(w, b.Routines) }},
+		{WorkoutsCSVFile, func(w io.Writer) error { return writeWorkoutsCSV(w, b.Workouts) }},
+		{WorkoutSetsCSVFile, func(w io.Writer) error { return writeWorkoutSetsCSV(w, b.Workouts) }},
+	}
+	for _, file := range csvFiles {
+		fw, err := create(zw, file.name)
+		if err != nil {
+			return err
+		}
+		if err := file.write(fw); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file.name, err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to finish archive: %w", err)
+	}
+
+	return nil
+}
+
+func writeJSON(zw *zip.Writer, name string, value interface{}) error {
+	fw, err := create(zw, name)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(fw)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+
+	return nil
+}
+
+func create(zw *zip.Writer, name string) (io.Writer, error) {
+	fw, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to add %s to archive: %w", name, err)
+	}
+	return fw, nil
+}
+
+// nonNil keeps empty lists as [] instead of null in the JSON files
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
@@ -0,0 +1,162 @@
+package bundle
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/FaustCelaj/GetFit.git/internal/store"
+)
+
+func writeExercisesCSV(w io.Writer, exercises []*store.Exercise) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "name", "category", "force", "level", "mechanic", "equipment", "primary_muscles", "secondary_muscles", "tracking_type", "is_custom", "created_at"})
+
+	for _, exercise := range exercises {
+		cw.Write([]string{
+			exercise.ID.Hex(),
+			exercise.Name,
+			exercise.Category,
+			stringValue(exercise.Force),
+			stringValue(exercise.Level),
+			stringValue(exercise.Mechanic),
+			stringValue(exercise.Equipment),
+			joinValues(exercise.PrimaryMuscles),
+			joinValues(exercise.SecondaryMuscles),
+			string(exercise.Tracking()),
+			strconv.FormatBool(exercise.IsCustom),
+			formatTime(exercise.CreatedAt),
+		})
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// one row per template set, routines without exercises get no rows
+func writeRoutineSetsCSV(w io.Writer, routines []*store.Routine) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"routine_id", "routine_title", "exercise_id", "exercise_order", "set_number", "weight", "reps", "duration_seconds", "distance_meters", "added_weight", "assist_weight"})
+
+	for _, routine := range routines {
+		for _, exercise := range routine.Exercises {
+			for _, set := range exercise.Sets {
+				cw.Write([]string{
+					routine.ID.Hex(),
+					routine.Title,
+					exercise.ExerciseID.Hex(),
+					strconv.Itoa(exercise.Order),
+					strconv.Itoa(int(set.SetNumber)),
+					formatFloat(set.Weight),
+					strconv.Itoa(int(set.Reps)),
+					strconv.Itoa(int(set.DurationSeconds)),
+					formatFloat(set.DistanceMeters),
+					formatFloat(set.AddedWeight),
+					formatFloat(set.AssistWeight),
+				})
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeWorkoutsCSV(w io.Writer, workouts []*store.WorkoutSession) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "title", "routine_id", "status", "start_time", "end_time", "exercise_count", "notes", "metrics"})
+
+	for _, workout := range workouts {
+		routineID := ""
+		if workout.RoutineID != nil {
+			routineID = workout.RoutineID.Hex()
+		}
+		endTime := ""
+		if workout.EndTime != nil {
+			endTime = formatTime(*workout.EndTime)
+		}
+		// metrics have no fixed shape, so they are kept as a JSON object
+		metrics := ""
+		if len(workout.Metrics) > 0 {
+			encoded, err := json.Marshal(workout.Metrics)
+			if err != nil {
+				return err
+			}
+			metrics = string(encoded)
+		}
+
+		cw.Write([]string{
+			workout.ID.Hex(),
+			workout.Title,
+			routineID,
+			workout.Status,
+			formatTime(workout.StartTime),
+			endTime,
+			strconv.Itoa(len(workout.Exercises)),
+			stringValue(workout.Notes),
+			metrics,
+		})
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// one row per completed set
+func writeWorkoutSetsCSV(w io.Writer, workouts []*store.WorkoutSession) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"workout_id", "workout_title", "exercise_id", "exercise_order", "set_number", "weight", "reps", "duration_seconds", "distance_meters", "added_weight", "assist_weight", "completed_at"})
+
+	for _, workout := range workouts {
+		for _, exercise := range workout.Exercises {
+			for _, set := range exercise.CompletedSets {
+				cw.Write([]string{
+					workout.ID.Hex(),
+					workout.Title,
+					exercise.ExerciseID.Hex(),
+					strconv.Itoa(exercise.Order),
+					strconv.Itoa(int(set.SetNumber)),
+					formatFloat(set.Weight),
+					strconv.Itoa(int(set.Reps)),
+					strconv.Itoa(int(set.DurationSeconds)),
+					formatFloat(set.DistanceMeters),
+					formatFloat(set.AddedWeight),
+					formatFloat(set.AssistWeight),
+					formatTime(set.CompletedAt),
+				})
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// list values share a cell, separated by semicolons
+func joinValues(values *[]string) string {
+	if values == nil {
+		return ""
+	}
+	return strings.Join(*values, ";")
+}
+
+func formatFloat(f float32) string {
+	return strconv.FormatFloat(float64(f), 'f', -1, 32)
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,373 @@
+package bundle
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/FaustCelaj/GetFit.git/internal/fuzzy"
+	"github.com/FaustCelaj/GetFit.git/internal/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// How an imported item that matches one the user already has is handled.
+// Exercises match by name, routines by title and workouts by title and start time.
+const (
+	ModeSkip      = "skip"      // keep the existing item, references point at it
+	ModeOverwrite = "overwrite" // replace the existing item's content, keeping its ID
+	ModeKeepBoth  = "keep_both" // import a second copy
+)
+
+var Modes = []string{ModeSkip, ModeOverwrite, ModeKeepBoth}
+
+// what happened to each item of the bundle
+const (
+	ActionCreate    = "create"
+	ActionOverwrite = "overwrite"
+	ActionSkip      = "skip"
+	ActionUpdate    = "update"
+)
+
+// suffix added to the name of a duplicate imported with ModeKeepBoth
+const keepBothSuffix = " (imported)"
+
+// Existing is what the account already holds
+type Existing struct {
+	Profile   *store.User
+	Exercises []*store.Exercise
+	Routines  []*store.Routine
+	Workouts  []*store.WorkoutSession
+	// SharedExercises are catalog exercises referenced by the bundle, which exist on
+	// every instance under the same ID, those references are kept as is
+	SharedExercises map[primitive.ObjectID]bool
+}
+
+// ReportItem describes the outcome for one item of the bundle
+type ReportItem struct {
+	Type     string `json:"type"`
+	SourceID string `json:"source_id"`
+	TargetID string `json:"target_id,omitempty"`
+	Name     string `json:"name"`
+	Action   string `json:"action"`
+}
+
+// Report summarises an import, it is returned for dry runs as well
+type Report struct {
+	Mode     string                    `json:"mode"`
+	DryRun   bool                      `json:"dry_run"`
+	Counts   map[string]map[string]int `json:"counts"` // type -> action -> count
+	Items    []ReportItem              `json:"items"`
+	Warnings []string                  `json:"warnings"`
+}
+
+// Merge works out the writes needed to bring the bundle into the user's account.
+// Every imported document gets a new ObjectID, unless it overwrites an existing one,
+// and the exercise and routine references inside routines and workouts are
+// rewritten to match. Nothing is written, the caller applies the result.
+func Merge(b *Bundle, existing *Existing, userID primitive.ObjectID, mode string) (*store.ImportSet, *Report, error) {
+	valid := false
+	for _, m := range Modes {
+		if mode == m {
+			valid = true
+		}
+	}
+	if !valid {
+		return nil, nil, fmt.Errorf("mode must be one of %s", strings.Join(Modes, ", "))
+	}
+
+	m := &merger{
+		existing:  existing,
+		userID:    userID,
+		mode:      mode,
+		now:       time.Now(),
+		set:       &store.ImportSet{},
+		exercises: make(map[primitive.ObjectID]primitive.ObjectID),
+		routines:  make(map[primitive.ObjectID]primitive.ObjectID),
+		report: &Report{
+			Mode:     mode,
+			Counts:   make(map[string]map[string]int),
+			Items:    []ReportItem{},
+			Warnings: []string{},
+		},
+	}
+
+	// exercises first, routines and workouts refer to them
+	m.mergeProfile(b.Profile)
+	m.mergeExercises(b.Exercises)
+	m.mergeRoutines(b.Routines)
+	m.mergeWorkouts(b.Workouts)
+
+	return m.set, m.report, nil
+}
+
+type merger struct {
+	existing *Existing
+	userID   primitive.ObjectID
+	mode     string
+	now      time.Time
+	set      *store.ImportSet
+	report   *Report
+
+	// bundle ID -> ID in the account
+	exercises map[primitive.ObjectID]primitive.ObjectID
+	routines  map[primitive.ObjectID]primitive.ObjectID
+}
+
+func (m *merger) record(resourceType string, sourceID, targetID primitive.ObjectID, name, action string) {
+	item := ReportItem{
+		Type:     resourceType,
+		SourceID: sourceID.Hex(),
+		Name:     name,
+		Action:   action,
+	}
+	if targetID != primitive.NilObjectID {
+		item.TargetID = targetID.Hex()
+	}
+	m.report.Items = append(m.report.Items, item)
+
+	if m.report.Counts[resourceType] == nil {
+		m.report.Counts[resourceType] = make(map[string]int)
+	}
+	m.report.Counts[resourceType][action]++
+}
+
+func (m *merger) warn(format string, args ...interface{}) {
+	m.report.Warnings = append(m.report.Warnings, fmt.Sprintf(format, args...))
+}
+
+// mergeProfile copies the descriptive profile fields. Username and email identify
+// the account and are never imported. Unless overwriting, only empty fields are filled.
+func (m *merger) mergeProfile(profile *store.User) {
+	if profile == nil || m.existing.Profile == nil {
+		return
+	}
+	current := m.existing.Profile
+	overwrite := m.mode == ModeOverwrite
+
+	updates := map[string]interface{}{}
+	setString := func(field, value, currentValue string) {
+		if value != "" && value != currentValue && (overwrite || currentValue == "") {
+			updates[field] = value
+		}
+	}
+	setString("first_name", profile.FirstName, current.FirstName)
+	setString("last_name", profile.LastName, current.LastName)
+	setString("title", profile.Title, current.Title)
+	setString("bio", profile.Bio, current.Bio)
+	if profile.Age != 0 && profile.Age != current.Age && (overwrite || current.Age == 0) {
+		updates["age"] = profile.Age
+	}
+
+	action := ActionSkip
+	if len(updates) > 0 {
+		m.set.Profile = updates
+		action = ActionUpdate
+	}
+	m.record(store.AuditResourceUser, profile.ID, current.ID, current.Username, action)
+}
+
+func (m *merger) mergeExercises(exercises []*store.Exercise) {
+	// an imported exercise is a duplicate when its name is the name or an alias of an existing one
+	byName := make(map[string]*store.Exercise, len(m.existing.Exercises))
+	for _, e := range m.existing.Exercises {
+		for _, alias := range e.Aliases {
+			byName[normalize(alias)] = e
+		}
+	}
+	for _, e := range m.existing.Exercises {
+		byName[normalize(e.Name)] = e
+	}
+
+	for _, e := range exercises {
+		if e == nil {
+			continue
+		}
+		if strings.TrimSpace(e.Name) == "" {
+			m.warn("exercise %s has no name and was not imported", e.ID.Hex())
+			continue
+		}
+
+		sourceID := e.ID
+		imported := *e
+		imported.UserID = &m.userID
+		imported.IsCustom = true
+		imported.UpdatedAt = m.now
+		if imported.CreatedAt.IsZero() {
+			imported.CreatedAt = m.now
+		}
+
+		match, duplicate := byName[normalize(e.Name)]
+		switch {
+		case duplicate && m.mode == ModeSkip:
+			m.exercises[sourceID] = match.ID
+			m.record(store.AuditResourceExercise, sourceID, match.ID, e.Name, ActionSkip)
+
+		case duplicate && m.mode == ModeOverwrite:
+			imported.ID = match.ID
+			imported.CreatedAt = match.CreatedAt
+			imported.Version = match.Version + 1
+			m.exercises[sourceID] = match.ID
+			m.set.ReplaceExercises = append(m.set.ReplaceExercises, &imported)
+			m.record(store.AuditResourceExercise, sourceID, match.ID, e.Name, ActionOverwrite)
+
+		default:
+			if duplicate {
+				imported.Name += keepBothSuffix
+			}
+			imported.ID = primitive.NewObjectID()
+			imported.Version = 1
+			m.exercises[sourceID] = imported.ID
+			m.set.Exercises = append(m.set.Exercises, &imported)
+			m.record(store.AuditResourceExercise, sourceID, imported.ID, imported.Name, ActionCreate)
+		}
+	}
+}
+
+// exerciseID maps an exercise reference from the bundle to the account
+func (m *merger) exerciseID(sourceID primitive.ObjectID) (primitive.ObjectID, bool) {
+	if id, ok := m.exercises[sourceID]; ok {
+		return id, true
+	}
+	if m.existing.SharedExercises[sourceID] {
+		return sourceID, true
+	}
+	return primitive.NilObjectID, false
+}
+
+func (m *merger) mergeRoutines(routines []*store.Routine) {
+	byTitle := make(map[string]*store.Routine, len(m.existing.Routines))
+	for _, r := range m.existing.Routines {
+		byTitle[normalize(r.Title)] = r
+	}
+
+	for _, r := range routines {
+		if r == nil {
+			continue
+		}
+		if strings.TrimSpace(r.Title) == "" {
+			m.warn("routine %s has no title and was not imported", r.ID.Hex())
+			continue
+		}
+
+		sourceID := r.ID
+		imported := *r
+		imported.UserID = m.userID
+		imported.UpdatedAt = m.now
+		if imported.CreatedAt.IsZero() {
+			imported.CreatedAt = m.now
+		}
+
+		imported.Exercises = make([]store.RoutineExercise, 0, len(r.Exercises))
+		for _, re := range r.Exercises {
+			id, ok := m.exerciseID(re.ExerciseID)
+			if !ok {
+				m.warn("routine %q refers to exercise %s which is not in the bundle, it was left out", r.Title, re.ExerciseID.Hex())
+				continue
+			}
+			re.ExerciseID = id
+			re.EntryID = primitive.NewObjectID()
+			imported.Exercises = append(imported.Exercises, re)
+		}
+		if len(imported.Exercises) == 0 {
+			m.warn("routine %q has no exercises left and was not imported", r.Title)
+			continue
+		}
+
+		match, duplicate := byTitle[normalize(r.Title)]
+		switch {
+		case duplicate && m.mode == ModeSkip:
+			m.routines[sourceID] = match.ID
+			m.record(store.AuditResourceRoutine, sourceID, match.ID, r.Title, ActionSkip)
+
+		case duplicate && m.mode == ModeOverwrite:
+			imported.ID = match.ID
+			imported.CreatedAt = match.CreatedAt
+			imported.Version = match.Version + 1
+			m.routines[sourceID] = match.ID
+			m.set.ReplaceRoutines = append(m.set.ReplaceRoutines, &imported)
+			m.record(store.AuditResourceRoutine, sourceID, match.ID, r.Title, ActionOverwrite)
+
+		default:
+			if duplicate {
+				imported.Title += keepBothSuffix
+			}
+			imported.ID = primitive.NewObjectID()
+			imported.Version = 1
+			m.routines[sourceID] = imported.ID
+			m.set.Routines = append(m.set.Routines, &imported)
+			m.record(store.AuditResourceRoutine, sourceID, imported.ID, imported.Title, ActionCreate)
+		}
+	}
+}
+
+func (m *merger) mergeWorkouts(workouts []*store.WorkoutSession) {
+	byKey := make(map[string]*store.WorkoutSession, len(m.existing.Workouts))
+	for _, w := range m.existing.Workouts {
+		byKey[workoutKey(w)] = w
+	}
+
+	for _, w := range workouts {
+		if w == nil {
+			continue
+		}
+
+		sourceID := w.ID
+		imported := *w
+		imported.UserID = m.userID
+		imported.UpdatedAt = m.now
+		if imported.CreatedAt.IsZero() {
+			imported.CreatedAt = m.now
+		}
+
+		// a workout whose routine did not come along is kept as a freestyle workout
+		if w.RoutineID != nil {
+			if id, ok := m.routines[*w.RoutineID]; ok {
+				imported.RoutineID = &id
+			} else {
+				imported.RoutineID = nil
+				m.warn("workout %q refers to routine %s which is not in the bundle, it was imported without it", w.Title, w.RoutineID.Hex())
+			}
+		}
+
+		imported.Exercises = make([]store.SessionExercise, 0, len(w.Exercises))
+		for _, se := range w.Exercises {
+			id, ok := m.exerciseID(se.ExerciseID)
+			if !ok {
+				m.warn("workout %q refers to exercise %s which is not in the bundle, it was left out", w.Title, se.ExerciseID.Hex())
+				continue
+			}
+			se.ExerciseID = id
+			se.EntryID = primitive.NewObjectID()
+			imported.Exercises = append(imported.Exercises, se)
+		}
+
+		match, duplicate := byKey[workoutKey(w)]
+		switch {
+		case duplicate && m.mode == ModeSkip:
+			m.record(store.AuditResourceWorkout, sourceID, match.ID, w.Title, ActionSkip)
+
+		case duplicate && m.mode == ModeOverwrite:
+			imported.ID = match.ID
+			imported.CreatedAt = match.CreatedAt
+			imported.Version = match.Version + 1
+			m.set.ReplaceWorkouts = append(m.set.ReplaceWorkouts, &imported)
+			m.record(store.AuditResourceWorkout, sourceID, match.ID, w.Title, ActionOverwrite)
+
+		default:
+			imported.ID = primitive.NewObjectID()
+			imported.Version = 1
+			m.set.Workouts = append(m.set.Workouts, &imported)
+			m.record(store.AuditResourceWorkout, sourceID, imported.ID, imported.Title, ActionCreate)
+		}
+	}
+}
+
+// names compare without case, accents or punctuation
+func normalize(name string) string {
+	return fuzzy.Normalize(name)
+}
+
+// workouts are the same session when they share a title and a start time
+func workoutKey(w *store.WorkoutSession) string {
+	return normalize(w.Title) + "|" + w.StartTime.UTC().Truncate(time.Second).Format(time.RFC3339)
+}
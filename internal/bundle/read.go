@@ -0,0 +1,109 @@
+package bundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrUnsupportedVersion is returned for bundles written by a newer or unknown format
+var ErrUnsupportedVersion = errors.New("unsupported bundle format version")
+
+// the largest file read out of an archive, guards against zip bombs
+const maxFileSize = 64 << 20
+
+// Read parses a bundle from either a ZIP archive written by Write or a single
+// JSON document holding the manifest, profile, exercises, routines and workouts
+func Read(data []byte) (*Bundle, error) {
+	var (
+		b   *Bundle
+		err error
+	)
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		b, err = readZip(data)
+	} else {
+		b, err = readDocument(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if b.Manifest.FormatVersion < 1 || b.Manifest.FormatVersion > FormatVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, b.Manifest.FormatVersion)
+	}
+
+	return b, nil
+}
+
+func readDocument(data []byte) (*Bundle, error) {
+	b := &Bundle{}
+	if err := json.Unmarshal(data, b); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle: %w", err)
+	}
+	return b, nil
+}
+
+func readZip(data []byte) (*Bundle, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	// the CSV files are derived from the JSON ones and are not read back
+	b := &Bundle{}
+	jsonFiles := []struct {
+		name     string
+		value    interface{}
+		required bool
+	}{
+		{ManifestFile, &b.Manifest, true},
+		{ProfileFile, &b.Profile, false},
+		{ExercisesFile, &b.Exercises, false},
+		{RoutinesFile, &b.Routines, false},
+		{WorkoutsFile, &b.Workouts, false},
+	}
+	for _, file := range jsonFiles {
+		f, ok := files[file.name]
+		if !ok {
+			if file.required {
+				return nil, fmt.Errorf("archive is missing %s", file.name)
+			}
+			continue
+		}
+		if err := readJSON(f, file.value); err != nil {
+			return nil, err
+		}
+	}
+
+	return b, nil
+}
+
+func readJSON(f *zip.File, value interface{}) error {
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, maxFileSize+1))
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", f.Name, err)
+	}
+	if len(data) > maxFileSize {
+		return fmt.Errorf("%s is too large", f.Name)
+	}
+
+	if err := json.Unmarshal(data, value); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", f.Name, err)
+	}
+
+	return nil
+}
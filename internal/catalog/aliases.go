@@ -0,0 +1,58 @@
+package catalog
+
+import "github.com/FaustCelaj/GetFit.git/internal/fuzzy"
+
+// commonAliases are the short names people type for well known dataset exercises,
+// keyed by the dataset name. Names missing from a dataset version are ignored.
+var commonAliases = map[string][]string{
+	"Barbell Bench Press - Medium Grip":         {"bench", "bench press", "bb bench", "flat bench"},
+	"Barbell Incline Bench Press - Medium Grip": {"incline bench", "incline bench press"},
+	"Dumbbell Bench Press":                      {"db bench", "db bench press"},
+	"Romanian Deadlift":                         {"rdl", "romanian dl"},
+	"Barbell Deadlift":                          {"deadlift", "dl", "conventional deadlift"},
+	"Sumo Deadlift":                             {"sumo", "sumo dl"},
+	"Barbell Squat":                             {"squat", "back squat", "bb squat"},
+	"Barbell Full Squat":                        {"atg squat", "full squat"},
+	"Front Barbell Squat":                       {"front squat"},
+	"Standing Military Press":                   {"ohp", "overhead press", "military press"},
+	"Seated Dumbbell Press":                     {"db shoulder press", "seated db press"},
+	"Bent Over Barbell Row":                     {"barbell row", "bb row", "bent over row"},
+	"Pullups":                                   {"pull up", "pull-up"},
+	"Chin-Up":                                   {"chin up", "chins"},
+	"Barbell Hip Thrust":                        {"hip thrust"},
+	"Wide-Grip Lat Pulldown":                    {"lat pulldown", "pulldown"},
+	"Dips - Triceps Version":                    {"dips", "tricep dips"},
+	"Barbell Curl":                              {"curl", "bb curl"},
+	"Lying Triceps Press":                       {"skull crusher", "skullcrusher"},
+	"Good Morning":                              {"gm"},
+	"Barbell Shrug":                             {"shrug", "shrugs"},
+	"Standing Calf Raises":                      {"calf raise", "calf raises"},
+	"Close-Grip Barbell Bench Press":            {"cgbp", "close grip bench"},
+	"Push-Ups - Close Triceps Position":         {"diamond push up"},
+	"Pushups":                                   {"push up", "push-up", "pushup"},
+	"Kettlebell One-Legged Deadlift":            {"single leg rdl"},
+	"Dumbbell Lunges":                           {"lunges", "db lunge"},
+	"Barbell Glute Bridge":                      {"glute bridge"},
+	"Hanging Leg Raise":                         {"hlr", "leg raise"},
+	"Smith Machine Bench Press":                 {"smith bench"},
+	"Cable Crossover":                           {"cable fly", "crossover"},
+	"Face Pull":                                 {"face pulls"},
+	"Seated Cable Rows":                         {"cable row", "seated row"},
+	"Barbell Step Ups":                          {"step ups"},
+	"Power Clean":                               {"clean"},
+	"Snatch":                                    {"full snatch"},
+	"Clean and Jerk":                            {"c&j", "clean & jerk"},
+}
+
+// aliasesFor returns the aliases of a dataset exercise by its name
+func aliasesFor(name string) []string {
+	return aliasIndex[fuzzy.Normalize(name)]
+}
+
+var aliasIndex = func() map[string][]string {
+	index := make(map[string][]string, len(commonAliases))
+	for name, aliases := range commonAliases {
+		index[fuzzy.Normalize(name)] = aliases
+	}
+	return index
+}()
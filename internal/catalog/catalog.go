@@ -0,0 +1,75 @@
+// Package catalog reads the free-exercise-db dataset
+// (https://github.com/yuhonas/free-exercise-db) into catalog exercises.
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/FaustCelaj/GetFit.git/internal/store"
+)
+
+// entry is one exercise as it appears in the dataset's exercises.json
+type entry struct {
+	ID               string   `json:"id"`
+	Name             string   `json:"name"`
+	Force            *string  `json:"force"`
+	Level            *string  `json:"level"`
+	Mechanic         *string  `json:"mechanic"`
+	Equipment        *string  `json:"equipment"`
+	PrimaryMuscles   []string `json:"primaryMuscles"`
+	SecondaryMuscles []string `json:"secondaryMuscles"`
+	Instructions     []string `json:"instructions"`
+	Category         string   `json:"category"`
+	Images           []string `json:"images"`
+}
+
+// Parse reads the dataset's JSON array. Entries without an ID or name are rejected,
+// the ID is what makes loading the same dataset twice a no-op.
+func Parse(r io.Reader) ([]*store.Exercise, error) {
+	var entries []entry
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("failed to parse exercise dataset: %w", err)
+	}
+
+	exercises := make([]*store.Exercise, 0, len(entries))
+	seen := make(map[string]bool, len(entries))
+	for i, e := range entries {
+		e.ID = strings.TrimSpace(e.ID)
+		e.Name = strings.TrimSpace(e.Name)
+		if e.ID == "" || e.Name == "" {
+			return nil, fmt.Errorf("exercise %d in the dataset has no id or name", i)
+		}
+		if seen[e.ID] {
+			return nil, fmt.Errorf("exercise id %q appears more than once in the dataset", e.ID)
+		}
+		seen[e.ID] = true
+
+		exercises = append(exercises, &store.Exercise{
+			SourceID:         e.ID,
+			Name:             e.Name,
+			Force:            e.Force,
+			Level:            e.Level,
+			Mechanic:         e.Mechanic,
+			Equipment:        e.Equipment,
+			PrimaryMuscles:   nonNil(e.PrimaryMuscles),
+			SecondaryMuscles: nonNil(e.SecondaryMuscles),
+			Instructions:     nonNil(e.Instructions),
+			Category:         e.Category,
+			Images:           e.Images,
+			Aliases:          aliasesFor(e.Name),
+			TrackingType:     trackingTypeFor(e.Name, e.Category, e.Equipment),
+		})
+	}
+
+	return exercises, nil
+}
+
+func nonNil(items []string) *[]string {
+	if items == nil {
+		items = []string{}
+	}
+	return &items
+}
@@ -0,0 +1,48 @@
+package catalog
+
+import (
+	"strings"
+
+	"github.com/FaustCelaj/GetFit.git/internal/fuzzy"
+	"github.com/FaustCelaj/GetFit.git/internal/store"
+)
+
+// the dataset has no tracking type, these name words give it away before the
+// category and equipment do
+var trackingByNameWord = []struct {
+	word     string
+	tracking store.TrackingType
+}{
+	{"carry", store.TrackingWeightDistance},
+	{"farmer", store.TrackingWeightDistance},
+	{"yoke", store.TrackingWeightDistance},
+	{"sled", store.TrackingWeightDistance},
+	{"assisted", store.TrackingAssistedBodyweight},
+	{"plank", store.TrackingDuration},
+	{"hold", store.TrackingDuration},
+}
+
+// trackingTypeFor guesses what a dataset exercise's sets record
+func trackingTypeFor(name, category string, equipment *string) store.TrackingType {
+	words := strings.Fields(fuzzy.Normalize(name))
+	for _, rule := range trackingByNameWord {
+		for _, word := range words {
+			if strings.HasPrefix(word, rule.word) {
+				return rule.tracking
+			}
+		}
+	}
+
+	switch category {
+	case "stretching":
+		return store.TrackingDuration
+	case "cardio":
+		return store.TrackingDistanceDuration
+	}
+
+	if equipment != nil && *equipment == "body only" {
+		return store.TrackingBodyweightReps
+	}
+
+	return store.TrackingWeightReps
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -41,3 +42,17 @@ func New(addr string, maxConn int, maxIdleTime string) (*mongo.Client, error) {
 
 	return client, nil
 }
+
+// SupportsTransactions reports whether the server is a replica set member or a
+// sharded cluster router, a standalone mongod refuses transactions
+func SupportsTransactions(ctx context.Context, client *mongo.Client) (bool, error) {
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	if err := client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		return false, fmt.Errorf("could not ask MongoDB for its topology: %v", err)
+	}
+
+	return hello.SetName != "" || hello.Msg == "isdbgrid", nil
+}
@@ -0,0 +1,178 @@
+// Package dedupe finds custom exercises that are probably the same exercise saved
+// more than once, like "Bench Press", "bench press (barbell)" and "BB Bench".
+package dedupe
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/FaustCelaj/GetFit.git/internal/fuzzy"
+	"github.com/FaustCelaj/GetFit.git/internal/store"
+)
+
+// pairs scoring below this are not reported
+const DefaultMinScore = 0.7
+
+// names at least this similar are worth comparing further
+const nameThreshold = 0.45
+
+// Candidate is two exercises that look like duplicates
+type Candidate struct {
+	First     *store.Exercise `json:"first"`
+	Second    *store.Exercise `json:"second"`
+	Score     float64         `json:"score"`      // overall confidence, from 0 to 1
+	NameScore float64         `json:"name_score"` // how alike the closest names or aliases are
+	Reasons   []string        `json:"reasons"`
+}
+
+// Find compares every pair of exercises and returns the likely duplicates, most
+// likely first. Name similarity drives the score, matching equipment and primary
+// muscles raise it and conflicting ones lower it. Exercises tracked differently
+// are never paired, their sets could not be merged.
+func Find(exercises []*store.Exercise, minScore float64) []Candidate {
+	var candidates []Candidate
+	for i := 0; i < len(exercises); i++ {
+		for j := i + 1; j < len(exercises); j++ {
+			a, b := exercises[i], exercises[j]
+			if a.Tracking() != b.Tracking() {
+				continue
+			}
+
+			candidate, ok := compare(a, b)
+			if ok && candidate.Score >= minScore {
+				candidates = append(candidates, candidate)
+			}
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+
+	return candidates
+}
+
+func compare(a, b *store.Exercise) (Candidate, bool) {
+	nameScore := 0.0
+	for _, x := range names(a) {
+		for _, y := range names(b) {
+			nameScore = max(nameScore, fuzzy.Similarity(x, y))
+		}
+	}
+	if nameScore < nameThreshold {
+		return Candidate{}, false
+	}
+
+	score := nameScore
+	reasons := []string{"similar names"}
+
+	if ea, eb := equipment(a), equipment(b); len(ea) > 0 && len(eb) > 0 {
+		if intersects(ea, eb) {
+			score += 0.1
+			reasons = append(reasons, "same equipment")
+		} else {
+			score -= 0.3
+			reasons = append(reasons, "different equipment")
+		}
+	}
+
+	if overlap, ok := muscleOverlap(a.PrimaryMuscles, b.PrimaryMuscles); ok {
+		if overlap > 0 {
+			score += 0.1 * overlap
+			reasons = append(reasons, "shared primary muscles")
+		} else {
+			score -= 0.2
+			reasons = append(reasons, "different primary muscles")
+		}
+	}
+
+	if a.Category != "" && a.Category == b.Category {
+		score += 0.05
+	}
+
+	return Candidate{
+		First:     a,
+		Second:    b,
+		Score:     min(1, max(0, score)),
+		NameScore: nameScore,
+		Reasons:   reasons,
+	}, true
+}
+
+func names(e *store.Exercise) []string {
+	return append([]string{e.Name}, e.Aliases...)
+}
+
+// words in names that give the equipment away, "DB Bench" is not a barbell bench press
+var equipmentWords = map[string]string{
+	"barbell":     "barbell",
+	"bb":          "barbell",
+	"dumbbell":    "dumbbell",
+	"dumbbells":   "dumbbell",
+	"db":          "dumbbell",
+	"kettlebell":  "kettlebells",
+	"kettlebells": "kettlebells",
+	"kb":          "kettlebells",
+	"cable":       "cable",
+	"machine":     "machine",
+	"smith":       "machine",
+	"band":        "bands",
+	"bands":       "bands",
+}
+
+// equipment of an exercise, from its equipment field and the words of its names
+func equipment(e *store.Exercise) map[string]bool {
+	found := make(map[string]bool)
+	if e.Equipment != nil {
+		if value := strings.ToLower(strings.TrimSpace(*e.Equipment)); value != "" && value != "other" {
+			found[value] = true
+		}
+	}
+	for _, name := range names(e) {
+		for _, word := range strings.Fields(fuzzy.Normalize(name)) {
+			if value, ok := equipmentWords[word]; ok {
+				found[value] = true
+			}
+		}
+	}
+	return found
+}
+
+func intersects(a, b map[string]bool) bool {
+	for value := range a {
+		if b[value] {
+			return true
+		}
+	}
+	return false
+}
+
+// muscleOverlap is the Jaccard index of two muscle lists, not ok when either is empty
+func muscleOverlap(a, b *[]string) (float64, bool) {
+	if a == nil || b == nil || len(*a) == 0 || len(*b) == 0 {
+		return 0, false
+	}
+
+	set := make(map[string]bool, len(*a))
+	for _, muscle := range *a {
+		set[strings.ToLower(muscle)] = true
+	}
+
+	shared := 0
+	union := len(set)
+	seen := make(map[string]bool, len(*b))
+	for _, muscle := range *b {
+		muscle = strings.ToLower(muscle)
+		if seen[muscle] {
+			continue
+		}
+		seen[muscle] = true
+		if set[muscle] {
+			shared++
+		} else {
+			union++
+		}
+	}
+
+	return float64(shared) / float64(union), true
+}
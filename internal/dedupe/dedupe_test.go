@@ -0,0 +1,150 @@
+package dedupe
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/FaustCelaj/GetFit.git/internal/store"
+)
+
+func exercise(name string, opts ...func(*store.Exercise)) *store.Exercise {
+	e := &store.Exercise{Name: name, Category: "strength"}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+func withEquipment(equipment string) func(*store.Exercise) {
+	return func(e *store.Exercise) { e.Equipment = &equipment }
+}
+
+func withMuscles(muscles ...string) func(*store.Exercise) {
+	return func(e *store.Exercise) { e.PrimaryMuscles = &muscles }
+}
+
+func withAliases(aliases ...string) func(*store.Exercise) {
+	return func(e *store.Exercise) { e.Aliases = aliases }
+}
+
+func withTracking(t store.TrackingType) func(*store.Exercise) {
+	return func(e *store.Exercise) { e.TrackingType = t }
+}
+
+func TestFindPairs(t *testing.T) {
+	tests := []struct {
+		name       string
+		a, b       *store.Exercise
+		want       bool
+		wantReason string // a reason the pair must give, when it is found
+	}{
+		{
+			name: "same name in another case",
+			a:    exercise("Bench Press"),
+			b:    exercise("bench press"),
+			want: true,
+		},
+		{
+			name:       "same equipment and muscles",
+			a:          exercise("Bench Press", withEquipment("barbell"), withMuscles("chest")),
+			b:          exercise("Bench Press (Barbell)", withEquipment("barbell"), withMuscles("chest", "triceps")),
+			want:       true,
+			wantReason: "same equipment",
+		},
+		{
+			name:       "matched through an alias",
+			a:          exercise("Romanian Deadlift", withAliases("RDL")),
+			b:          exercise("RDL"),
+			want:       true,
+			wantReason: "similar names",
+		},
+		{
+			name: "equipment in the name conflicts",
+			a:    exercise("DB Bench Press", withMuscles("chest")),
+			b:    exercise("Barbell Bench Press", withMuscles("chest")),
+			want: false,
+		},
+		{
+			name: "different primary muscles",
+			a:    exercise("Cable Fly", withMuscles("chest")),
+			b:    exercise("Cable Fly", withMuscles("shoulders")),
+			want: true, // the same name still outweighs the muscles
+		},
+		{
+			name: "unrelated names",
+			a:    exercise("Squat"),
+			b:    exercise("Lat Pulldown"),
+			want: false,
+		},
+		{
+			name: "tracked differently",
+			a:    exercise("Plank", withTracking(store.TrackingDuration)),
+			b:    exercise("Plank", withTracking(store.TrackingBodyweightReps)),
+			want: false,
+		},
+		{
+			name: "no tracking type is weight and reps",
+			a:    exercise("Deadlift"),
+			b:    exercise("Deadlift", withTracking(store.TrackingWeightReps)),
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		candidates := Find([]*store.Exercise{tt.a, tt.b}, DefaultMinScore)
+		if got := len(candidates) == 1; got != tt.want {
+			t.Errorf("%s: found = %v, want %v (candidates %+v)", tt.name, got, tt.want, candidates)
+			continue
+		}
+		if !tt.want {
+			continue
+		}
+
+		candidate := candidates[0]
+		if candidate.Score < DefaultMinScore || candidate.Score > 1 {
+			t.Errorf("%s: Score = %v, want between %v and 1", tt.name, candidate.Score, DefaultMinScore)
+		}
+		if tt.wantReason != "" && !slices.Contains(candidate.Reasons, tt.wantReason) {
+			t.Errorf("%s: Reasons = %v, want %q among them", tt.name, candidate.Reasons, tt.wantReason)
+		}
+	}
+}
+
+func TestFindOrdersByScore(t *testing.T) {
+	exercises := []*store.Exercise{
+		exercise("Bench Press", withEquipment("barbell"), withMuscles("chest")),
+		exercise("Incline Bench Press"),
+		exercise("Bench Press", withEquipment("barbell"), withMuscles("chest")),
+		exercise("Squat"),
+	}
+
+	candidates := Find(exercises, DefaultMinScore)
+	if len(candidates) != 3 {
+		t.Fatalf("Find returned %d candidates, want the 3 bench press pairs", len(candidates))
+	}
+
+	for i := 1; i < len(candidates); i++ {
+		if candidates[i].Score > candidates[i-1].Score {
+			t.Errorf("candidate %d scores %v, above %v of the one before it", i, candidates[i].Score, candidates[i-1].Score)
+		}
+	}
+
+	// the two identical exercises are the surest pair
+	first := candidates[0]
+	if first.First != exercises[0] || first.Second != exercises[2] {
+		t.Errorf("first candidate is %q and %q, want the two identical bench presses", first.First.Name, first.Second.Name)
+	}
+}
+
+func TestFindMinScore(t *testing.T) {
+	exercises := []*store.Exercise{exercise("Bench Press"), exercise("Incline Bench Press")}
+
+	all := Find(exercises, 0)
+	if len(all) != 1 {
+		t.Fatalf("Find with no minimum returned %d candidates, want 1", len(all))
+	}
+
+	if got := Find(exercises, all[0].Score+0.01); len(got) != 0 {
+		t.Errorf("Find above the pair's score returned %d candidates, want 0", len(got))
+	}
+}
@@ -0,0 +1,232 @@
+// Package fuzzy resolves free-text exercise names, like "RDL", "bench" or "romanain
+// deadlift", to known names and their aliases with a confidence score.
+package fuzzy
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// scores below this are not reported as matches
+const DefaultThreshold = 0.45
+
+// Candidate is something a name can resolve to, under its name or any of its aliases
+type Candidate struct {
+	ID      string
+	Name    string
+	Aliases []string
+}
+
+// Match is a candidate with how confident the matcher is, from 0 to 1
+type Match struct {
+	ID      string  `json:"id"`
+	Name    string  `json:"name"`
+	Matched string  `json:"matched"` // the name or alias that matched best
+	Score   float64 `json:"score"`
+}
+
+type term struct {
+	source   string // as given, reported back in Match.Matched
+	text     string
+	trigrams map[string]struct{}
+}
+
+type entry struct {
+	candidate Candidate
+	terms     []term
+}
+
+// Matcher holds candidates prepared for matching, build it once and reuse it
+type Matcher struct {
+	entries []entry
+}
+
+func NewMatcher(candidates []Candidate) *Matcher {
+	m := &Matcher{entries: make([]entry, 0, len(candidates))}
+	for _, c := range candidates {
+		e := entry{candidate: c}
+		for _, name := range append([]string{c.Name}, c.Aliases...) {
+			if text := Normalize(name); text != "" {
+				e.terms = append(e.terms, term{source: name, text: text, trigrams: trigrams(text)})
+			}
+		}
+		m.entries = append(m.entries, e)
+	}
+	return m
+}
+
+// Match returns up to limit candidates scoring at least threshold, best first
+func (m *Matcher) Match(query string, limit int, threshold float64) []Match {
+	text := Normalize(query)
+	if text == "" || limit < 1 {
+		return nil
+	}
+	q := term{text: text, trigrams: trigrams(text)}
+
+	var matches []Match
+	for _, e := range m.entries {
+		best := Match{ID: e.candidate.ID, Name: e.candidate.Name}
+		for _, t := range e.terms {
+			if s := score(q, t); s > best.Score {
+				best.Score = s
+				best.Matched = t.source
+			}
+		}
+		if best.Score >= threshold {
+			matches = append(matches, best)
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Name < matches[j].Name
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	return matches
+}
+
+// Best returns the highest scoring candidate, if any reaches threshold
+func (m *Matcher) Best(query string, threshold float64) (Match, bool) {
+	matches := m.Match(query, 1, threshold)
+	if len(matches) == 0 {
+		return Match{}, false
+	}
+	return matches[0], true
+}
+
+// Similarity scores two names against each other the way Match scores a query
+// against a name, from 0 to 1. It is symmetric.
+func Similarity(a, b string) float64 {
+	ta, tb := Normalize(a), Normalize(b)
+	if ta == "" || tb == "" {
+		return 0
+	}
+	x := term{text: ta, trigrams: trigrams(ta)}
+	y := term{text: tb, trigrams: trigrams(tb)}
+	return max(score(x, y), score(y, x))
+}
+
+// score compares a normalized query with a normalized name. Equal names score 1,
+// otherwise the best of trigram similarity, edit distance and how much of the
+// query is found inside the name, the last one capped below a full match.
+func score(q, t term) float64 {
+	if q.text == t.text {
+		return 1
+	}
+
+	best := dice(q.trigrams, t.trigrams)
+	if lev := levenshteinRatio(q.text, t.text); lev > best {
+		best = lev
+	}
+	if contained := 0.85 * containment(q.trigrams, t.trigrams); contained > best {
+		best = contained
+	}
+
+	return best
+}
+
+// Normalize lowercases a name, strips accents and turns punctuation into single
+// spaces, so "Pull-Up" and "pull up" compare equal
+func Normalize(s string) string {
+	folded, _, err := transform.String(transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), s)
+	if err != nil {
+		folded = s
+	}
+
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(folded) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteRune(r)
+			space = false
+			continue
+		}
+		space = true
+	}
+
+	return b.String()
+}
+
+// trigrams of each word padded with spaces, so short words and word starts count
+func trigrams(text string) map[string]struct{} {
+	set := make(map[string]struct{})
+	for _, word := range strings.Fields(text) {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			set[string(padded[i:i+3])] = struct{}{}
+		}
+	}
+	return set
+}
+
+func dice(a, b map[string]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	return 2 * float64(intersection(a, b)) / float64(len(a)+len(b))
+}
+
+// containment is the share of a's trigrams that are also in b
+func containment(a, b map[string]struct{}) float64 {
+	if len(a) == 0 {
+		return 0
+	}
+	return float64(intersection(a, b)) / float64(len(a))
+}
+
+func intersection(a, b map[string]struct{}) int {
+	n := 0
+	for g := range a {
+		if _, ok := b[g]; ok {
+			n++
+		}
+	}
+	return n
+}
+
+func levenshteinRatio(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+func levenshtein(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
@@ -0,0 +1,49 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+type Mailer interface {
+	Send(context.Context, Message) error
+}
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Render builds a plain text message from a subject and body template
+func Render(to, subject, body string, data any) (Message, error) {
+	tmpl, err := template.New("body").Parse(body)
+	if err != nil {
+		return Message{}, fmt.Errorf("failed to parse email template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return Message{}, fmt.Errorf("failed to render email template: %w", err)
+	}
+
+	return Message{To: to, Subject: subject, Body: buf.String()}, nil
+}
+
+// bytes returns the message in RFC 5322 format
+func (m Message) bytes(from string) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", m.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", m.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(m.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
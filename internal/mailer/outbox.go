@@ -0,0 +1,49 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// OutboxMailer writes messages to a directory as .eml files and logs them instead of
+// delivering them, so emails can be inspected during development and in tests
+type OutboxMailer struct {
+	dir    string
+	from   string
+	logger *zap.SugaredLogger
+}
+
+func NewOutboxMailer(dir, from string, logger *zap.SugaredLogger) (*OutboxMailer, error) {
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create outbox directory: %w", err)
+		}
+	}
+
+	return &OutboxMailer{dir: dir, from: from, logger: logger}, nil
+}
+
+func (m *OutboxMailer) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.logger.Infow("email queued in outbox", "to", msg.To, "subject", msg.Subject, "body", msg.Body)
+
+	if m.dir == "" {
+		return nil
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405"), primitive.NewObjectID().Hex())
+	if err := os.WriteFile(filepath.Join(m.dir, name), msg.bytes(m.from), 0o644); err != nil {
+		return fmt.Errorf("failed to write email to outbox: %w", err)
+	}
+
+	return nil
+}
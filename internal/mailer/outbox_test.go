@@ -0,0 +1,153 @@
+package mailer
+
+import (
+	"context"
+	"io"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+const testFrom = "GetFit <no-reply@getfit.local>"
+
+// sendToOutbox renders a template, sends it through a fresh outbox and returns the one message written
+func sendToOutbox(t *testing.T, subject, body string, data any) *mail.Message {
+	t.Helper()
+
+	dir := t.TempDir()
+	outbox, err := NewOutboxMailer(dir, testFrom, zap.NewNop().Sugar())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := Render("lifter@example.com", subject, body, data)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if err := outbox.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("outbox holds %d messages, want 1", len(files))
+	}
+
+	file, err := os.Open(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { file.Close() })
+
+	written, err := mail.ReadMessage(file)
+	if err != nil {
+		t.Fatalf("outbox message is not RFC 5322: %v", err)
+	}
+	return written
+}
+
+func checkHeaders(t *testing.T, msg *mail.Message, subject string) {
+	t.Helper()
+
+	for header, want := range map[string]string{
+		"From":         testFrom,
+		"To":           "lifter@example.com",
+		"Subject":      subject,
+		"Content-Type": `text/plain; charset="utf-8"`,
+	} {
+		if got := msg.Header.Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+	if _, err := msg.Header.Date(); err != nil {
+		t.Errorf("Date header: %v", err)
+	}
+}
+
+func readBody(t *testing.T, msg *mail.Message) string {
+	t.Helper()
+	body, err := io.ReadAll(msg.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func TestOutboxPasswordReset(t *testing.T) {
+	msg := sendToOutbox(t, PasswordResetSubject, PasswordResetTemplate, map[string]string{
+		"Username":  "lifter",
+		"Link":      "http://localhost:5173/reset-password?token=abc123",
+		"ExpiresIn": "30 minutes",
+	})
+	checkHeaders(t, msg, PasswordResetSubject)
+
+	body := readBody(t, msg)
+	for _, want := range []string{
+		"Hi lifter,",
+		"http://localhost:5173/reset-password?token=abc123",
+		"The link expires in 30 minutes",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body does not contain %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(strings.ReplaceAll(body, "\r\n", ""), "\n") {
+		t.Error("body has bare line feeds, want CRLF line endings")
+	}
+}
+
+func TestOutboxEmailVerification(t *testing.T) {
+	msg := sendToOutbox(t, EmailVerificationSubject, EmailVerificationTemplate, map[string]string{
+		"Username":  "lifter",
+		"Email":     "lifter@example.com",
+		"Link":      "http://localhost:5173/verify-email?token=def456",
+		"ExpiresIn": "72 hours",
+	})
+	checkHeaders(t, msg, EmailVerificationSubject)
+
+	body := readBody(t, msg)
+	for _, want := range []string{
+		"Hi lifter,",
+		"confirm that lifter@example.com is your email address",
+		"http://localhost:5173/verify-email?token=def456",
+		"The link expires in 72 hours.",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body does not contain %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestOutboxWithoutDirectoryOnlyLogs(t *testing.T) {
+	outbox, err := NewOutboxMailer("", testFrom, zap.NewNop().Sugar())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := outbox.Send(context.Background(), Message{To: "lifter@example.com", Subject: "s", Body: "b"}); err != nil {
+		t.Errorf("Send = %v, want nil", err)
+	}
+}
+
+func TestOutboxHonoursCancelledContext(t *testing.T) {
+	dir := t.TempDir()
+	outbox, err := NewOutboxMailer(dir, testFrom, zap.NewNop().Sugar())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := outbox.Send(ctx, Message{To: "lifter@example.com", Subject: "s", Body: "b"}); err == nil {
+		t.Error("Send with a cancelled context succeeded")
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*.eml")); len(files) != 0 {
+		t.Errorf("%d messages were written for a cancelled send", len(files))
+	}
+}
@@ -0,0 +1,26 @@
+package mailer
+
+const PasswordResetSubject = "Reset your GetFit password"
+
+const PasswordResetTemplate = `Hi {{.Username}},
+
+Someone asked to reset the password for your GetFit account.
+If it was you, open the link below to choose a new password:
+
+{{.Link}}
+
+The link expires in {{.ExpiresIn}} and can only be used once.
+If you did not ask for this you can ignore this email.
+`
+
+const EmailVerificationSubject = "Confirm your GetFit email address"
+
+const EmailVerificationTemplate = `Hi {{.Username}},
+
+Please confirm that {{.Email}} is your email address by opening the link below:
+
+{{.Link}}
+
+The link expires in {{.ExpiresIn}}.
+If you did not create a GetFit account or change your email you can ignore this email.
+`
@@ -0,0 +1,136 @@
+// Package media checks uploaded exercise media and makes thumbnails of images.
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif" // registers the GIF decoder
+	"image/jpeg"
+	_ "image/png" // registers the PNG decoder
+	"net/http"
+)
+
+const (
+	KindImage = "image"
+	KindVideo = "video"
+)
+
+// ThumbnailContentType is what Thumbnail produces
+const ThumbnailContentType = "image/jpeg"
+
+// content types accepted for upload, by kind
+var allowedTypes = map[string]string{
+	"image/jpeg": KindImage,
+	"image/png":  KindImage,
+	"image/gif":  KindImage,
+	"image/webp": KindImage,
+	"video/mp4":  KindVideo,
+	"video/webm": KindVideo,
+}
+
+// images larger than this are refused before decoding, a small file can claim a huge size
+const maxPixels = 40_000_000
+
+var (
+	ErrUnsupportedType = errors.New("unsupported media type")
+	ErrUndecodable     = errors.New("image cannot be decoded")
+)
+
+// Detect sniffs the content type from the data itself, the type a client claims is
+// not trusted, and returns it with its kind
+func Detect(data []byte) (contentType, kind string, err error) {
+	contentType = http.DetectContentType(data)
+	kind, ok := allowedTypes[contentType]
+	if !ok {
+		return "", "", fmt.Errorf("%w: %s", ErrUnsupportedType, contentType)
+	}
+	return contentType, kind, nil
+}
+
+// AllowedTypes lists the content types Detect accepts
+func AllowedTypes() []string {
+	return []string{"image/jpeg", "image/png", "image/gif", "image/webp", "video/mp4", "video/webm"}
+}
+
+// Thumbnail scales an image down to fit in size by size pixels and encodes it as
+// JPEG, and returns it with the size of the original. Images already small enough
+// keep their size. Formats the standard library cannot decode, like WebP, return
+// ErrUndecodable.
+func Thumbnail(data []byte, size int) (thumbnail []byte, width, height int, err error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, 0, ErrUndecodable
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxPixels {
+		return nil, 0, 0, fmt.Errorf("%w: %dx%d pixels", ErrUndecodable, config.Width, config.Height)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, 0, ErrUndecodable
+	}
+
+	thumbWidth, thumbHeight := fit(config.Width, config.Height, size)
+	scaled := scale(src, thumbWidth, thumbHeight)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, scaled, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, 0, 0, fmt.Errorf("failed to encode thumbnail: %w", err)
+	}
+
+	return buf.Bytes(), config.Width, config.Height, nil
+}
+
+// fit keeps the aspect ratio while making the longest side at most size
+func fit(width, height, size int) (int, int) {
+	if width <= size && height <= size {
+		return width, height
+	}
+	if width >= height {
+		return size, max(1, height*size/width)
+	}
+	return max(1, width*size/height), size
+}
+
+// scale averages the source pixels covered by each destination pixel. Transparent
+// areas are put on white, JPEG has no alpha.
+func scale(src image.Image, width, height int) *image.RGBA {
+	bounds := src.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Over)
+
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := y * srcHeight / height
+		y1 := max(y0+1, (y+1)*srcHeight/height)
+		for x := 0; x < width; x++ {
+			x0 := x * srcWidth / width
+			x1 := max(x0+1, (x+1)*srcWidth/width)
+
+			var r, g, b, n uint64
+			for sy := y0; sy < y1; sy++ {
+				row := rgba.Pix[sy*rgba.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					r += uint64(row[sx*4])
+					g += uint64(row[sx*4+1])
+					b += uint64(row[sx*4+2])
+					n++
+				}
+			}
+
+			i := y*dst.Stride + x*4
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = 255
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,129 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// the smallest lossy WebP header, enough for content sniffing
+var webpHeader = []byte("RIFF\x1a\x00\x00\x00WEBPVP8 \x0e\x00\x00\x00\x30\x01\x00\x9d\x01\x2a\x01\x00\x01\x00")
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        []byte
+		contentType string
+		kind        string
+	}{
+		{"png", encodePNG(t, image.NewRGBA(image.Rect(0, 0, 2, 2))), "image/png", KindImage},
+		{"webp", webpHeader, "image/webp", KindImage},
+		{"mp4", []byte("\x00\x00\x00\x18ftypmp42\x00\x00\x00\x00mp42isom"), "video/mp4", KindVideo},
+	}
+
+	for _, tt := range tests {
+		contentType, kind, err := Detect(tt.data)
+		if err != nil {
+			t.Errorf("%s: Detect = %v", tt.name, err)
+			continue
+		}
+		if contentType != tt.contentType || kind != tt.kind {
+			t.Errorf("%s: Detect = %s, %s, want %s, %s", tt.name, contentType, kind, tt.contentType, tt.kind)
+		}
+	}
+}
+
+func TestDetectRefusesOtherTypes(t *testing.T) {
+	for _, data := range [][]byte{
+		[]byte("just some text"),
+		[]byte("<html><script>alert(1)</script></html>"),
+		[]byte("%PDF-1.4"),
+	} {
+		if _, _, err := Detect(data); !errors.Is(err, ErrUnsupportedType) {
+			t.Errorf("Detect(%q) = %v, want ErrUnsupportedType", data, err)
+		}
+	}
+}
+
+func TestThumbnailScalesDown(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 640, 320))
+	for y := 0; y < 320; y++ {
+		for x := 0; x < 640; x++ {
+			src.Set(x, y, color.RGBA{R: 200, A: 255})
+		}
+	}
+
+	thumb, width, height, err := Thumbnail(encodePNG(t, src), 160)
+	if err != nil {
+		t.Fatalf("Thumbnail: %v", err)
+	}
+	if width != 640 || height != 320 {
+		t.Errorf("original size = %dx%d, want 640x320", width, height)
+	}
+
+	decoded, err := jpeg.Decode(bytes.NewReader(thumb))
+	if err != nil {
+		t.Fatalf("thumbnail is not a JPEG: %v", err)
+	}
+	if bounds := decoded.Bounds(); bounds.Dx() != 160 || bounds.Dy() != 80 {
+		t.Errorf("thumbnail size = %dx%d, want 160x80", bounds.Dx(), bounds.Dy())
+	}
+}
+
+func TestThumbnailKeepsSmallImages(t *testing.T) {
+	thumb, _, _, err := Thumbnail(encodePNG(t, image.NewRGBA(image.Rect(0, 0, 40, 30))), 160)
+	if err != nil {
+		t.Fatalf("Thumbnail: %v", err)
+	}
+
+	config, err := jpeg.DecodeConfig(bytes.NewReader(thumb))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Width != 40 || config.Height != 30 {
+		t.Errorf("thumbnail size = %dx%d, want 40x30", config.Width, config.Height)
+	}
+}
+
+func TestThumbnailPutsTransparencyOnWhite(t *testing.T) {
+	thumb, _, _, err := Thumbnail(encodePNG(t, image.NewNRGBA(image.Rect(0, 0, 8, 8))), 8)
+	if err != nil {
+		t.Fatalf("Thumbnail: %v", err)
+	}
+
+	decoded, err := jpeg.Decode(bytes.NewReader(thumb))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, g, b, _ := decoded.At(4, 4).RGBA(); r>>8 < 250 || g>>8 < 250 || b>>8 < 250 {
+		t.Errorf("transparent pixel became %d,%d,%d, want white", r>>8, g>>8, b>>8)
+	}
+}
+
+func TestThumbnailSkipsWebP(t *testing.T) {
+	if _, _, _, err := Thumbnail(webpHeader, 160); !errors.Is(err, ErrUndecodable) {
+		t.Errorf("Thumbnail of WebP = %v, want ErrUndecodable", err)
+	}
+}
+
+func TestThumbnailRefusesOversizedImages(t *testing.T) {
+	// a GIF header claiming 10000x5000 pixels, refused before anything is decoded
+	header := []byte("GIF89a\x10\x27\x88\x13\x00\x00\x00")
+
+	if _, _, _, err := Thumbnail(header, 160); !errors.Is(err, ErrUndecodable) {
+		t.Errorf("Thumbnail of a 50 megapixel image = %v, want ErrUndecodable", err)
+	}
+}
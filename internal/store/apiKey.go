@@ -0,0 +1,206 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// APIKeyScopes lists every scope a personal API key can be granted
+var APIKeyScopes = []string{
+	"exercises:read",
+	"exercises:write",
+	"routines:read",
+	"routines:write",
+	"workouts:read",
+	"workouts:write",
+}
+
+// APIKey is a personal key for scripts and integrations. Only a hash of the key is
+// stored, the prefix is kept in the clear so users can tell their keys apart.
+type APIKey struct {
+	ID         primitive.ObjectID `bson:"_id" json:"id"`
+	UserID     primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Name       string             `bson:"name" json:"name"`
+	Prefix     string             `bson:"prefix" json:"prefix"`
+	KeyHash    string             `bson:"key_hash" json:"-"`
+	Scopes     []string           `bson:"scopes" json:"scopes"`
+	ExpiresAt  *time.Time         `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
+	LastUsedAt *time.Time         `bson:"last_used_at,omitempty" json:"last_used_at,omitempty"`
+	Version    int16              `bson:"version" json:"version"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// HasScope reports whether the key was granted a scope
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Expired reports whether the key is past its expiry
+func (k *APIKey) Expired() bool {
+	return k.ExpiresAt != nil && !k.ExpiresAt.After(time.Now())
+}
+
+type APIKeyStore struct {
+	db *mongo.Database
+}
+
+const apiKeyCollection = "api_key"
+
+// creating an api key
+func (s *APIKeyStore) Create(ctx context.Context, key *APIKey) error {
+	key.ID = primitive.NewObjectID()
+	key.CreatedAt = time.Now()
+	key.UpdatedAt = time.Now()
+
+	if key.Version == 0 {
+		key.Version = 1
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := s.db.Collection(apiKeyCollection).InsertOne(ctx, key)
+	if err != nil {
+		return fmt.Errorf("failed to insert api key: %w", err)
+	}
+
+	return nil
+}
+
+// return all api keys of a user
+func (s *APIKeyStore) GetAllUserKeys(ctx context.Context, userID primitive.ObjectID) ([]*APIKey, error) {
+	var keys []*APIKey
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.M{"created_at": -1})
+
+	cursor, err := s.db.Collection(apiKeyCollection).Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch api keys: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, fmt.Errorf("failed to decode api keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// return 1 api key of a user
+func (s *APIKeyStore) GetByID(ctx context.Context, keyID, userID primitive.ObjectID) (*APIKey, error) {
+	return s.findOne(ctx, bson.M{"_id": keyID, "user_id": userID})
+}
+
+// look up a key by the hash of its plaintext value
+func (s *APIKeyStore) GetByHash(ctx context.Context, hash string) (*APIKey, error) {
+	return s.findOne(ctx, bson.M{"key_hash": hash})
+}
+
+func (s *APIKeyStore) findOne(ctx context.Context, filter bson.M) (*APIKey, error) {
+	key := &APIKey{}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	err := s.db.Collection(apiKeyCollection).FindOne(ctx, filter).Decode(key)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch api key: %w", err)
+	}
+
+	return key, nil
+}
+
+// update an api key's name, scopes or expiry
+func (s *APIKeyStore) Update(ctx context.Context, keyID, userID primitive.ObjectID, updates map[string]interface{}, expectedVersion int16) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"_id":     keyID,
+		"user_id": userID,
+		"version": expectedVersion,
+	}
+
+	updateFields := bson.M{}
+	for key, value := range updates {
+		updateFields[key] = value
+	}
+	updateFields["updated_at"] = time.Now()
+
+	update := bson.M{
+		"$set": updateFields,
+		"$inc": bson.M{"version": 1},
+	}
+
+	result, err := s.db.Collection(apiKeyCollection).UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to update api key: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrVersionMismatch
+	}
+
+	return nil
+}
+
+// record that a key was used
+func (s *APIKeyStore) TouchLastUsed(ctx context.Context, keyID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := s.db.Collection(apiKeyCollection).UpdateOne(ctx, bson.M{"_id": keyID}, bson.M{"$set": bson.M{"last_used_at": time.Now()}})
+	if err != nil {
+		return fmt.Errorf("failed to update api key last used: %w", err)
+	}
+
+	return nil
+}
+
+// delete an api key
+func (s *APIKeyStore) Delete(ctx context.Context, keyID, userID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := s.db.Collection(apiKeyCollection).DeleteOne(ctx, bson.M{"_id": keyID, "user_id": userID})
+	if err != nil {
+		return fmt.Errorf("failed to delete api key: %w", err)
+	}
+
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// delete every api key of a user, when their password changes
+func (s *APIKeyStore) DeleteAllForUser(ctx context.Context, userID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := s.db.Collection(apiKeyCollection).DeleteMany(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return fmt.Errorf("failed to delete api keys: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,154 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	AuditActionCreate             = "create"
+	AuditActionUpdate             = "update"
+	AuditActionDelete             = "delete"
+	AuditActionRevoke             = "revoke"
+	AuditActionPasswordChange     = "password_change"
+	AuditActionPasswordReset      = "password_reset"
+	AuditActionEmailChange        = "email_change"
+	AuditActionTwoFactorEnable    = "two_factor_enable"
+	AuditActionTwoFactorDisable   = "two_factor_disable"
+	AuditActionDisable            = "disable"
+	AuditActionEnable             = "enable"
+	AuditActionRoleChange         = "role_change"
+	AuditActionForcePasswordReset = "force_password_reset"
+	AuditActionDeletionSchedule   = "deletion_schedule"
+	AuditActionDeletionCancel     = "deletion_cancel"
+	AuditActionExport             = "export"
+	AuditActionImport             = "import"
+	AuditActionArchive            = "archive"
+	AuditActionMerge              = "merge"
+	AuditActionUndoMerge          = "undo_merge"
+	AuditActionRestore            = "restore"
+)
+
+const (
+	AuditResourceUser     = "user"
+	AuditResourceRoutine  = "routine"
+	AuditResourceExercise = "exercise"
+	AuditResourceMedia    = "exercise_media"
+	AuditResourceWorkout  = "workout"
+	AuditResourceAPIKey   = "api_key"
+	AuditResourceSession  = "session"
+)
+
+// AuditEntry records who did what to which resource. Entries are only ever inserted.
+type AuditEntry struct {
+	ID           primitive.ObjectID  `bson:"_id" json:"id"`
+	ActorID      primitive.ObjectID  `bson:"actor_id" json:"actor_id"`
+	APIKeyID     *primitive.ObjectID `bson:"api_key_id,omitempty" json:"api_key_id,omitempty"` // set when the actor used an API key
+	OwnerID      primitive.ObjectID  `bson:"owner_id" json:"owner_id"`                         // user whose data was affected
+	Action       string              `bson:"action" json:"action"`
+	ResourceType string              `bson:"resource_type" json:"resource_type"`
+	ResourceID   primitive.ObjectID  `bson:"resource_id" json:"resource_id"`
+	Changes      map[string]Change   `bson:"changes,omitempty" json:"changes,omitempty"`
+	IP           string              `bson:"ip" json:"ip"`
+	UserAgent    string              `bson:"user_agent" json:"user_agent"`
+	RequestID    string              `bson:"request_id" json:"request_id"`
+	CreatedAt    time.Time           `bson:"created_at" json:"created_at"`
+}
+
+// AuditFilter narrows an audit log listing, zero values are ignored
+type AuditFilter struct {
+	OwnerID      *primitive.ObjectID
+	ActorID      *primitive.ObjectID
+	ResourceID   *primitive.ObjectID
+	Action       string
+	ResourceType string
+	From         *time.Time
+	To           *time.Time
+	Skip         int64
+	Limit        int64
+}
+
+type AuditStore struct {
+	db *mongo.Database
+}
+
+const auditCollection = "audit_log"
+
+// appending an entry to the audit log
+func (s *AuditStore) Create(ctx context.Context, entry *AuditEntry) error {
+	entry.ID = primitive.NewObjectID()
+	entry.CreatedAt = time.Now()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := s.db.Collection(auditCollection).InsertOne(ctx, entry)
+	if err != nil {
+		return fmt.Errorf("failed to insert audit entry: %w", err)
+	}
+
+	return nil
+}
+
+// listing audit entries newest first, with the total number of matches
+func (s *AuditStore) List(ctx context.Context, auditFilter AuditFilter) ([]*AuditEntry, int64, error) {
+	var entries []*AuditEntry
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if auditFilter.OwnerID != nil {
+		filter["owner_id"] = *auditFilter.OwnerID
+	}
+	if auditFilter.ActorID != nil {
+		filter["actor_id"] = *auditFilter.ActorID
+	}
+	if auditFilter.ResourceID != nil {
+		filter["resource_id"] = *auditFilter.ResourceID
+	}
+	if auditFilter.Action != "" {
+		filter["action"] = auditFilter.Action
+	}
+	if auditFilter.ResourceType != "" {
+		filter["resource_type"] = auditFilter.ResourceType
+	}
+	if auditFilter.From != nil || auditFilter.To != nil {
+		createdAt := bson.M{}
+		if auditFilter.From != nil {
+			createdAt["$gte"] = *auditFilter.From
+		}
+		if auditFilter.To != nil {
+			createdAt["$lt"] = *auditFilter.To
+		}
+		filter["created_at"] = createdAt
+	}
+
+	total, err := s.db.Collection(auditCollection).CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit entries: %w", err)
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(auditFilter.Skip).
+		SetLimit(auditFilter.Limit)
+
+	cursor, err := s.db.Collection(auditCollection).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch audit entries: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode audit entries: %w", err)
+	}
+
+	return entries, total, nil
+}
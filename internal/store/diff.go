@@ -0,0 +1,70 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Change is the value of a single field before and after a write
+type Change struct {
+	Before interface{} `bson:"before" json:"before"`
+	After  interface{} `bson:"after" json:"after"`
+}
+
+// fields that change on every write and say nothing about what the user did
+var diffIgnoredFields = map[string]bool{
+	"id":         true,
+	"version":    true,
+	"created_at": true,
+	"updated_at": true,
+}
+
+// Diff compares two documents field by field. Documents are compared by their JSON
+// form, so fields hidden from the API (password hashes, secrets) never show up.
+// A nil before or after describes a created or deleted document.
+func Diff(before, after interface{}) (map[string]Change, error) {
+	beforeFields, err := diffFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := diffFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]Change)
+	for field, value := range beforeFields {
+		if !reflect.DeepEqual(value, afterFields[field]) {
+			changes[field] = Change{Before: value, After: afterFields[field]}
+		}
+	}
+	for field, value := range afterFields {
+		if _, ok := beforeFields[field]; !ok && value != nil {
+			changes[field] = Change{After: value}
+		}
+	}
+
+	return changes, nil
+}
+
+func diffFields(document interface{}) (map[string]interface{}, error) {
+	fields := make(map[string]interface{})
+	if document == nil || reflect.ValueOf(document).Kind() == reflect.Ptr && reflect.ValueOf(document).IsNil() {
+		return fields, nil
+	}
+
+	data, err := json.Marshal(document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode document for diff: %w", err)
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to decode document for diff: %w", err)
+	}
+
+	for field := range diffIgnoredFields {
+		delete(fields, field)
+	}
+
+	return fields, nil
+}
@@ -2,30 +2,39 @@ package store
 
 import (
 	"context"
+	"crypto/sha256"
+	"errors"
 	"fmt"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type Exercise struct {
-	ID               primitive.ObjectID `bson:"_id" json:"id"`
-	UserID           primitive.ObjectID `bson:"user_id" json:"user_id"`
-	Name             string             `bson:"name" json:"name"`
-	Force            *string            `bson:"force" json:"force"`
-	Level            *string            `bson:"level" json:"level"`
-	Mechanic         *string            `bson:"mechanic" json:"mechanic"`
-	Equipment        *string            `bson:"equipment" json:"equipment"`
-	PrimaryMuscles   *[]string          `bson:"primaryMuscles" json:"primaryMuscles"`
-	SecondaryMuscles *[]string          `bson:"secondaryMuscles" json:"secondaryMuscles"`
-	Instructions     *[]string          `bson:"instructions" json:"instructions"`
-	Category         string             `bson:"category" json:"category"`
-	IsCustom         bool               `bson:"is_custom" json:"is_custom"`
-	Version          int16              `bson:"version" json:"version"`
-	CreatedAt        time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt        time.Time          `bson:"updated_at" json:"updated_at"`
+	ID               primitive.ObjectID  `bson:"_id" json:"id"`
+	UserID           *primitive.ObjectID `bson:"user_id,omitempty" json:"user_id,omitempty"`         // nil for catalog exercises
+	SourceID         string              `bson:"source_id,omitempty" json:"source_id,omitempty"`     // ID in the dataset a catalog exercise was loaded from
+	ForkedFrom       *primitive.ObjectID `bson:"forked_from,omitempty" json:"forked_from,omitempty"` // catalog exercise a custom one was copied from
+	Name             string              `bson:"name" json:"name"`
+	Aliases          []string            `bson:"aliases,omitempty" json:"aliases,omitempty"` // other names people use, like "RDL"
+	Force            *string             `bson:"force" json:"force"`
+	Level            *string             `bson:"level" json:"level"`
+	Mechanic         *string             `bson:"mechanic" json:"mechanic"`
+	Equipment        *string             `bson:"equipment" json:"equipment"`
+	PrimaryMuscles   *[]string           `bson:"primaryMuscles" json:"primaryMuscles"`
+	SecondaryMuscles *[]string           `bson:"secondaryMuscles" json:"secondaryMuscles"`
+	Instructions     *[]string           `bson:"instructions" json:"instructions"`
+	Category         string              `bson:"category" json:"category"`
+	TrackingType     TrackingType        `bson:"tracking_type,omitempty" json:"tracking_type,omitempty"` // empty means DefaultTrackingType
+	Images           []string            `bson:"images,omitempty" json:"images,omitempty"`
+	IsCustom         bool                `bson:"is_custom" json:"is_custom"`                         // false for the catalog shared by every user
+	ArchivedAt       *time.Time          `bson:"archived_at,omitempty" json:"archived_at,omitempty"` // hidden from lists and search, kept for workout history
+	Version          int16               `bson:"version" json:"version"`
+	CreatedAt        time.Time           `bson:"created_at" json:"created_at"`
+	UpdatedAt        time.Time           `bson:"updated_at" json:"updated_at"`
 }
 
 type ExerciseStore struct {
@@ -50,6 +59,35 @@ func (s *ExerciseStore) SearchExerciseByID(ctx context.Context, exerciseID primi
 	return exercise, nil
 }
 
+// which of the given IDs belong to catalog exercises
+func (s *ExerciseStore) GetCatalogIDs(ctx context.Context, exerciseIDs []primitive.ObjectID) (map[primitive.ObjectID]bool, error) {
+	found := make(map[primitive.ObjectID]bool)
+	if len(exerciseIDs) == 0 {
+		return found, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"_id":       bson.M{"$in": exerciseIDs},
+		"is_custom": false,
+	}
+
+	ids, err := s.db.Collection(exerciseCollection).Distinct(ctx, "_id", filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch exercises: %w", err)
+	}
+
+	for _, id := range ids {
+		if oid, ok := id.(primitive.ObjectID); ok {
+			found[oid] = true
+		}
+	}
+
+	return found, nil
+}
+
 // CUSTOM EXERCSIE ROUTES //
 
 // create a custom exercise
@@ -58,6 +96,7 @@ func (s *ExerciseStore) Create(ctx context.Context, exercise *Exercise, userID p
 	exercise.ID = primitive.NewObjectID()
 	exercise.CreatedAt = time.Now()
 	exercise.UpdatedAt = time.Now()
+	exercise.UserID = &userID
 	exercise.IsCustom = true
 
 	if exercise.Version == 0 {
@@ -99,15 +138,37 @@ func (s *ExerciseStore) GetAllUserExercises(ctx context.Context, userID primitiv
 	return exercises, nil
 }
 
-// return 1 specified custom exercise
+var exerciseListSpec = listSpec{
+	sortFields: map[string]string{
+		"name":       "name",
+		"category":   "category",
+		"created_at": "created_at",
+		"updated_at": "updated_at",
+	},
+	defaultSort: "name",
+}
+
+// one page of the user's custom exercises, either the active or the archived ones
+func (s *ExerciseStore) List(ctx context.Context, userID primitive.ObjectID, archived bool, query ListQuery) (*Page[*Exercise], error) {
+	filter := bson.M{"user_id": userID, "archived_at": nil}
+	if archived {
+		filter["archived_at"] = bson.M{"$ne": nil}
+	}
+	return findPage[*Exercise](ctx, s.db.Collection(exerciseCollection), filter, query, exerciseListSpec)
+}
+
+// return 1 exercise the user can see, either their own or from the catalog
 func (s *ExerciseStore) GetByID(ctx context.Context, exerciseID, userID primitive.ObjectID) (*Exercise, error) {
 	exercise := &Exercise{}
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	filter := bson.M{
-		"_id":     exerciseID,
-		"user_id": userID,
+		"_id": exerciseID,
+		"$or": bson.A{
+			bson.M{"user_id": userID},
+			bson.M{"is_custom": false},
+		},
 	}
 
 	err := s.db.Collection(exerciseCollection).FindOne(ctx, filter).Decode(exercise)
@@ -120,9 +181,6 @@ func (s *ExerciseStore) GetByID(ctx context.Context, exerciseID, userID primitiv
 
 // update custom exercise
 func (s *ExerciseStore) Update(ctx context.Context, exerciseID, userID primitive.ObjectID, updates map[string]interface{}, expectedVersion int16) error {
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
-
 	// Build filter to ensure the exercise belongs to the user and matches the expected version
 	filter := bson.M{
 		"_id":     exerciseID,
@@ -145,44 +203,591 @@ func (s *ExerciseStore) Update(ctx context.Context, exerciseID, userID primitive
 		"$set": updateFields,
 	}
 
-	// Perform the update
-	result, err := s.db.Collection(exerciseCollection).UpdateOne(ctx, filter, update)
+	// Perform the update, keeping the previous version as a revision
+	err := updateWithRevision(ctx, s.db, exerciseCollection, userID, filter, update, &Exercise{}, &Exercise{})
 	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return fmt.Errorf("no exercise found with ID %s or version mismatch", exerciseID.Hex())
+		}
 		return fmt.Errorf("failed to update exercise: %w", err)
 	}
 
-	// Check if the exercise was found and updated
-	if result.ModifiedCount == 0 {
-		return fmt.Errorf("no exercise found with ID %s or version mismatch", exerciseID.Hex())
+	return nil
+}
+
+// Restore puts the editable fields of a custom exercise back to how they were at an
+// earlier version. The restore is an update of its own, with its own revision, so it
+// can be restored away again.
+func (s *ExerciseStore) Restore(ctx context.Context, exerciseID, userID primitive.ObjectID, version, expectedVersion int16) (*Exercise, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	session, err := s.db.Client().StartSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start session: %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	after := &Exercise{}
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		snapshot := &Exercise{}
+		if err := revisionSnapshot(sessCtx, s.db, RevisionResourceExercise, exerciseID, userID, version, snapshot); err != nil {
+			return nil, err
+		}
+
+		filter := bson.M{
+			"_id":       exerciseID,
+			"user_id":   userID,
+			"is_custom": true,
+			"version":   expectedVersion,
+		}
+		update := bson.M{
+			"$set": bson.M{
+				"name":             snapshot.Name,
+				"aliases":          snapshot.Aliases,
+				"force":            snapshot.Force,
+				"level":            snapshot.Level,
+				"mechanic":         snapshot.Mechanic,
+				"equipment":        snapshot.Equipment,
+				"primaryMuscles":   snapshot.PrimaryMuscles,
+				"secondaryMuscles": snapshot.SecondaryMuscles,
+				"instructions":     snapshot.Instructions,
+				"category":         snapshot.Category,
+				"tracking_type":    snapshot.TrackingType,
+				"images":           snapshot.Images,
+				"updated_at":       time.Now(),
+				"version":          expectedVersion + 1,
+			},
+		}
+
+		err := applyWithRevision(sessCtx, s.db, exerciseCollection, userID, filter, update, &Exercise{}, after)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrVersionMismatch
+		}
+		return nil, err
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return after, nil
+}
+
+// what DELETE does with an exercise that routines or workouts still use
+const (
+	ExerciseDeleteRestrict = "restrict" // refuse, the default
+	ExerciseDeleteCascade  = "cascade"  // remove it from routines and in progress workouts first
+	ExerciseDeleteArchive  = "archive"  // keep it, hidden, so workout history stays readable
+)
+
+var ExerciseDeleteModes = []string{ExerciseDeleteRestrict, ExerciseDeleteCascade, ExerciseDeleteArchive}
+
+// ErrExerciseInUse is returned when routines or workouts keep an exercise from being deleted
+var ErrExerciseInUse = errors.New("exercise is still used by routines or workouts")
+
+// ExerciseReference is a routine or workout that uses an exercise
+type ExerciseReference struct {
+	ID        primitive.ObjectID `bson:"_id" json:"id"`
+	Title     string             `bson:"title" json:"title"`
+	Status    string             `bson:"status,omitempty" json:"status,omitempty"`
+	StartTime *time.Time         `bson:"start_time,omitempty" json:"start_time,omitempty"`
+}
+
+// ExerciseDependents lists everything of the user that uses an exercise
+type ExerciseDependents struct {
+	Routines []ExerciseReference `json:"routines"`
+	Workouts []ExerciseReference `json:"workouts"`
+}
+
+func (d *ExerciseDependents) Empty() bool {
+	return len(d.Routines) == 0 && len(d.Workouts) == 0
 }
 
-// delete a custom exercise
-func (s *ExerciseStore) Delete(ctx context.Context, exerciseID primitive.ObjectID, userID primitive.ObjectID) error {
+// routines and workouts of the user that use an exercise
+func (s *ExerciseStore) GetDependents(ctx context.Context, exerciseID, userID primitive.ObjectID) (*ExerciseDependents, error) {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	filter := bson.M{"_id": exerciseID, "user_id": userID}
+	return s.dependents(ctx, exerciseID, userID)
+}
+
+func (s *ExerciseStore) dependents(ctx context.Context, exerciseID, userID primitive.ObjectID) (*ExerciseDependents, error) {
+	filter := bson.M{"user_id": userID, "exercises.exercise_id": exerciseID}
+	dependents := &ExerciseDependents{Routines: []ExerciseReference{}, Workouts: []ExerciseReference{}}
+
+	opts := options.Find().
+		SetProjection(bson.M{"title": 1}).
+		SetSort(bson.D{{Key: "title", Value: 1}})
+	cursor, err := s.db.Collection(routineCollection).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch routines using exercise: %w", err)
+	}
+	if err := cursor.All(ctx, &dependents.Routines); err != nil {
+		return nil, fmt.Errorf("failed to decode routines using exercise: %w", err)
+	}
+
+	opts = options.Find().
+		SetProjection(bson.M{"title": 1, "status": 1, "start_time": 1}).
+		SetSort(bson.D{{Key: "start_time", Value: -1}})
+	cursor, err = s.db.Collection(workoutCollection).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch workouts using exercise: %w", err)
+	}
+	if err := cursor.All(ctx, &dependents.Workouts); err != nil {
+		return nil, fmt.Errorf("failed to decode workouts using exercise: %w", err)
+	}
+
+	return dependents, nil
+}
+
+// Delete removes a custom exercise according to mode. When routines or workouts
+// keep it from being removed, they are returned along with ErrExerciseInUse.
+// Cascading never touches completed workouts, those need the exercise archived.
+func (s *ExerciseStore) Delete(ctx context.Context, exerciseID, userID primitive.ObjectID, mode string) (*ExerciseDependents, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
 
-	result, err := s.db.Collection(exerciseCollection).DeleteOne(ctx, filter)
+	session, err := s.db.Client().StartSession()
 	if err != nil {
-		return fmt.Errorf("failed to delete exercise: %w", err)
+		return nil, fmt.Errorf("failed to start session: %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	var blocking *ExerciseDependents
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		blocking = nil
+
+		dependents, err := s.dependents(sessCtx, exerciseID, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		switch mode {
+		case ExerciseDeleteArchive:
+			return nil, s.archive(sessCtx, exerciseID, userID)
+
+		case ExerciseDeleteCascade:
+			history := &ExerciseDependents{Routines: []ExerciseReference{}, Workouts: []ExerciseReference{}}
+			for _, workout := range dependents.Workouts {
+				if workout.Status != WorkoutStatusInProgress {
+					history.Workouts = append(history.Workouts, workout)
+				}
+			}
+			if !history.Empty() {
+				blocking = history
+				return nil, ErrExerciseInUse
+			}
+
+			if err := removeExerciseReference(sessCtx, s.db, routineCollection, userID, bson.M{"user_id": userID}, exerciseID); err != nil {
+				return nil, err
+			}
+			if err := removeExerciseReference(sessCtx, s.db, workoutCollection, userID, bson.M{"user_id": userID, "status": WorkoutStatusInProgress}, exerciseID); err != nil {
+				return nil, err
+			}
+
+		default:
+			if !dependents.Empty() {
+				blocking = dependents
+				return nil, ErrExerciseInUse
+			}
+		}
+
+		result, err := s.db.Collection(exerciseCollection).DeleteOne(sessCtx, bson.M{"_id": exerciseID, "user_id": userID})
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete exercise: %w", err)
+		}
+		if result.DeletedCount == 0 {
+			return nil, ErrNotFound
+		}
+
+		return nil, deleteRevisions(sessCtx, s.db, RevisionResourceExercise, exerciseID, userID)
+	})
+	if err != nil {
+		return blocking, err
 	}
 
-	if result.DeletedCount == 0 {
-		return fmt.Errorf("no exercise found for the given user and or exercise ID")
+	return nil, nil
+}
+
+func (s *ExerciseStore) archive(ctx mongo.SessionContext, exerciseID, userID primitive.ObjectID) error {
+	update := bson.M{
+		"$set": bson.M{"archived_at": time.Now(), "updated_at": time.Now()},
+		"$inc": bson.M{"version": 1},
 	}
 
-	_, err = s.db.Collection("user").UpdateOne(
-		ctx,
-		bson.M{"_id": userID},
-		bson.M{"$pull": bson.M{"custom_exercises": exerciseID}},
-	)
+	err := applyWithRevision(ctx, s.db, exerciseCollection, userID, bson.M{"_id": exerciseID, "user_id": userID}, update, &Exercise{}, &Exercise{})
 	if err != nil {
-		return fmt.Errorf("failed to remove exercise from user's routines array: %w", err)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("failed to archive exercise: %w", err)
+	}
+
+	return nil
+}
+
+// removeExerciseReference pulls an exercise out of the documents matching filter
+func removeExerciseReference(ctx mongo.SessionContext, db *mongo.Database, collection string, userID primitive.ObjectID, filter bson.M, exerciseID primitive.ObjectID) error {
+	filter["exercises.exercise_id"] = exerciseID
+	update := bson.M{
+		"$pull": bson.M{"exercises": bson.M{"exercise_id": exerciseID}},
+		"$set":  bson.M{"updated_at": time.Now()},
+		"$inc":  bson.M{"version": 1},
+	}
+
+	if _, err := updateEachWithRevision(ctx, db, collection, userID, filter, update); err != nil {
+		return fmt.Errorf("failed to remove exercise from %s documents: %w", collection, err)
 	}
 
 	return nil
 }
+
+// counting all exercises in the system
+func (s *ExerciseStore) Count(ctx context.Context) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	count, err := s.db.Collection(exerciseCollection).CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count exercises: %w", err)
+	}
+
+	return count, nil
+}
+
+// names and aliases of every exercise the user can see, for fuzzy matching
+func (s *ExerciseStore) GetMatchCandidates(ctx context.Context, userID primitive.ObjectID) ([]*Exercise, error) {
+	var exercises []*Exercise
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"$or": bson.A{
+			bson.M{"is_custom": false},
+			bson.M{"user_id": userID, "archived_at": nil},
+		},
+	}
+	opts := options.Find().SetProjection(bson.M{"name": 1, "aliases": 1, "is_custom": 1, "user_id": 1})
+
+	cursor, err := s.db.Collection(exerciseCollection).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch exercise names: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &exercises); err != nil {
+		return nil, fmt.Errorf("failed to decode exercise names: %w", err)
+	}
+
+	return exercises, nil
+}
+
+// ForkResult counts the documents pointed at a new fork
+type ForkResult struct {
+	RoutinesUpdated int64 `json:"routines_updated"`
+	WorkoutsUpdated int64 `json:"workouts_updated"`
+}
+
+// Fork copies a catalog exercise into the user's custom exercises. With rewrite, the
+// user's routines and in progress workouts that use the original use the copy instead.
+func (s *ExerciseStore) Fork(ctx context.Context, original *Exercise, userID primitive.ObjectID, rewrite bool) (*Exercise, *ForkResult, error) {
+	fork := *original
+	fork.ID = primitive.NewObjectID()
+	fork.UserID = &userID
+	fork.ForkedFrom = &original.ID
+	fork.SourceID = ""
+	fork.IsCustom = true
+	fork.Version = 1
+	fork.CreatedAt = time.Now()
+	fork.UpdatedAt = time.Now()
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	session, err := s.db.Client().StartSession()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to start session: %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	result := &ForkResult{}
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		// the transaction may be retried, count from scratch each time
+		*result = ForkResult{}
+
+		if _, err := s.db.Collection(exerciseCollection).InsertOne(sessCtx, &fork); err != nil {
+			return nil, fmt.Errorf("failed to insert fork: %w", err)
+		}
+		if !rewrite {
+			return nil, nil
+		}
+
+		routines, err := replaceExerciseReference(sessCtx, s.db, routineCollection, userID,
+			bson.M{"user_id": userID}, original.ID, fork.ID)
+		if err != nil {
+			return nil, err
+		}
+		workouts, err := replaceExerciseReference(sessCtx, s.db, workoutCollection, userID,
+			bson.M{"user_id": userID, "status": WorkoutStatusInProgress}, original.ID, fork.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		result.RoutinesUpdated, result.WorkoutsUpdated = routines, workouts
+		return nil, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &fork, result, nil
+}
+
+// replaceExerciseReference points every exercises entry referencing from at to, in
+// the documents matching filter, and returns how many documents changed
+func replaceExerciseReference(ctx mongo.SessionContext, db *mongo.Database, collection string, userID primitive.ObjectID, filter bson.M, from, to primitive.ObjectID) (int64, error) {
+	filter["exercises.exercise_id"] = from
+	update := bson.M{
+		"$set": bson.M{
+			"exercises.$[entry].exercise_id": to,
+			"updated_at":                     time.Now(),
+		},
+		"$inc": bson.M{"version": 1},
+	}
+
+	return updateEachWithRevision(ctx, db, collection, userID, filter, update, bson.M{"entry.exercise_id": from})
+}
+
+// CATALOG ROUTES //
+
+// CatalogFilter narrows a catalog listing, zero values are ignored
+type CatalogFilter struct {
+	Category  string
+	Level     string
+	Equipment string
+	Muscle    string // matched against the primary muscles
+}
+
+// CatalogExerciseID derives the ID of a catalog exercise from its dataset ID, so the
+// catalog has the same IDs on every instance and reloading it updates in place
+func CatalogExerciseID(sourceID string) primitive.ObjectID {
+	sum := sha256.Sum256([]byte("catalog:" + sourceID))
+
+	var id primitive.ObjectID
+	copy(id[:], sum[:len(id)])
+	return id
+}
+
+// one page of catalog exercises
+func (s *ExerciseStore) GetCatalog(ctx context.Context, catalogFilter CatalogFilter, query ListQuery) (*Page[*Exercise], error) {
+	filter := bson.M{"is_custom": false}
+	if catalogFilter.Category != "" {
+		filter["category"] = catalogFilter.Category
+	}
+	if catalogFilter.Level != "" {
+		filter["level"] = catalogFilter.Level
+	}
+	if catalogFilter.Equipment != "" {
+		filter["equipment"] = catalogFilter.Equipment
+	}
+	if catalogFilter.Muscle != "" {
+		filter["primaryMuscles"] = catalogFilter.Muscle
+	}
+
+	return findPage[*Exercise](ctx, s.db.Collection(exerciseCollection), filter, query, exerciseListSpec)
+}
+
+// UpsertCatalog inserts new catalog exercises and updates changed ones, keyed by
+// their source ID. Running it again with the same data changes nothing.
+func (s *ExerciseStore) UpsertCatalog(ctx context.Context, exercises []*Exercise) (inserted, updated int64, err error) {
+	if len(exercises) == 0 {
+		return 0, 0, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	models := make([]mongo.WriteModel, 0, len(exercises))
+	for _, exercise := range exercises {
+		if exercise.SourceID == "" {
+			return 0, 0, fmt.Errorf("catalog exercise %q has no source ID", exercise.Name)
+		}
+
+		update := bson.M{
+			"$set": bson.M{
+				"source_id":        exercise.SourceID,
+				"name":             exercise.Name,
+				"force":            exercise.Force,
+				"level":            exercise.Level,
+				"mechanic":         exercise.Mechanic,
+				"equipment":        exercise.Equipment,
+				"primaryMuscles":   exercise.PrimaryMuscles,
+				"secondaryMuscles": exercise.SecondaryMuscles,
+				"instructions":     exercise.Instructions,
+				"category":         exercise.Category,
+				"images":           exercise.Images,
+				"aliases":          exercise.Aliases,
+				"tracking_type":    exercise.TrackingType,
+			},
+			"$setOnInsert": bson.M{
+				"is_custom":  false,
+				"version":    1,
+				"created_at": now,
+				"updated_at": now,
+			},
+		}
+
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": CatalogExerciseID(exercise.SourceID)}).
+			SetUpdate(update).
+			SetUpsert(true))
+	}
+
+	result, err := s.db.Collection(exerciseCollection).BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to load catalog: %w", err)
+	}
+
+	return result.UpsertedCount, result.ModifiedCount, nil
+}
+
+// SEARCH ROUTES //
+
+// the fields exercises can be filtered and faceted on, keyed by the name used in the API
+var ExerciseFacets = map[string]string{
+	"primary_muscle":   "primaryMuscles",
+	"secondary_muscle": "secondaryMuscles",
+	"equipment":        "equipment",
+	"level":            "level",
+	"force":            "force",
+	"mechanic":         "mechanic",
+	"category":         "category",
+}
+
+// ExerciseSearch is a search over the catalog and one user's custom exercises
+type ExerciseSearch struct {
+	UserID  primitive.ObjectID
+	Query   string               // text searched in names, aliases and instructions
+	IDs     []primitive.ObjectID // when set, searches these exercises instead of matching Query
+	Filters map[string]string    // facet name -> value, see ExerciseFacets
+	Skip    int64
+	Limit   int64
+}
+
+// FacetCount is how many results have a value for a facet
+type FacetCount struct {
+	Value string `bson:"_id" json:"value"`
+	Count int64  `bson:"count" json:"count"`
+}
+
+type ExerciseSearchResult struct {
+	Exercises []*Exercise             `json:"exercises"`
+	Total     int64                   `json:"total"`
+	Facets    map[string][]FacetCount `json:"facets"`
+}
+
+// Search finds exercises matching the query and filters, best text matches first
+// or by name without a query. The count for each facet value applies every filter
+// except the one on that facet, so it is the number of results picking it would give.
+func (s *ExerciseStore) Search(ctx context.Context, search ExerciseSearch) (*ExerciseSearchResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// a $text match has to be the first stage of the pipeline
+	base := bson.M{
+		"$or": bson.A{
+			bson.M{"is_custom": false},
+			bson.M{"user_id": search.UserID, "archived_at": nil},
+		},
+	}
+	sort := bson.D{{Key: "name", Value: 1}}
+	if len(search.IDs) > 0 {
+		base["_id"] = bson.M{"$in": search.IDs}
+	} else if search.Query != "" {
+		base["$text"] = bson.M{"$search": search.Query}
+		sort = bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}, {Key: "name", Value: 1}}
+	}
+
+	filtersExcept := func(skip string) bson.M {
+		filter := bson.M{}
+		for name, value := range search.Filters {
+			if field, ok := ExerciseFacets[name]; ok && name != skip && value != "" {
+				filter[field] = value
+			}
+		}
+		return filter
+	}
+
+	facets := bson.M{
+		"results": bson.A{
+			bson.M{"$match": filtersExcept("")},
+			bson.M{"$sort": sort},
+			bson.M{"$skip": search.Skip},
+			bson.M{"$limit": search.Limit},
+		},
+		"total": bson.A{
+			bson.M{"$match": filtersExcept("")},
+			bson.M{"$count": "count"},
+		},
+	}
+	for name, field := range ExerciseFacets {
+		facets[name] = bson.A{
+			bson.M{"$match": filtersExcept(name)},
+			// unwinding a plain field leaves it as is, array fields are counted per value
+			bson.M{"$unwind": "$" + field},
+			bson.M{"$match": bson.M{field: bson.M{"$nin": bson.A{nil, ""}}}},
+			bson.M{"$group": bson.M{"_id": "$" + field, "count": bson.M{"$sum": 1}}},
+			bson.M{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+		}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: base}},
+		{{Key: "$facet", Value: facets}},
+	}
+
+	cursor, err := s.db.Collection(exerciseCollection).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search exercises: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var output []map[string]bson.RawValue
+	if err := cursor.All(ctx, &output); err != nil {
+		return nil, fmt.Errorf("failed to decode exercise search: %w", err)
+	}
+
+	result := &ExerciseSearchResult{
+		Exercises: []*Exercise{},
+		Facets:    make(map[string][]FacetCount, len(ExerciseFacets)),
+	}
+	if len(output) == 0 {
+		return result, nil
+	}
+
+	if err := output[0]["results"].Unmarshal(&result.Exercises); err != nil {
+		return nil, fmt.Errorf("failed to decode exercise search results: %w", err)
+	}
+
+	var total []struct {
+		Count int64 `bson:"count"`
+	}
+	if err := output[0]["total"].Unmarshal(&total); err != nil {
+		return nil, fmt.Errorf("failed to decode exercise search total: %w", err)
+	}
+	if len(total) > 0 {
+		result.Total = total[0].Count
+	}
+
+	for name := range ExerciseFacets {
+		counts := []FacetCount{}
+		if err := output[0][name].Unmarshal(&counts); err != nil {
+			return nil, fmt.Errorf("failed to decode %s facet: %w", name, err)
+		}
+		result.Facets[name] = counts
+	}
+
+	return result, nil
+}
@@ -0,0 +1,116 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ImportSet is every write an import makes to one account. Documents are already
+// owned by the user and carry their final IDs.
+type ImportSet struct {
+	Profile          map[string]interface{} // profile fields to set, may be empty
+	Exercises        []*Exercise
+	Routines         []*Routine
+	Workouts         []*WorkoutSession
+	ReplaceExercises []*Exercise // replace the user's document with the same ID
+	ReplaceRoutines  []*Routine
+	ReplaceWorkouts  []*WorkoutSession
+}
+
+type ImportStore struct {
+	db *mongo.Database
+}
+
+// Apply writes an import in a single transaction, so a failed import leaves the account untouched
+func (s *ImportStore) Apply(ctx context.Context, userID primitive.ObjectID, set *ImportSet) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	session, err := s.db.Client().StartSession()
+	if err != nil {
+		return fmt.Errorf("failed to start session: %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		if len(set.Profile) > 0 {
+			fields := bson.M{"updated_at": time.Now()}
+			for field, value := range set.Profile {
+				fields[field] = value
+			}
+			update := bson.M{"$set": fields, "$inc": bson.M{"version": 1}}
+
+			result, err := s.db.Collection(userCollection).UpdateOne(sessCtx, bson.M{"_id": userID}, update)
+			if err != nil {
+				return nil, fmt.Errorf("failed to update profile: %w", err)
+			}
+			if result.MatchedCount == 0 {
+				return nil, ErrNotFound
+			}
+		}
+
+		if err := insertAll(sessCtx, s.db.Collection(exerciseCollection), set.Exercises); err != nil {
+			return nil, err
+		}
+		if err := insertAll(sessCtx, s.db.Collection(routineCollection), set.Routines); err != nil {
+			return nil, err
+		}
+		if err := insertAll(sessCtx, s.db.Collection(workoutCollection), set.Workouts); err != nil {
+			return nil, err
+		}
+
+		for _, exercise := range set.ReplaceExercises {
+			if err := replaceOwned(sessCtx, s.db.Collection(exerciseCollection), exercise.ID, userID, exercise); err != nil {
+				return nil, err
+			}
+		}
+		for _, routine := range set.ReplaceRoutines {
+			if err := replaceOwned(sessCtx, s.db.Collection(routineCollection), routine.ID, userID, routine); err != nil {
+				return nil, err
+			}
+		}
+		for _, workout := range set.ReplaceWorkouts {
+			if err := replaceOwned(sessCtx, s.db.Collection(workoutCollection), workout.ID, userID, workout); err != nil {
+				return nil, err
+			}
+		}
+
+		return nil, nil
+	})
+
+	return err
+}
+
+func insertAll[T any](ctx context.Context, collection *mongo.Collection, documents []T) error {
+	if len(documents) == 0 {
+		return nil
+	}
+
+	docs := make([]interface{}, len(documents))
+	for i, doc := range documents {
+		docs[i] = doc
+	}
+
+	if _, err := collection.InsertMany(ctx, docs); err != nil {
+		return fmt.Errorf("failed to insert %s documents: %w", collection.Name(), err)
+	}
+
+	return nil
+}
+
+func replaceOwned(ctx context.Context, collection *mongo.Collection, id, userID primitive.ObjectID, document interface{}) error {
+	result, err := collection.ReplaceOne(ctx, bson.M{"_id": id, "user_id": userID}, document)
+	if err != nil {
+		return fmt.Errorf("failed to replace %s document: %w", collection.Name(), err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
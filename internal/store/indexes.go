@@ -0,0 +1,120 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// indexes lists the indexes each collection needs, keyed by collection name
+var indexes = map[string][]mongo.IndexModel{
+	refreshTokenCollection: {
+		{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "family_id", Value: 1}}},
+		// expired tokens are removed by MongoDB once they are past expires_at
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	},
+	userTokenCollection: {
+		{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "purpose", Value: 1}}},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	},
+	userCollection: {
+		{Keys: bson.D{{Key: "deletion_scheduled_for", Value: 1}}, Options: options.Index().SetSparse(true)},
+	},
+	exerciseCollection: {
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "name", Value: 1}}},
+		{Keys: bson.D{{Key: "is_custom", Value: 1}, {Key: "name", Value: 1}}},
+		// search matches names and aliases before instructions
+		{
+			Keys: bson.D{{Key: "name", Value: "text"}, {Key: "aliases", Value: "text"}, {Key: "instructions", Value: "text"}},
+			Options: options.Index().SetName("exercise_search").
+				SetWeights(bson.D{{Key: "name", Value: 10}, {Key: "aliases", Value: 8}, {Key: "instructions", Value: 1}}),
+		},
+		{Keys: bson.D{{Key: "is_custom", Value: 1}, {Key: "category", Value: 1}, {Key: "level", Value: 1}}},
+		{Keys: bson.D{{Key: "is_custom", Value: 1}, {Key: "primaryMuscles", Value: 1}, {Key: "equipment", Value: 1}}},
+		{Keys: bson.D{{Key: "is_custom", Value: 1}, {Key: "secondaryMuscles", Value: 1}}},
+		{
+			Keys: bson.D{{Key: "source_id", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"is_custom": false}),
+		},
+	},
+	routineCollection: {
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "updated_at", Value: -1}}},
+	},
+	workoutCollection: {
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "start_time", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "status", Value: 1}, {Key: "start_time", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "routine_id", Value: 1}, {Key: "start_time", Value: -1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "exercises.exercise_id", Value: 1}, {Key: "start_time", Value: -1}}},
+	},
+	apiKeyCollection: {
+		{Keys: bson.D{{Key: "key_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	},
+	securityEventCollection: {
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "type", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "ip", Value: 1}, {Key: "created_at", Value: -1}}},
+	},
+	jobCollection: {
+		{Keys: bson.D{{Key: "type", Value: 1}, {Key: "status", Value: 1}, {Key: "created_at", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "type", Value: 1}, {Key: "status", Value: 1}}},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetSparse(true)},
+	},
+	mediaCollection: {
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "exercise_id", Value: 1}, {Key: "created_at", Value: 1}}},
+	},
+	mergeCollection: {
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	},
+	revisionCollection: {
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "resource_type", Value: 1}, {Key: "resource_id", Value: 1}, {Key: "version", Value: -1}}},
+	},
+	auditCollection: {
+		{Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "actor_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "resource_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "created_at", Value: -1}}},
+	},
+}
+
+// CreateIndexes makes sure every index in the indexes list exists. A named index
+// whose definition changed is dropped and built again.
+func CreateIndexes(ctx context.Context, db *mongo.Database) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	for collection, models := range indexes {
+		view := db.Collection(collection).Indexes()
+		for _, model := range models {
+			_, err := view.CreateOne(ctx, model)
+			if err != nil && isIndexConflict(err) && model.Options != nil && model.Options.Name != nil {
+				if _, err = view.DropOne(ctx, *model.Options.Name); err == nil {
+					_, err = view.CreateOne(ctx, model)
+				}
+			}
+			if err != nil {
+				return fmt.Errorf("failed to create indexes on %s: %w", collection, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// the error codes MongoDB uses when an index of the same name exists with other options or keys
+func isIndexConflict(err error) bool {
+	var cmdErr mongo.CommandError
+	if !errors.As(err, &cmdErr) {
+		return false
+	}
+	return cmdErr.Code == 85 || cmdErr.Code == 86
+}
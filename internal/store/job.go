@@ -0,0 +1,217 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	JobTypeExport = "export"
+)
+
+const (
+	JobStatusPending   = "pending"
+	JobStatusRunning   = "running"
+	JobStatusCompleted = "completed"
+	JobStatusFailed    = "failed"
+)
+
+// Job is a unit of background work requested by a user, picked up by a worker in the API process
+type Job struct {
+	ID         primitive.ObjectID `bson:"_id" json:"id"`
+	UserID     primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Type       string             `bson:"type" json:"type"`
+	Status     string             `bson:"status" json:"status"`
+	Error      string             `bson:"error,omitempty" json:"error,omitempty"`
+	FilePath   string             `bson:"file_path,omitempty" json:"-"`
+	FileSize   int64              `bson:"file_size,omitempty" json:"file_size,omitempty"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	StartedAt  *time.Time         `bson:"started_at,omitempty" json:"started_at,omitempty"`
+	FinishedAt *time.Time         `bson:"finished_at,omitempty" json:"finished_at,omitempty"`
+	ExpiresAt  *time.Time         `bson:"expires_at,omitempty" json:"expires_at,omitempty"` // the result is removed after this time
+}
+
+type JobStore struct {
+	db *mongo.Database
+}
+
+const jobCollection = "job"
+
+// queueing a job
+func (s *JobStore) Create(ctx context.Context, job *Job) error {
+	job.ID = primitive.NewObjectID()
+	job.Status = JobStatusPending
+	job.CreatedAt = time.Now()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := s.db.Collection(jobCollection).InsertOne(ctx, job)
+	if err != nil {
+		return fmt.Errorf("failed to insert job: %w", err)
+	}
+
+	return nil
+}
+
+// return 1 job belonging to the user
+func (s *JobStore) GetByID(ctx context.Context, jobID, userID primitive.ObjectID) (*Job, error) {
+	job := &Job{}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": jobID, "user_id": userID}
+
+	err := s.db.Collection(jobCollection).FindOne(ctx, filter).Decode(job)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+
+	return job, nil
+}
+
+// the user's pending or running job of a type, if any
+func (s *JobStore) GetActive(ctx context.Context, userID primitive.ObjectID, jobType string) (*Job, error) {
+	job := &Job{}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"user_id": userID,
+		"type":    jobType,
+		"status":  bson.M{"$in": bson.A{JobStatusPending, JobStatusRunning}},
+	}
+
+	err := s.db.Collection(jobCollection).FindOne(ctx, filter).Decode(job)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get active job: %w", err)
+	}
+
+	return job, nil
+}
+
+// ClaimNext marks the oldest pending job of a type as running and returns it,
+// so each job is only picked up once
+func (s *JobStore) ClaimNext(ctx context.Context, jobType string) (*Job, error) {
+	job := &Job{}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"type": jobType, "status": JobStatusPending}
+	update := bson.M{"$set": bson.M{"status": JobStatusRunning, "started_at": time.Now()}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	err := s.db.Collection(jobCollection).FindOneAndUpdate(ctx, filter, update, opts).Decode(job)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to claim job: %w", err)
+	}
+
+	return job, nil
+}
+
+// RequeueRunning puts jobs that were running when the process stopped back in the queue
+func (s *JobStore) RequeueRunning(ctx context.Context, jobType string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"type": jobType, "status": JobStatusRunning}
+	update := bson.M{"$set": bson.M{"status": JobStatusPending}, "$unset": bson.M{"started_at": ""}}
+
+	_, err := s.db.Collection(jobCollection).UpdateMany(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to requeue jobs: %w", err)
+	}
+
+	return nil
+}
+
+// recording a finished job and where its result was written
+func (s *JobStore) Complete(ctx context.Context, jobID primitive.ObjectID, filePath string, fileSize int64, expiresAt time.Time) error {
+	return s.finish(ctx, jobID, bson.M{
+		"status":      JobStatusCompleted,
+		"file_path":   filePath,
+		"file_size":   fileSize,
+		"finished_at": time.Now(),
+		"expires_at":  expiresAt,
+	})
+}
+
+// recording a job that could not be finished
+func (s *JobStore) Fail(ctx context.Context, jobID primitive.ObjectID, message string) error {
+	return s.finish(ctx, jobID, bson.M{
+		"status":      JobStatusFailed,
+		"error":       message,
+		"finished_at": time.Now(),
+	})
+}
+
+func (s *JobStore) finish(ctx context.Context, jobID primitive.ObjectID, fields bson.M) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := s.db.Collection(jobCollection).UpdateOne(ctx, bson.M{"_id": jobID}, bson.M{"$set": fields})
+	if err != nil {
+		return fmt.Errorf("failed to update job: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// jobs whose result is past its expiry
+func (s *JobStore) GetExpired(ctx context.Context, before time.Time) ([]*Job, error) {
+	var jobs []*Job
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"expires_at": bson.M{"$lte": before}}
+
+	cursor, err := s.db.Collection(jobCollection).Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch expired jobs: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &jobs); err != nil {
+		return nil, fmt.Errorf("failed to decode expired jobs: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// DELETING a job
+func (s *JobStore) Delete(ctx context.Context, jobID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := s.db.Collection(jobCollection).DeleteOne(ctx, bson.M{"_id": jobID})
+	if err != nil {
+		return fmt.Errorf("failed to delete job: %w", err)
+	}
+
+	return nil
+}
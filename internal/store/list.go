@@ -0,0 +1,231 @@
+package store
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	DefaultListLimit = 50
+	MaxListLimit     = 200
+)
+
+// ErrInvalidListQuery is returned for a sort field, field or cursor the list does not accept
+var ErrInvalidListQuery = errors.New("invalid list query")
+
+// ListQuery asks for one page of a list. The zero value is the first page in the
+// default order with every field.
+type ListQuery struct {
+	Cursor string   // next_cursor of the previous page
+	Limit  int64    // page size, DefaultListLimit when zero
+	Sort   string   // a sort field of the list, prefixed with "-" for descending order
+	Fields []string // JSON names of the fields to return, the id is always included
+}
+
+// Page is one page of a list
+type Page[T any] struct {
+	Items      []T
+	NextCursor string // empty on the last page
+	HasMore    bool
+}
+
+// listSpec describes what a list can be sorted by, keyed by the name used in the API
+type listSpec struct {
+	sortFields  map[string]string
+	defaultSort string
+}
+
+// listCursor is what an opaque cursor holds: the sort it was made for and the
+// position of the last item of the page, as its sort value and ID
+type listCursor struct {
+	Sort  string             `bson:"s"`
+	Value bson.RawValue      `bson:"v"`
+	ID    primitive.ObjectID `bson:"id"`
+}
+
+// findPage returns the page of documents matching filter that comes after the
+// query's cursor. Pages are sorted on the sort field and then _id, so items with
+// the same sort value are neither skipped nor repeated between pages.
+func findPage[T any](ctx context.Context, collection *mongo.Collection, filter bson.M, query ListQuery, spec listSpec) (*Page[T], error) {
+	sortName := query.Sort
+	if sortName == "" {
+		sortName = spec.defaultSort
+	}
+	direction := 1
+	if strings.HasPrefix(sortName, "-") {
+		direction = -1
+	}
+	sortField, ok := spec.sortFields[strings.TrimPrefix(sortName, "-")]
+	if !ok {
+		return nil, fmt.Errorf("%w: sort must be one of %s", ErrInvalidListQuery, strings.Join(sortNames(spec), ", "))
+	}
+
+	limit := query.Limit
+	if limit == 0 {
+		limit = DefaultListLimit
+	}
+	if limit < 1 || limit > MaxListLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidListQuery, MaxListLimit)
+	}
+
+	if query.Cursor != "" {
+		after, err := decodeCursor(query.Cursor, sortName)
+		if err != nil {
+			return nil, err
+		}
+
+		comparison := "$gt"
+		if direction == -1 {
+			comparison = "$lt"
+		}
+		filter = bson.M{"$and": bson.A{filter, bson.M{"$or": bson.A{
+			bson.M{sortField: bson.M{comparison: after.Value}},
+			bson.M{sortField: after.Value, "_id": bson.M{comparison: after.ID}},
+		}}}}
+	}
+
+	// one extra document tells whether there is another page
+	opts := options.Find().
+		SetSort(bson.D{{Key: sortField, Value: direction}, {Key: "_id", Value: direction}}).
+		SetLimit(limit + 1)
+
+	if len(query.Fields) > 0 {
+		projection, err := projectionFor[T](query.Fields, sortField)
+		if err != nil {
+			return nil, err
+		}
+		opts.SetProjection(projection)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s documents: %w", collection.Name(), err)
+	}
+	defer cursor.Close(ctx)
+
+	page := &Page[T]{Items: []T{}}
+	var last bson.Raw
+	for cursor.Next(ctx) {
+		if int64(len(page.Items)) == limit {
+			page.HasMore = true
+			break
+		}
+
+		var item T
+		if err := cursor.Decode(&item); err != nil {
+			return nil, fmt.Errorf("failed to decode %s document: %w", collection.Name(), err)
+		}
+		page.Items = append(page.Items, item)
+		last = cursor.Current
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch %s documents: %w", collection.Name(), err)
+	}
+
+	if page.HasMore {
+		page.NextCursor, err = encodeCursor(last, sortName, sortField)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return page, nil
+}
+
+func encodeCursor(last bson.Raw, sortName, sortField string) (string, error) {
+	id, ok := last.Lookup("_id").ObjectIDOK()
+	if !ok {
+		return "", fmt.Errorf("document has no ObjectID to continue from")
+	}
+
+	data, err := bson.Marshal(listCursor{
+		Sort:  sortName,
+		Value: last.Lookup(strings.Split(sortField, ".")...), // a dotted sort field is a nested key
+		ID:    id,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeCursor(encoded, sortName string) (*listCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidListQuery)
+	}
+
+	after := &listCursor{}
+	if err := bson.Unmarshal(data, after); err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidListQuery)
+	}
+
+	// a cursor is a position in one ordering and means nothing in another
+	if after.Sort != sortName {
+		return nil, fmt.Errorf("%w: cursor was made for a different sort", ErrInvalidListQuery)
+	}
+
+	return after, nil
+}
+
+// projectionFor turns JSON field names of T into a projection on their BSON names.
+// The sort field and _id are always included, the next cursor is built from them.
+func projectionFor[T any](fields []string, sortField string) (bson.M, error) {
+	available := fieldNames(reflect.TypeOf((*T)(nil)).Elem())
+
+	projection := bson.M{"_id": 1, sortField: 1}
+	for _, field := range fields {
+		bsonName, ok := available[field]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown field %q", ErrInvalidListQuery, field)
+		}
+		projection[bsonName] = 1
+	}
+
+	return projection, nil
+}
+
+// fieldNames maps the JSON name of every exported field of a struct to its BSON name
+func fieldNames(t reflect.Type) map[string]string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	names := make(map[string]string, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		jsonName := strings.Split(field.Tag.Get("json"), ",")[0]
+		bsonName := strings.Split(field.Tag.Get("bson"), ",")[0]
+		if jsonName == "" || jsonName == "-" || bsonName == "" || bsonName == "-" {
+			continue
+		}
+		names[jsonName] = bsonName
+	}
+
+	return names
+}
+
+func sortNames(spec listSpec) []string {
+	names := make([]string, 0, len(spec.sortFields))
+	for name := range spec.sortFields {
+		names = append(names, name)
+	}
+	// map order is random, keep error messages stable
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,96 @@
+package store
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	id := primitive.NewObjectID()
+	createdAt := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		sortName  string
+		sortField string
+		doc       bson.D
+	}{
+		{"string ascending", "name", "name", bson.D{{Key: "_id", Value: id}, {Key: "name", Value: "Bench Press"}}},
+		{"date descending", "-created_at", "created_at", bson.D{{Key: "_id", Value: id}, {Key: "created_at", Value: createdAt}}},
+		{"number", "version", "version", bson.D{{Key: "_id", Value: id}, {Key: "version", Value: int32(7)}}},
+		{"nested field", "-stats.volume", "stats.volume", bson.D{{Key: "_id", Value: id}, {Key: "stats", Value: bson.D{{Key: "volume", Value: 1250.5}}}}},
+		{"id", "-id", "_id", bson.D{{Key: "_id", Value: id}}},
+	}
+
+	for _, tt := range tests {
+		last, err := bson.Marshal(tt.doc)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		encoded, err := encodeCursor(last, tt.sortName, tt.sortField)
+		if err != nil {
+			t.Fatalf("%s: encodeCursor: %v", tt.name, err)
+		}
+
+		after, err := decodeCursor(encoded, tt.sortName)
+		if err != nil {
+			t.Fatalf("%s: decodeCursor: %v", tt.name, err)
+		}
+
+		if after.Sort != tt.sortName {
+			t.Errorf("%s: Sort = %q, want %q", tt.name, after.Sort, tt.sortName)
+		}
+		if after.ID != id {
+			t.Errorf("%s: ID = %s, want %s", tt.name, after.ID.Hex(), id.Hex())
+		}
+		if want := bson.Raw(last).Lookup(strings.Split(tt.sortField, ".")...); !after.Value.Equal(want) {
+			t.Errorf("%s: Value = %s, want %s", tt.name, after.Value, want)
+		}
+	}
+}
+
+func TestDecodeCursorRejects(t *testing.T) {
+	last, err := bson.Marshal(bson.D{{Key: "_id", Value: primitive.NewObjectID()}, {Key: "name", Value: "Squat"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid, err := encodeCursor(last, "name", "name")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		encoded  string
+		sortName string
+	}{
+		{"not base64", "not a cursor!", "name"},
+		{"not bson", base64.RawURLEncoding.EncodeToString([]byte("hello")), "name"},
+		{"other sort", valid, "-name"},
+		{"other sort field", valid, "created_at"},
+	}
+
+	for _, tt := range tests {
+		if _, err := decodeCursor(tt.encoded, tt.sortName); !errors.Is(err, ErrInvalidListQuery) {
+			t.Errorf("%s: decodeCursor = %v, want ErrInvalidListQuery", tt.name, err)
+		}
+	}
+}
+
+func TestEncodeCursorNeedsObjectID(t *testing.T) {
+	last, err := bson.Marshal(bson.D{{Key: "_id", Value: "not an ObjectID"}, {Key: "name", Value: "Squat"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := encodeCursor(last, "name", "name"); err == nil {
+		t.Error("encodeCursor accepted a document without an ObjectID")
+	}
+}
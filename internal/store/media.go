@@ -0,0 +1,120 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ExerciseMedia is an image or clip attached to a custom exercise. The file itself
+// lives in the blob store under Key, its thumbnail under ThumbnailKey.
+type ExerciseMedia struct {
+	ID           primitive.ObjectID `bson:"_id" json:"id"`
+	UserID       primitive.ObjectID `bson:"user_id" json:"user_id"`
+	ExerciseID   primitive.ObjectID `bson:"exercise_id" json:"exercise_id"`
+	Kind         string             `bson:"kind" json:"kind"` // image or video
+	ContentType  string             `bson:"content_type" json:"content_type"`
+	Size         int64              `bson:"size" json:"size"`
+	FileName     string             `bson:"file_name,omitempty" json:"file_name,omitempty"`
+	Width        int                `bson:"width,omitempty" json:"width,omitempty"`
+	Height       int                `bson:"height,omitempty" json:"height,omitempty"`
+	Key          string             `bson:"key" json:"-"`
+	ThumbnailKey string             `bson:"thumbnail_key,omitempty" json:"-"`
+	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
+}
+
+type MediaStore struct {
+	db *mongo.Database
+}
+
+const mediaCollection = "exercise_media"
+
+func (s *MediaStore) Create(ctx context.Context, media *ExerciseMedia) error {
+	media.CreatedAt = time.Now()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if _, err := s.db.Collection(mediaCollection).InsertOne(ctx, media); err != nil {
+		return fmt.Errorf("failed to insert exercise media: %w", err)
+	}
+
+	return nil
+}
+
+// media of one exercise, oldest first
+func (s *MediaStore) GetByExercise(ctx context.Context, exerciseID, userID primitive.ObjectID) ([]*ExerciseMedia, error) {
+	return s.find(ctx, bson.M{"exercise_id": exerciseID, "user_id": userID})
+}
+
+// every media of a user, to remove the files when the account is purged
+func (s *MediaStore) GetAllUserMedia(ctx context.Context, userID primitive.ObjectID) ([]*ExerciseMedia, error) {
+	return s.find(ctx, bson.M{"user_id": userID})
+}
+
+func (s *MediaStore) GetByID(ctx context.Context, mediaID, exerciseID, userID primitive.ObjectID) (*ExerciseMedia, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	media := &ExerciseMedia{}
+	filter := bson.M{"_id": mediaID, "exercise_id": exerciseID, "user_id": userID}
+	if err := s.db.Collection(mediaCollection).FindOne(ctx, filter).Decode(media); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch exercise media: %w", err)
+	}
+
+	return media, nil
+}
+
+func (s *MediaStore) CountByExercise(ctx context.Context, exerciseID, userID primitive.ObjectID) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	count, err := s.db.Collection(mediaCollection).CountDocuments(ctx, bson.M{"exercise_id": exerciseID, "user_id": userID})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count exercise media: %w", err)
+	}
+
+	return count, nil
+}
+
+func (s *MediaStore) Delete(ctx context.Context, mediaID, userID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := s.db.Collection(mediaCollection).DeleteOne(ctx, bson.M{"_id": mediaID, "user_id": userID})
+	if err != nil {
+		return fmt.Errorf("failed to delete exercise media: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *MediaStore) find(ctx context.Context, filter bson.M) ([]*ExerciseMedia, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := s.db.Collection(mediaCollection).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch exercise media: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	media := []*ExerciseMedia{}
+	if err := cursor.All(ctx, &media); err != nil {
+		return nil, fmt.Errorf("failed to decode exercise media: %w", err)
+	}
+
+	return media, nil
+}
@@ -0,0 +1,332 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	// ErrInvalidMerge is returned for a merge of exercises that cannot be combined
+	ErrInvalidMerge = errors.New("invalid merge")
+	// ErrMergeUndone is returned when undoing a merge a second time
+	ErrMergeUndone = errors.New("merge was already undone")
+)
+
+// ExerciseMerge records duplicates folded into a survivor, with what is needed to undo it
+type ExerciseMerge struct {
+	ID           primitive.ObjectID `bson:"_id" json:"id"`
+	UserID       primitive.ObjectID `bson:"user_id" json:"user_id"`
+	SurvivorID   primitive.ObjectID `bson:"survivor_id" json:"survivor_id"`
+	Duplicates   []*Exercise        `bson:"duplicates" json:"duplicates"` // as they were before the merge
+	References   []MergeReference   `bson:"references" json:"references"`
+	AddedAliases []string           `bson:"added_aliases,omitempty" json:"added_aliases,omitempty"`
+	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
+	UndoneAt     *time.Time         `bson:"undone_at,omitempty" json:"undone_at,omitempty"`
+}
+
+// MergeReference is a routine or workout whose exercises were pointed at the survivor
+type MergeReference struct {
+	Collection string             `bson:"collection" json:"collection"` // routine or workout
+	DocumentID primitive.ObjectID `bson:"document_id" json:"document_id"`
+	Entries    []MergeEntry       `bson:"entries" json:"entries"`
+}
+
+// MergeEntry is one rewritten exercises entry, found again by its entry ID
+type MergeEntry struct {
+	EntryID    primitive.ObjectID `bson:"entry_id" json:"entry_id"`
+	ExerciseID primitive.ObjectID `bson:"exercise_id" json:"exercise_id"` // the duplicate it pointed at
+}
+
+type MergeStore struct {
+	db *mongo.Database
+}
+
+const mergeCollection = "exercise_merge"
+
+// Merge folds duplicates into the survivor: every routine and workout entry using a
+// duplicate uses the survivor instead, the duplicates' names become aliases of a
+// custom survivor, and the duplicates are removed. The record returned can undo it.
+func (s *MergeStore) Merge(ctx context.Context, userID, survivorID primitive.ObjectID, duplicateIDs []primitive.ObjectID) (*ExerciseMerge, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	session, err := s.db.Client().StartSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start session: %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	var merge *ExerciseMerge
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		exercises := s.db.Collection(exerciseCollection)
+
+		survivor := &Exercise{}
+		survivorFilter := bson.M{"_id": survivorID, "$or": bson.A{bson.M{"user_id": userID}, bson.M{"is_custom": false}}}
+		if err := exercises.FindOne(sessCtx, survivorFilter).Decode(survivor); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return nil, ErrNotFound
+			}
+			return nil, fmt.Errorf("failed to fetch survivor exercise: %w", err)
+		}
+
+		var duplicates []*Exercise
+		cursor, err := exercises.Find(sessCtx, bson.M{"_id": bson.M{"$in": duplicateIDs}, "user_id": userID, "is_custom": true})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch duplicate exercises: %w", err)
+		}
+		if err := cursor.All(sessCtx, &duplicates); err != nil {
+			return nil, fmt.Errorf("failed to decode duplicate exercises: %w", err)
+		}
+		if len(duplicates) != len(duplicateIDs) {
+			return nil, ErrNotFound
+		}
+		for _, duplicate := range duplicates {
+			if duplicate.Tracking() != survivor.Tracking() {
+				return nil, fmt.Errorf("%w: %q is tracked as %s and %q as %s", ErrInvalidMerge,
+					duplicate.Name, duplicate.Tracking(), survivor.Name, survivor.Tracking())
+			}
+		}
+
+		merge = &ExerciseMerge{
+			ID:         primitive.NewObjectID(),
+			UserID:     userID,
+			SurvivorID: survivorID,
+			Duplicates: duplicates,
+			References: []MergeReference{},
+			CreatedAt:  time.Now(),
+		}
+
+		for _, collection := range []string{routineCollection, workoutCollection} {
+			references, err := repointExercises(sessCtx, s.db, collection, userID, duplicateIDs, survivorID)
+			if err != nil {
+				return nil, err
+			}
+			merge.References = append(merge.References, references...)
+		}
+
+		if survivor.IsCustom {
+			merge.AddedAliases = newAliases(survivor, duplicates)
+			if len(merge.AddedAliases) > 0 {
+				update := bson.M{
+					"$addToSet": bson.M{"aliases": bson.M{"$each": merge.AddedAliases}},
+					"$set":      bson.M{"updated_at": time.Now()},
+					"$inc":      bson.M{"version": 1},
+				}
+				err := applyWithRevision(sessCtx, s.db, exerciseCollection, userID, bson.M{"_id": survivorID, "user_id": userID}, update, &Exercise{}, &Exercise{})
+				if err != nil {
+					return nil, fmt.Errorf("failed to add aliases to survivor exercise: %w", err)
+				}
+			}
+		}
+
+		if _, err := exercises.DeleteMany(sessCtx, bson.M{"_id": bson.M{"$in": duplicateIDs}, "user_id": userID}); err != nil {
+			return nil, fmt.Errorf("failed to delete duplicate exercises: %w", err)
+		}
+
+		if _, err := s.db.Collection(mergeCollection).InsertOne(sessCtx, merge); err != nil {
+			return nil, fmt.Errorf("failed to record merge: %w", err)
+		}
+
+		return nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return merge, nil
+}
+
+// Undo restores the duplicates of a merge and points the entries it rewrote back at
+// them. Entries removed or changed since the merge are left alone.
+func (s *MergeStore) Undo(ctx context.Context, mergeID, userID primitive.ObjectID) (*ExerciseMerge, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	session, err := s.db.Client().StartSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start session: %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	merge := &ExerciseMerge{}
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		filter := bson.M{"_id": mergeID, "user_id": userID}
+		if err := s.db.Collection(mergeCollection).FindOne(sessCtx, filter).Decode(merge); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return nil, ErrNotFound
+			}
+			return nil, fmt.Errorf("failed to fetch merge: %w", err)
+		}
+		if merge.UndoneAt != nil {
+			return nil, ErrMergeUndone
+		}
+
+		if len(merge.Duplicates) > 0 {
+			documents := make([]interface{}, len(merge.Duplicates))
+			for i, duplicate := range merge.Duplicates {
+				documents[i] = duplicate
+			}
+			if _, err := s.db.Collection(exerciseCollection).InsertMany(sessCtx, documents); err != nil {
+				return nil, fmt.Errorf("failed to restore duplicate exercises: %w", err)
+			}
+		}
+
+		for _, reference := range merge.References {
+			if err := restoreEntries(sessCtx, s.db, userID, merge.SurvivorID, reference); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(merge.AddedAliases) > 0 {
+			update := bson.M{
+				"$pullAll": bson.M{"aliases": merge.AddedAliases},
+				"$set":     bson.M{"updated_at": time.Now()},
+				"$inc":     bson.M{"version": 1},
+			}
+			// a survivor deleted since has no aliases left to remove
+			err := applyWithRevision(sessCtx, s.db, exerciseCollection, userID, bson.M{"_id": merge.SurvivorID, "user_id": userID}, update, &Exercise{}, &Exercise{})
+			if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+				return nil, fmt.Errorf("failed to remove merged aliases: %w", err)
+			}
+		}
+
+		now := time.Now()
+		merge.UndoneAt = &now
+		if _, err := s.db.Collection(mergeCollection).UpdateByID(sessCtx, mergeID, bson.M{"$set": bson.M{"undone_at": now}}); err != nil {
+			return nil, fmt.Errorf("failed to mark merge undone: %w", err)
+		}
+
+		return nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return merge, nil
+}
+
+// merges of the user, newest first
+func (s *MergeStore) GetAllUserMerges(ctx context.Context, userID primitive.ObjectID) ([]*ExerciseMerge, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := s.db.Collection(mergeCollection).Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch merges: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	merges := []*ExerciseMerge{}
+	if err := cursor.All(ctx, &merges); err != nil {
+		return nil, fmt.Errorf("failed to decode merges: %w", err)
+	}
+
+	return merges, nil
+}
+
+// repointExercises points every entry using one of from at to, in the user's
+// documents of a collection, and returns what it changed
+func repointExercises(ctx mongo.SessionContext, db *mongo.Database, collectionName string, userID primitive.ObjectID, from []primitive.ObjectID, to primitive.ObjectID) ([]MergeReference, error) {
+	collection := db.Collection(collectionName)
+	filter := bson.M{"user_id": userID, "exercises.exercise_id": bson.M{"$in": from}}
+
+	var documents []struct {
+		ID        primitive.ObjectID `bson:"_id"`
+		Exercises []struct {
+			EntryID    primitive.ObjectID `bson:"entry_id"`
+			ExerciseID primitive.ObjectID `bson:"exercise_id"`
+		} `bson:"exercises"`
+	}
+	cursor, err := collection.Find(ctx, filter, options.Find().SetProjection(bson.M{"exercises.entry_id": 1, "exercises.exercise_id": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s documents: %w", collection.Name(), err)
+	}
+	if err := cursor.All(ctx, &documents); err != nil {
+		return nil, fmt.Errorf("failed to decode %s documents: %w", collection.Name(), err)
+	}
+
+	merged := make(map[primitive.ObjectID]bool, len(from))
+	for _, id := range from {
+		merged[id] = true
+	}
+
+	references := make([]MergeReference, 0, len(documents))
+	for _, document := range documents {
+		reference := MergeReference{Collection: collection.Name(), DocumentID: document.ID}
+		for _, entry := range document.Exercises {
+			if merged[entry.ExerciseID] {
+				reference.Entries = append(reference.Entries, MergeEntry{EntryID: entry.EntryID, ExerciseID: entry.ExerciseID})
+			}
+		}
+		references = append(references, reference)
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"exercises.$[entry].exercise_id": to,
+			"updated_at":                     time.Now(),
+		},
+		"$inc": bson.M{"version": 1},
+	}
+	arrayFilter := bson.M{"entry.exercise_id": bson.M{"$in": from}}
+	if _, err := updateEachWithRevision(ctx, db, collectionName, userID, filter, update, arrayFilter); err != nil {
+		return nil, err
+	}
+
+	return references, nil
+}
+
+// restoreEntries points the entries of one merged document back at their duplicates
+func restoreEntries(ctx mongo.SessionContext, db *mongo.Database, userID, survivorID primitive.ObjectID, reference MergeReference) error {
+	set := bson.M{"updated_at": time.Now()}
+	filters := make([]interface{}, 0, len(reference.Entries))
+	for i, entry := range reference.Entries {
+		// an entry without an ID cannot be told apart from the survivor's own entries
+		if entry.EntryID.IsZero() {
+			continue
+		}
+		identifier := fmt.Sprintf("e%d", i)
+		set["exercises.$["+identifier+"].exercise_id"] = entry.ExerciseID
+		filters = append(filters, bson.M{identifier + ".entry_id": entry.EntryID, identifier + ".exercise_id": survivorID})
+	}
+	if len(filters) == 0 {
+		return nil
+	}
+
+	update := bson.M{"$set": set, "$inc": bson.M{"version": 1}}
+	filter := bson.M{"_id": reference.DocumentID, "user_id": userID}
+	if _, err := updateEachWithRevision(ctx, db, reference.Collection, userID, filter, update, filters...); err != nil {
+		return fmt.Errorf("failed to restore %s document: %w", reference.Collection, err)
+	}
+
+	return nil
+}
+
+// names of the duplicates the survivor does not already go by
+func newAliases(survivor *Exercise, duplicates []*Exercise) []string {
+	known := map[string]bool{strings.ToLower(survivor.Name): true}
+	for _, alias := range survivor.Aliases {
+		known[strings.ToLower(alias)] = true
+	}
+
+	var aliases []string
+	for _, duplicate := range duplicates {
+		for _, name := range append([]string{duplicate.Name}, duplicate.Aliases...) {
+			if key := strings.ToLower(name); !known[key] {
+				known[key] = true
+				aliases = append(aliases, name)
+			}
+		}
+	}
+
+	return aliases
+}
@@ -0,0 +1,97 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// a migration brings documents written by an older version up to date. Every
+// migration runs on each start, so it must only touch documents still needing it.
+type migration struct {
+	name string
+	run  func(context.Context, *mongo.Database) (int64, error)
+}
+
+var migrations = []migration{
+	// accounts made before email verification existed have no email_verified field,
+	// they were never asked to verify and keep their access
+	{name: "backfill email_verified", run: func(ctx context.Context, db *mongo.Database) (int64, error) {
+		result, err := db.Collection(userCollection).UpdateMany(ctx,
+			bson.M{"email_verified": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"email_verified": true}})
+		if err != nil {
+			return 0, err
+		}
+		return result.ModifiedCount, nil
+	}},
+	// exercises entries are told apart by entry_id since merges were made undoable
+	{name: "backfill routine entry_id", run: backfillEntryIDs(routineCollection)},
+	{name: "backfill workout entry_id", run: backfillEntryIDs(workoutCollection)},
+}
+
+// Migrate runs every migration, and returns how many documents each one changed
+func Migrate(ctx context.Context, db *mongo.Database) (map[string]int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	changed := make(map[string]int64, len(migrations))
+	for _, m := range migrations {
+		count, err := m.run(ctx, db)
+		if err != nil {
+			return nil, fmt.Errorf("migration %q failed: %w", m.name, err)
+		}
+		changed[m.name] = count
+	}
+
+	return changed, nil
+}
+
+// backfillEntryIDs gives an entry_id to every exercises entry of collection without one
+func backfillEntryIDs(collection string) func(context.Context, *mongo.Database) (int64, error) {
+	return func(ctx context.Context, db *mongo.Database) (int64, error) {
+		missing := bson.M{"$in": bson.A{nil, primitive.NilObjectID}}
+		filter := bson.M{"exercises": bson.M{"$elemMatch": bson.M{"entry_id": missing}}}
+
+		var documents []struct {
+			ID        primitive.ObjectID `bson:"_id"`
+			Exercises []struct {
+				EntryID primitive.ObjectID `bson:"entry_id"`
+			} `bson:"exercises"`
+		}
+		cursor, err := db.Collection(collection).Find(ctx, filter, options.Find().SetProjection(bson.M{"exercises.entry_id": 1}))
+		if err != nil {
+			return 0, err
+		}
+		if err := cursor.All(ctx, &documents); err != nil {
+			return 0, err
+		}
+
+		var changed int64
+		for _, document := range documents {
+			// a document edited in between no longer matches and is picked up on the next start
+			documentFilter := bson.M{"_id": document.ID, "exercises": bson.M{"$size": len(document.Exercises)}}
+			set := bson.M{}
+			for i, entry := range document.Exercises {
+				if entry.EntryID.IsZero() {
+					field := fmt.Sprintf("exercises.%d.entry_id", i)
+					documentFilter[field] = missing
+					set[field] = primitive.NewObjectID()
+				}
+			}
+
+			result, err := db.Collection(collection).UpdateOne(ctx, documentFilter, bson.M{"$set": set})
+			if err != nil {
+				return 0, err
+			}
+			changed += result.ModifiedCount
+		}
+
+		return changed, nil
+	}
+}
@@ -0,0 +1,209 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrTokenReused = errors.New("refresh token has already been used")
+
+// RefreshToken is a single link in a rotation chain. Every token issued from
+// the same login shares a FamilyID, which is what clients see as a session.
+type RefreshToken struct {
+	ID               primitive.ObjectID `bson:"_id" json:"id"`
+	UserID           primitive.ObjectID `bson:"user_id" json:"user_id"`
+	FamilyID         primitive.ObjectID `bson:"family_id" json:"family_id"`
+	TokenHash        string             `bson:"token_hash" json:"-"`
+	UserAgent        string             `bson:"user_agent" json:"user_agent"`
+	IP               string             `bson:"ip" json:"ip"`
+	SessionStartedAt time.Time          `bson:"session_started_at" json:"session_started_at"`
+	ExpiresAt        time.Time          `bson:"expires_at" json:"expires_at"`
+	RotatedAt        *time.Time         `bson:"rotated_at,omitempty" json:"rotated_at,omitempty"`
+	RevokedAt        *time.Time         `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+	CreatedAt        time.Time          `bson:"created_at" json:"created_at"`
+}
+
+type RefreshTokenStore struct {
+	db *mongo.Database
+}
+
+const refreshTokenCollection = "refresh_token"
+
+// creating a refresh token, a zero FamilyID starts a new session
+func (s *RefreshTokenStore) Create(ctx context.Context, token *RefreshToken) error {
+	token.ID = primitive.NewObjectID()
+	token.CreatedAt = time.Now()
+
+	if token.FamilyID.IsZero() {
+		token.FamilyID = token.ID
+		token.SessionStartedAt = token.CreatedAt
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := s.db.Collection(refreshTokenCollection).InsertOne(ctx, token)
+	if err != nil {
+		return fmt.Errorf("failed to insert refresh token: %w", err)
+	}
+
+	return nil
+}
+
+func (s *RefreshTokenStore) GetByHash(ctx context.Context, hash string) (*RefreshToken, error) {
+	token := &RefreshToken{}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	err := s.db.Collection(refreshTokenCollection).FindOne(ctx, bson.M{"token_hash": hash}).Decode(token)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	return token, nil
+}
+
+// Rotate marks the current token as used and stores its replacement in the same family.
+// If the current token was already rotated the caller is replaying it and ErrTokenReused is returned.
+func (s *RefreshTokenStore) Rotate(ctx context.Context, current *RefreshToken, next *RefreshToken) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+
+	filter := bson.M{
+		"_id":        current.ID,
+		"rotated_at": bson.M{"$exists": false},
+		"revoked_at": bson.M{"$exists": false},
+	}
+	update := bson.M{"$set": bson.M{"rotated_at": now}}
+
+	result, err := s.db.Collection(refreshTokenCollection).UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrTokenReused
+	}
+
+	next.ID = primitive.NewObjectID()
+	next.UserID = current.UserID
+	next.FamilyID = current.FamilyID
+	next.SessionStartedAt = current.SessionStartedAt
+	next.CreatedAt = now
+
+	_, err = s.db.Collection(refreshTokenCollection).InsertOne(ctx, next)
+	if err != nil {
+		return fmt.Errorf("failed to insert refresh token: %w", err)
+	}
+
+	return nil
+}
+
+// return the live token of every session the user has
+func (s *RefreshTokenStore) GetActiveSessions(ctx context.Context, userID primitive.ObjectID) ([]*RefreshToken, error) {
+	var tokens []*RefreshToken
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"user_id":    userID,
+		"rotated_at": bson.M{"$exists": false},
+		"revoked_at": bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": time.Now()},
+	}
+
+	opts := options.Find().SetSort(bson.M{"created_at": -1})
+
+	cursor, err := s.db.Collection(refreshTokenCollection).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sessions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &tokens); err != nil {
+		return nil, fmt.Errorf("failed to decode sessions: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// report whether a session was revoked, by the user, a password change or reuse detection
+func (s *RefreshTokenStore) IsSessionRevoked(ctx context.Context, familyID primitive.ObjectID) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"family_id": familyID, "revoked_at": bson.M{"$exists": true}}
+
+	count, err := s.db.Collection(refreshTokenCollection).CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, fmt.Errorf("failed to check session: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+// revoke every token in a family, used when a rotated token is replayed
+func (s *RefreshTokenStore) RevokeFamily(ctx context.Context, familyID primitive.ObjectID) error {
+	return s.revoke(ctx, bson.M{"family_id": familyID})
+}
+
+// revoke a single session belonging to the user
+func (s *RefreshTokenStore) RevokeSession(ctx context.Context, userID, familyID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"user_id":    userID,
+		"family_id":  familyID,
+		"revoked_at": bson.M{"$exists": false},
+	}
+
+	result, err := s.db.Collection(refreshTokenCollection).UpdateMany(ctx, filter, bson.M{"$set": bson.M{"revoked_at": time.Now()}})
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// revoke every session the user has
+func (s *RefreshTokenStore) RevokeAllForUser(ctx context.Context, userID primitive.ObjectID) error {
+	return s.revoke(ctx, bson.M{"user_id": userID})
+}
+
+// revoke every session except the one the request came from
+func (s *RefreshTokenStore) RevokeOtherSessions(ctx context.Context, userID, keepFamilyID primitive.ObjectID) error {
+	return s.revoke(ctx, bson.M{"user_id": userID, "family_id": bson.M{"$ne": keepFamilyID}})
+}
+
+func (s *RefreshTokenStore) revoke(ctx context.Context, filter bson.M) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter["revoked_at"] = bson.M{"$exists": false}
+
+	_, err := s.db.Collection(refreshTokenCollection).UpdateMany(ctx, filter, bson.M{"$set": bson.M{"revoked_at": time.Now()}})
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,205 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	RevisionResourceExercise = "exercise"
+	RevisionResourceRoutine  = "routine"
+)
+
+// ErrStaleRevision is returned when restoring a revision that uses exercises that are gone
+var ErrStaleRevision = errors.New("revision uses exercises that no longer exist")
+
+// Revision is a document as it was before one of its updates, with what the update changed
+type Revision struct {
+	ID           primitive.ObjectID `bson:"_id" json:"id"`
+	UserID       primitive.ObjectID `bson:"user_id" json:"user_id"`
+	ResourceType string             `bson:"resource_type" json:"resource_type"` // exercise or routine
+	ResourceID   primitive.ObjectID `bson:"resource_id" json:"resource_id"`
+	Version      int16              `bson:"version" json:"version"` // the version the update replaced
+	Snapshot     bson.Raw           `bson:"snapshot" json:"-"`      // the whole document at Version
+	Changes      map[string]Change  `bson:"changes" json:"changes"` // field by field, from Version to the next
+	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// revisioned is a document that keeps revisions
+type revisioned interface {
+	revisionOf() (resourceType string, resourceID primitive.ObjectID, version int16)
+}
+
+func (e *Exercise) revisionOf() (string, primitive.ObjectID, int16) {
+	return RevisionResourceExercise, e.ID, e.Version
+}
+
+func (r *Routine) revisionOf() (string, primitive.ObjectID, int16) {
+	return RevisionResourceRoutine, r.ID, r.Version
+}
+
+type RevisionStore struct {
+	db *mongo.Database
+}
+
+const revisionCollection = "revision"
+
+var revisionListSpec = listSpec{
+	sortFields: map[string]string{
+		"version":    "version",
+		"created_at": "created_at",
+	},
+	defaultSort: "-version",
+}
+
+// List the revisions of one of the user's exercises or routines, newest first by default
+func (s *RevisionStore) List(ctx context.Context, resourceType string, resourceID, userID primitive.ObjectID, query ListQuery) (*Page[*Revision], error) {
+	filter := bson.M{"resource_type": resourceType, "resource_id": resourceID, "user_id": userID}
+	return findPage[*Revision](ctx, s.db.Collection(revisionCollection), filter, query, revisionListSpec)
+}
+
+// updateWithRevision applies update to the document matching filter and records the
+// revision it replaced, both in one transaction. before and after are filled with the
+// document on each side of the update. It returns mongo.ErrNoDocuments when nothing
+// matches filter.
+func updateWithRevision(ctx context.Context, db *mongo.Database, collection string, userID primitive.ObjectID, filter, update bson.M, before, after revisioned) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	session, err := db.Client().StartSession()
+	if err != nil {
+		return fmt.Errorf("failed to start session: %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		return nil, applyWithRevision(sessCtx, db, collection, userID, filter, update, before, after)
+	})
+	return err
+}
+
+// applyWithRevision is updateWithRevision inside a transaction the caller runs.
+// arrayFilters are for updates of exercises.$[identifier] entries, nil otherwise.
+func applyWithRevision(ctx mongo.SessionContext, db *mongo.Database, collection string, userID primitive.ObjectID, filter, update bson.M, before, after revisioned, arrayFilters ...interface{}) error {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.Before)
+	if len(arrayFilters) > 0 {
+		opts.SetArrayFilters(options.ArrayFilters{Filters: arrayFilters})
+	}
+
+	raw, err := db.Collection(collection).FindOneAndUpdate(ctx, filter, update, opts).Raw()
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return err
+		}
+		return fmt.Errorf("failed to update %s: %w", collection, err)
+	}
+	if err := bson.Unmarshal(raw, before); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", collection, err)
+	}
+
+	resourceType, resourceID, version := before.revisionOf()
+	if err := db.Collection(collection).FindOne(ctx, bson.M{"_id": resourceID}).Decode(after); err != nil {
+		return fmt.Errorf("failed to fetch updated %s: %w", collection, err)
+	}
+
+	changes, err := Diff(before, after)
+	if err != nil {
+		return err
+	}
+
+	revision := &Revision{
+		ID:           primitive.NewObjectID(),
+		UserID:       userID,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		Version:      version,
+		Snapshot:     raw,
+		Changes:      changes,
+		CreatedAt:    time.Now(),
+	}
+	if _, err := db.Collection(revisionCollection).InsertOne(ctx, revision); err != nil {
+		return fmt.Errorf("failed to record revision: %w", err)
+	}
+
+	return nil
+}
+
+// documents of the collections that keep revisions, to decode them into
+var revisionedCollections = map[string]func() revisioned{
+	exerciseCollection: func() revisioned { return &Exercise{} },
+	routineCollection:  func() revisioned { return &Routine{} },
+}
+
+// updateEachWithRevision applies update to every document of collection matching
+// filter, inside a transaction the caller runs, and returns how many it changed.
+// Documents of collections that keep revisions are updated one at a time so each
+// gets its revision, the others all at once.
+func updateEachWithRevision(ctx mongo.SessionContext, db *mongo.Database, collection string, userID primitive.ObjectID, filter, update bson.M, arrayFilters ...interface{}) (int64, error) {
+	newDocument, ok := revisionedCollections[collection]
+	if !ok {
+		opts := options.Update()
+		if len(arrayFilters) > 0 {
+			opts.SetArrayFilters(options.ArrayFilters{Filters: arrayFilters})
+		}
+		result, err := db.Collection(collection).UpdateMany(ctx, filter, update, opts)
+		if err != nil {
+			return 0, fmt.Errorf("failed to update %s documents: %w", collection, err)
+		}
+		return result.ModifiedCount, nil
+	}
+
+	var documents []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	cursor, err := db.Collection(collection).Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch %s documents: %w", collection, err)
+	}
+	if err := cursor.All(ctx, &documents); err != nil {
+		return 0, fmt.Errorf("failed to decode %s documents: %w", collection, err)
+	}
+
+	for _, document := range documents {
+		err := applyWithRevision(ctx, db, collection, userID, bson.M{"_id": document.ID}, update, newDocument(), newDocument(), arrayFilters...)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return int64(len(documents)), nil
+}
+
+// revisionSnapshot decodes the document of one revision into snapshot
+func revisionSnapshot(ctx context.Context, db *mongo.Database, resourceType string, resourceID, userID primitive.ObjectID, version int16, snapshot interface{}) error {
+	filter := bson.M{"resource_type": resourceType, "resource_id": resourceID, "user_id": userID, "version": version}
+
+	revision := &Revision{}
+	if err := db.Collection(revisionCollection).FindOne(ctx, filter).Decode(revision); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("failed to fetch revision: %w", err)
+	}
+
+	if err := bson.Unmarshal(revision.Snapshot, snapshot); err != nil {
+		return fmt.Errorf("failed to decode revision: %w", err)
+	}
+
+	return nil
+}
+
+// deleteRevisions removes the revisions of a document that is gone
+func deleteRevisions(ctx context.Context, db *mongo.Database, resourceType string, resourceID, userID primitive.ObjectID) error {
+	filter := bson.M{"resource_type": resourceType, "resource_id": resourceID, "user_id": userID}
+	if _, err := db.Collection(revisionCollection).DeleteMany(ctx, filter); err != nil {
+		return fmt.Errorf("failed to delete revisions: %w", err)
+	}
+	return nil
+}
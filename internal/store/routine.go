@@ -2,6 +2,7 @@ package store
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -22,15 +23,15 @@ type Routine struct {
 }
 
 type RoutineExercise struct {
+	EntryID    primitive.ObjectID `bson:"entry_id" json:"entry_id"` // set by the store, unlike order it never repeats or changes
 	ExerciseID primitive.ObjectID `bson:"exercise_id" json:"exercise_id"`
 	Order      int                `bson:"order" json:"order"`
 	Sets       []TemplateSet      `bson:"template_sets" json:"template_sets"`
 }
 
 type TemplateSet struct {
-	Weight    float32 `bson:"weight" json:"weight"`
-	Reps      int16   `bson:"reps" json:"reps"`
-	SetNumber int16   `bson:"set_number" json:"set_number"`
+	SetMetrics `bson:",inline"`
+	SetNumber  int16 `bson:"set_number" json:"set_number"`
 }
 
 type RoutineStore struct {
@@ -64,6 +65,7 @@ func (s *RoutineStore) Create(ctx context.Context, routine *Routine, userID prim
 
 	// setting the order if not provided
 	for i := range routine.Exercises {
+		routine.Exercises[i].EntryID = primitive.NewObjectID()
 		if routine.Exercises[i].Order == 0 {
 			routine.Exercises[i].Order = i
 		}
@@ -129,11 +131,22 @@ func (s *RoutineStore) GetAllUserRoutines(ctx context.Context, userID primitive.
 	return routines, nil
 }
 
+var routineListSpec = listSpec{
+	sortFields: map[string]string{
+		"title":      "title",
+		"created_at": "created_at",
+		"updated_at": "updated_at",
+	},
+	defaultSort: "-updated_at",
+}
+
+// one page of the user's routines
+func (s *RoutineStore) List(ctx context.Context, userID primitive.ObjectID, query ListQuery) (*Page[*Routine], error) {
+	return findPage[*Routine](ctx, s.db.Collection(routineCollection), bson.M{"user_id": userID}, query, routineListSpec)
+}
+
 // update a routine
 func (s *RoutineStore) Update(ctx context.Context, routineID, userID primitive.ObjectID, updates map[string]interface{}, expectedVersion int16) error {
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
-
 	filter := bson.M{
 		"_id":     routineID,
 		"user_id": userID,
@@ -152,15 +165,14 @@ func (s *RoutineStore) Update(ctx context.Context, routineID, userID primitive.O
 		"$inc": bson.M{"version": 1},
 	}
 
-	result, err := s.db.Collection(routineCollection).UpdateOne(ctx, filter, update)
+	err := updateWithRevision(ctx, s.db, routineCollection, userID, filter, update, &Routine{}, &Routine{})
 	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return fmt.Errorf("no routine found with ID %s", routineID.Hex())
+		}
 		return fmt.Errorf("failed to update routine: %w", err)
 	}
 
-	if result.ModifiedCount == 0 {
-		return fmt.Errorf("no routine found with ID %s", routineID.Hex())
-	}
-
 	return nil
 }
 
@@ -192,6 +204,7 @@ func (s *RoutineStore) AddExerciseToRoutine(ctx context.Context, routineID, user
 
 	// Create new routine exercise
 	newExercise := RoutineExercise{
+		EntryID:    primitive.NewObjectID(),
 		ExerciseID: exerciseID,
 		Order:      nextOrder,
 		Sets:       templateSets,
@@ -209,23 +222,19 @@ func (s *RoutineStore) AddExerciseToRoutine(ctx context.Context, routineID, user
 		"$inc":  bson.M{"version": 1},
 	}
 
-	result, err := s.db.Collection(routineCollection).UpdateOne(ctx, filter, update)
+	err = updateWithRevision(ctx, s.db, routineCollection, userID, filter, update, &Routine{}, &Routine{})
 	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return fmt.Errorf("no routine found with ID %s or version mismatch", routineID.Hex())
+		}
 		return fmt.Errorf("failed to add exercise to routine: %w", err)
 	}
 
-	if result.ModifiedCount == 0 {
-		return fmt.Errorf("no routine found with ID %s or version mismatch", routineID.Hex())
-	}
-
 	return nil
 }
 
 // update an exercise in a routine
 func (s *RoutineStore) UpdateExerciseInRoutine(ctx context.Context, routineID, userID, exerciseID primitive.ObjectID, templateSets []TemplateSet, expectedVersion int16) error {
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
-
 	// Set numbers for the sets
 	for i := range templateSets {
 		if templateSets[i].SetNumber == 0 {
@@ -248,23 +257,19 @@ func (s *RoutineStore) UpdateExerciseInRoutine(ctx context.Context, routineID, u
 		"$inc": bson.M{"version": 1},
 	}
 
-	result, err := s.db.Collection(routineCollection).UpdateOne(ctx, filter, update)
+	err := updateWithRevision(ctx, s.db, routineCollection, userID, filter, update, &Routine{}, &Routine{})
 	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return fmt.Errorf("no routine or exercise found with the given IDs or version mismatch")
+		}
 		return fmt.Errorf("failed to update exercise in routine: %w", err)
 	}
 
-	if result.ModifiedCount == 0 {
-		return fmt.Errorf("no routine or exercise found with the given IDs or version mismatch")
-	}
-
 	return nil
 }
 
 // remove exercise from a routine
 func (s *RoutineStore) RemoveExerciseFromRoutine(ctx context.Context, routineID, userID, exerciseID primitive.ObjectID, expectedVersion int16) error {
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
-
 	filter := bson.M{
 		"_id":     routineID,
 		"user_id": userID,
@@ -277,16 +282,90 @@ func (s *RoutineStore) RemoveExerciseFromRoutine(ctx context.Context, routineID,
 		"$inc":  bson.M{"version": 1},
 	}
 
-	result, err := s.db.Collection(routineCollection).UpdateOne(ctx, filter, update)
+	err := updateWithRevision(ctx, s.db, routineCollection, userID, filter, update, &Routine{}, &Routine{})
 	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return fmt.Errorf("no routine found with ID %s or version mismatch", routineID.Hex())
+		}
 		return fmt.Errorf("failed to remove exercise from routine: %w", err)
 	}
 
-	if result.ModifiedCount == 0 {
-		return fmt.Errorf("no routine found with ID %s or version mismatch", routineID.Hex())
+	return nil
+}
+
+// Restore puts a routine's title, description and exercises back to how they were at
+// an earlier version. Exercises deleted since then make the revision stale. The
+// restore is an update of its own, with its own revision.
+func (s *RoutineStore) Restore(ctx context.Context, routineID, userID primitive.ObjectID, version, expectedVersion int16) (*Routine, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	session, err := s.db.Client().StartSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start session: %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	after := &Routine{}
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		snapshot := &Routine{}
+		if err := revisionSnapshot(sessCtx, s.db, RevisionResourceRoutine, routineID, userID, version, snapshot); err != nil {
+			return nil, err
+		}
+
+		// a routine can list the same exercise more than once
+		seen := make(map[primitive.ObjectID]bool, len(snapshot.Exercises))
+		exerciseIDs := make([]primitive.ObjectID, 0, len(snapshot.Exercises))
+		for _, exercise := range snapshot.Exercises {
+			if !seen[exercise.ExerciseID] {
+				seen[exercise.ExerciseID] = true
+				exerciseIDs = append(exerciseIDs, exercise.ExerciseID)
+			}
+		}
+		found, err := s.db.Collection(exerciseCollection).CountDocuments(sessCtx, bson.M{
+			"_id": bson.M{"$in": exerciseIDs},
+			"$or": bson.A{bson.M{"user_id": userID}, bson.M{"is_custom": false}},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to check routine exercises: %w", err)
+		}
+		if found != int64(len(exerciseIDs)) {
+			return nil, ErrStaleRevision
+		}
+
+		// revisions from before entries had IDs
+		for i := range snapshot.Exercises {
+			if snapshot.Exercises[i].EntryID.IsZero() {
+				snapshot.Exercises[i].EntryID = primitive.NewObjectID()
+			}
+		}
+
+		filter := bson.M{
+			"_id":     routineID,
+			"user_id": userID,
+			"version": expectedVersion,
+		}
+		update := bson.M{
+			"$set": bson.M{
+				"title":       snapshot.Title,
+				"description": snapshot.Description,
+				"exercises":   snapshot.Exercises,
+				"updated_at":  time.Now(),
+			},
+			"$inc": bson.M{"version": 1},
+		}
+
+		err = applyWithRevision(sessCtx, s.db, routineCollection, userID, filter, update, &Routine{}, after)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrVersionMismatch
+		}
+		return nil, err
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return after, nil
 }
 
 // Delete a routine
@@ -315,5 +394,18 @@ func (s *RoutineStore) Delete(ctx context.Context, routineID primitive.ObjectID,
 		return fmt.Errorf("failed to remove routine from user's routines array: %w", err)
 	}
 
-	return nil
+	return deleteRevisions(ctx, s.db, RevisionResourceRoutine, routineID, userID)
+}
+
+// counting all routines in the system
+func (s *RoutineStore) Count(ctx context.Context) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	count, err := s.db.Collection(routineCollection).CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count routines: %w", err)
+	}
+
+	return count, nil
 }
@@ -0,0 +1,53 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	SecurityEventLoginFailed       = "login_failed"
+	SecurityEventLoginBlocked      = "login_blocked"
+	SecurityEventAccountLocked     = "account_locked"
+	SecurityEventMFAFailed         = "mfa_failed"
+	SecurityEventRateLimited       = "rate_limited"
+	SecurityEventRefreshTokenReuse = "refresh_token_reuse"
+)
+
+// SecurityEvent is an entry in the security log, written for failed and blocked authentication attempts
+type SecurityEvent struct {
+	ID        primitive.ObjectID     `bson:"_id" json:"id"`
+	Type      string                 `bson:"type" json:"type"`
+	UserID    *primitive.ObjectID    `bson:"user_id,omitempty" json:"user_id,omitempty"`
+	Email     string                 `bson:"email,omitempty" json:"email,omitempty"`
+	IP        string                 `bson:"ip" json:"ip"`
+	UserAgent string                 `bson:"user_agent" json:"user_agent"`
+	Details   map[string]interface{} `bson:"details,omitempty" json:"details,omitempty"`
+	CreatedAt time.Time              `bson:"created_at" json:"created_at"`
+}
+
+type SecurityEventStore struct {
+	db *mongo.Database
+}
+
+const securityEventCollection = "security_log"
+
+// writing a security event
+func (s *SecurityEventStore) Create(ctx context.Context, event *SecurityEvent) error {
+	event.ID = primitive.NewObjectID()
+	event.CreatedAt = time.Now()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := s.db.Collection(securityEventCollection).InsertOne(ctx, event)
+	if err != nil {
+		return fmt.Errorf("failed to insert security event: %w", err)
+	}
+
+	return nil
+}
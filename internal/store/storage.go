@@ -23,40 +23,148 @@ type Storage struct {
 		Create(context.Context, *User) error
 		GetByID(context.Context, primitive.ObjectID) (*User, error)
 		Update(context.Context, primitive.ObjectID, map[string]interface{}, int16) error
+		SetPendingEmail(context.Context, primitive.ObjectID, string) error
+		ClearPendingEmail(context.Context, primitive.ObjectID) error
+		VerifyEmail(context.Context, primitive.ObjectID, string) error
+		UpdatePassword(context.Context, primitive.ObjectID, []byte) (time.Time, error)
+		SetTOTPSecret(context.Context, primitive.ObjectID, string) error
+		EnableTOTP(context.Context, primitive.ObjectID, int64, []string) error
+		DisableTOTP(context.Context, primitive.ObjectID) error
+		UseTOTPStep(context.Context, primitive.ObjectID, int64) error
+		UseRecoveryCode(context.Context, primitive.ObjectID, string) error
+		IncrementFailedLogins(context.Context, primitive.ObjectID) (int, error)
+		LockUntil(context.Context, primitive.ObjectID, time.Time) error
+		ResetFailedLogins(context.Context, primitive.ObjectID) error
+		List(context.Context, UserFilter) ([]*User, int64, error)
+		Count(context.Context) (int64, error)
+		SetRole(context.Context, primitive.ObjectID, string) error
+		SetDisabled(context.Context, primitive.ObjectID, bool) error
+		ForcePasswordReset(context.Context, primitive.ObjectID) error
+		ScheduleDeletion(context.Context, primitive.ObjectID, time.Time) error
+		CancelDeletion(context.Context, primitive.ObjectID) error
+		GetDueForDeletion(context.Context, time.Time, int64) ([]primitive.ObjectID, error)
+		Delete(context.Context, primitive.ObjectID, time.Time) error
+	}
+	RefreshTokens interface {
+		Create(context.Context, *RefreshToken) error
+		GetByHash(context.Context, string) (*RefreshToken, error)
+		Rotate(context.Context, *RefreshToken, *RefreshToken) error
+		GetActiveSessions(context.Context, primitive.ObjectID) ([]*RefreshToken, error)
+		RevokeFamily(context.Context, primitive.ObjectID) error
+		RevokeSession(context.Context, primitive.ObjectID, primitive.ObjectID) error
+		IsSessionRevoked(context.Context, primitive.ObjectID) (bool, error)
+		RevokeAllForUser(context.Context, primitive.ObjectID) error
+		RevokeOtherSessions(context.Context, primitive.ObjectID, primitive.ObjectID) error
+	}
+	UserTokens interface {
+		Create(context.Context, *UserToken) error
+		Consume(context.Context, string, string) (*UserToken, error)
+		DeleteAllForUser(context.Context, primitive.ObjectID, string) error
+	}
+	APIKeys interface {
+		Create(context.Context, *APIKey) error
+		GetAllUserKeys(context.Context, primitive.ObjectID) ([]*APIKey, error)
+		GetByID(context.Context, primitive.ObjectID, primitive.ObjectID) (*APIKey, error)
+		GetByHash(context.Context, string) (*APIKey, error)
+		Update(context.Context, primitive.ObjectID, primitive.ObjectID, map[string]interface{}, int16) error
+		TouchLastUsed(context.Context, primitive.ObjectID) error
+		Delete(context.Context, primitive.ObjectID, primitive.ObjectID) error
+		DeleteAllForUser(context.Context, primitive.ObjectID) error
+	}
+	SecurityEvents interface {
+		Create(context.Context, *SecurityEvent) error
+	}
+	Jobs interface {
+		Create(context.Context, *Job) error
+		GetByID(context.Context, primitive.ObjectID, primitive.ObjectID) (*Job, error)
+		GetActive(context.Context, primitive.ObjectID, string) (*Job, error)
+		ClaimNext(context.Context, string) (*Job, error)
+		RequeueRunning(context.Context, string) error
+		Complete(context.Context, primitive.ObjectID, string, int64, time.Time) error
+		Fail(context.Context, primitive.ObjectID, string) error
+		GetExpired(context.Context, time.Time) ([]*Job, error)
 		Delete(context.Context, primitive.ObjectID) error
 	}
+	Audit interface {
+		Create(context.Context, *AuditEntry) error
+		List(context.Context, AuditFilter) ([]*AuditEntry, int64, error)
+	}
+	Media interface {
+		Create(context.Context, *ExerciseMedia) error
+		GetByExercise(context.Context, primitive.ObjectID, primitive.ObjectID) ([]*ExerciseMedia, error)
+		GetAllUserMedia(context.Context, primitive.ObjectID) ([]*ExerciseMedia, error)
+		GetByID(context.Context, primitive.ObjectID, primitive.ObjectID, primitive.ObjectID) (*ExerciseMedia, error)
+		CountByExercise(context.Context, primitive.ObjectID, primitive.ObjectID) (int64, error)
+		Delete(context.Context, primitive.ObjectID, primitive.ObjectID) error
+	}
+	Merges interface {
+		Merge(context.Context, primitive.ObjectID, primitive.ObjectID, []primitive.ObjectID) (*ExerciseMerge, error)
+		Undo(context.Context, primitive.ObjectID, primitive.ObjectID) (*ExerciseMerge, error)
+		GetAllUserMerges(context.Context, primitive.ObjectID) ([]*ExerciseMerge, error)
+	}
+	Revisions interface {
+		List(context.Context, string, primitive.ObjectID, primitive.ObjectID, ListQuery) (*Page[*Revision], error)
+	}
+	Imports interface {
+		Apply(context.Context, primitive.ObjectID, *ImportSet) error
+	}
 	Routine interface {
 		Create(context.Context, *Routine, primitive.ObjectID) error
 		GetAllUserRoutines(context.Context, primitive.ObjectID) ([]*Routine, error)
+		List(context.Context, primitive.ObjectID, ListQuery) (*Page[*Routine], error)
 		GetByID(context.Context, primitive.ObjectID, primitive.ObjectID) (*Routine, error)
 		Update(context.Context, primitive.ObjectID, primitive.ObjectID, map[string]interface{}, int16) error
 		AddExerciseToRoutine(context.Context, primitive.ObjectID, primitive.ObjectID, primitive.ObjectID, []TemplateSet, int16) error
 		UpdateExerciseInRoutine(context.Context, primitive.ObjectID, primitive.ObjectID, primitive.ObjectID, []TemplateSet, int16) error
 		RemoveExerciseFromRoutine(context.Context, primitive.ObjectID, primitive.ObjectID, primitive.ObjectID, int16) error
+		Restore(context.Context, primitive.ObjectID, primitive.ObjectID, int16, int16) (*Routine, error)
 		Delete(context.Context, primitive.ObjectID, primitive.ObjectID) error
+		Count(context.Context) (int64, error)
 	}
 	Exercise interface {
 		Create(context.Context, *Exercise, primitive.ObjectID) error
 		GetAllUserExercises(context.Context, primitive.ObjectID) ([]*Exercise, error)
+		List(context.Context, primitive.ObjectID, bool, ListQuery) (*Page[*Exercise], error)
 		GetByID(context.Context, primitive.ObjectID, primitive.ObjectID) (*Exercise, error)
+		GetDependents(context.Context, primitive.ObjectID, primitive.ObjectID) (*ExerciseDependents, error)
 		SearchExerciseByID(context.Context, primitive.ObjectID) (*Exercise, error)
+		GetCatalogIDs(context.Context, []primitive.ObjectID) (map[primitive.ObjectID]bool, error)
+		GetCatalog(context.Context, CatalogFilter, ListQuery) (*Page[*Exercise], error)
+		UpsertCatalog(context.Context, []*Exercise) (int64, int64, error)
+		Search(context.Context, ExerciseSearch) (*ExerciseSearchResult, error)
+		GetMatchCandidates(context.Context, primitive.ObjectID) ([]*Exercise, error)
+		Fork(context.Context, *Exercise, primitive.ObjectID, bool) (*Exercise, *ForkResult, error)
 		Update(context.Context, primitive.ObjectID, primitive.ObjectID, map[string]interface{}, int16) error
-		Delete(context.Context, primitive.ObjectID, primitive.ObjectID) error
+		Restore(context.Context, primitive.ObjectID, primitive.ObjectID, int16, int16) (*Exercise, error)
+		Delete(context.Context, primitive.ObjectID, primitive.ObjectID, string) (*ExerciseDependents, error)
+		Count(context.Context) (int64, error)
 	}
 	WorkoutSession interface {
 		Create(context.Context, *WorkoutSession, primitive.ObjectID) error
 		CreateFromRoutine(context.Context, primitive.ObjectID, primitive.ObjectID) (*WorkoutSession, error)
 		GetAllUserSessions(context.Context, primitive.ObjectID) ([]*WorkoutSession, error)
+		List(context.Context, primitive.ObjectID, WorkoutFilter, ListQuery) (*Page[*WorkoutSession], error)
 		GetByID(context.Context, primitive.ObjectID, primitive.ObjectID) (*WorkoutSession, error)
 		AddSetToExercise(context.Context, primitive.ObjectID, primitive.ObjectID, primitive.ObjectID, SessionSet) error
 		CompleteWorkout(context.Context, primitive.ObjectID, primitive.ObjectID) error
 		Delete(context.Context, primitive.ObjectID, primitive.ObjectID) error
+		Count(context.Context) (int64, error)
 	}
 }
 
 func NewMongoDBStorage(db *mongo.Database) Storage {
 	return Storage{
 		Users:          &UserStore{db},
+		RefreshTokens:  &RefreshTokenStore{db},
+		UserTokens:     &UserTokenStore{db},
+		APIKeys:        &APIKeyStore{db},
+		SecurityEvents: &SecurityEventStore{db},
+		Audit:          &AuditStore{db},
+		Jobs:           &JobStore{db},
+		Imports:        &ImportStore{db},
+		Media:          &MediaStore{db},
+		Merges:         &MergeStore{db},
+		Revisions:      &RevisionStore{db},
 		Routine:        &RoutineStore{db},
 		Exercise:       &ExerciseStore{db},
 		WorkoutSession: &WorkoutSessionStore{db},
@@ -0,0 +1,132 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// TrackingType is what a set of an exercise records
+type TrackingType string
+
+const (
+	TrackingWeightReps         TrackingType = "weight_reps"         // barbell bench press: weight and reps
+	TrackingBodyweightReps     TrackingType = "bodyweight_reps"     // push up: reps only
+	TrackingWeightedBodyweight TrackingType = "weighted_bodyweight" // weighted dip: reps with added weight
+	TrackingAssistedBodyweight TrackingType = "assisted_bodyweight" // assisted pull up: reps with assist weight
+	TrackingDuration           TrackingType = "duration"            // plank: time
+	TrackingDistanceDuration   TrackingType = "distance_duration"   // run or row: distance and time
+	TrackingWeightDistance     TrackingType = "weight_distance"     // farmer carry: weight and distance
+)
+
+// DefaultTrackingType is used for exercises saved before tracking types existed
+const DefaultTrackingType = TrackingWeightReps
+
+var TrackingTypes = []TrackingType{
+	TrackingWeightReps,
+	TrackingBodyweightReps,
+	TrackingWeightedBodyweight,
+	TrackingAssistedBodyweight,
+	TrackingDuration,
+	TrackingDistanceDuration,
+	TrackingWeightDistance,
+}
+
+// ErrInvalidSet is returned for a set that does not fit its exercise's tracking type
+var ErrInvalidSet = errors.New("invalid set")
+
+func (t TrackingType) Valid() bool {
+	for _, known := range TrackingTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// SetMetrics are the measurements of a set, which ones are used depends on the
+// exercise's tracking type and the others stay zero
+type SetMetrics struct {
+	Weight          float32 `bson:"weight" json:"weight"`
+	Reps            int16   `bson:"reps" json:"reps"`
+	DurationSeconds int32   `bson:"duration_seconds,omitempty" json:"duration_seconds,omitempty"`
+	DistanceMeters  float32 `bson:"distance_meters,omitempty" json:"distance_meters,omitempty"`
+	AddedWeight     float32 `bson:"added_weight,omitempty" json:"added_weight,omitempty"`   // on top of bodyweight
+	AssistWeight    float32 `bson:"assist_weight,omitempty" json:"assist_weight,omitempty"` // taken off bodyweight
+}
+
+// JSON names of the SetMetrics fields, in the order errors report them
+var setMetricNames = []string{"weight", "reps", "duration_seconds", "distance_meters", "added_weight", "assist_weight"}
+
+// Validate checks the set has the measurements its tracking type needs and none it does not use
+func (m SetMetrics) Validate(t TrackingType) error {
+	if t == "" {
+		t = DefaultTrackingType
+	}
+
+	values := map[string]float64{
+		"weight":           float64(m.Weight),
+		"reps":             float64(m.Reps),
+		"duration_seconds": float64(m.DurationSeconds),
+		"distance_meters":  float64(m.DistanceMeters),
+		"added_weight":     float64(m.AddedWeight),
+		"assist_weight":    float64(m.AssistWeight),
+	}
+	for _, name := range setMetricNames {
+		if values[name] < 0 {
+			return fmt.Errorf("%w: %s cannot be negative", ErrInvalidSet, name)
+		}
+	}
+
+	var required, optional []string
+	switch t {
+	case TrackingWeightReps:
+		// an empty bar or a warm up may be logged with no weight
+		required, optional = []string{"reps"}, []string{"weight"}
+	case TrackingBodyweightReps:
+		required = []string{"reps"}
+	case TrackingWeightedBodyweight:
+		required = []string{"reps", "added_weight"}
+	case TrackingAssistedBodyweight:
+		required = []string{"reps", "assist_weight"}
+	case TrackingDuration:
+		required = []string{"duration_seconds"}
+	case TrackingDistanceDuration:
+		required, optional = []string{"distance_meters"}, []string{"duration_seconds"}
+	case TrackingWeightDistance:
+		required, optional = []string{"weight", "distance_meters"}, []string{"duration_seconds"}
+	default:
+		return fmt.Errorf("%w: unknown tracking type %q", ErrInvalidSet, t)
+	}
+
+	used := make(map[string]bool, len(required)+len(optional))
+	for _, name := range required {
+		if values[name] == 0 {
+			return fmt.Errorf("%w: %s is required for %s exercises", ErrInvalidSet, name, t)
+		}
+		used[name] = true
+	}
+	for _, name := range optional {
+		used[name] = true
+	}
+
+	var unused []string
+	for _, name := range setMetricNames {
+		if !used[name] && values[name] != 0 {
+			unused = append(unused, name)
+		}
+	}
+	if len(unused) > 0 {
+		return fmt.Errorf("%w: %s not used for %s exercises", ErrInvalidSet, strings.Join(unused, ", "), t)
+	}
+
+	return nil
+}
+
+// Tracking is the exercise's tracking type, DefaultTrackingType when it has none
+func (e *Exercise) Tracking() TrackingType {
+	if e.TrackingType == "" {
+		return DefaultTrackingType
+	}
+	return e.TrackingType
+}
@@ -0,0 +1,72 @@
+package store
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSetMetricsValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		tracking TrackingType
+		metrics  SetMetrics
+		wantErr  string // part of the error, empty when the set is valid
+	}{
+		{"weight and reps", TrackingWeightReps, SetMetrics{Weight: 100, Reps: 5}, ""},
+		{"empty bar", TrackingWeightReps, SetMetrics{Reps: 10}, ""},
+		{"no tracking type is weight and reps", "", SetMetrics{Weight: 60, Reps: 8}, ""},
+		{"weight and reps without reps", TrackingWeightReps, SetMetrics{Weight: 100}, "reps is required"},
+		{"weight and reps with distance", TrackingWeightReps, SetMetrics{Weight: 100, Reps: 5, DistanceMeters: 10}, "distance_meters not used"},
+
+		{"bodyweight reps", TrackingBodyweightReps, SetMetrics{Reps: 20}, ""},
+		{"bodyweight reps with weight", TrackingBodyweightReps, SetMetrics{Weight: 10, Reps: 20}, "weight not used"},
+
+		{"weighted bodyweight", TrackingWeightedBodyweight, SetMetrics{Reps: 8, AddedWeight: 20}, ""},
+		{"weighted bodyweight without added weight", TrackingWeightedBodyweight, SetMetrics{Reps: 8}, "added_weight is required"},
+
+		{"assisted bodyweight", TrackingAssistedBodyweight, SetMetrics{Reps: 6, AssistWeight: 30}, ""},
+		{"assisted bodyweight without assist weight", TrackingAssistedBodyweight, SetMetrics{Reps: 6}, "assist_weight is required"},
+		{"assisted bodyweight with added weight", TrackingAssistedBodyweight, SetMetrics{Reps: 6, AssistWeight: 30, AddedWeight: 5}, "added_weight not used"},
+
+		{"duration", TrackingDuration, SetMetrics{DurationSeconds: 60}, ""},
+		{"duration without time", TrackingDuration, SetMetrics{}, "duration_seconds is required"},
+		{"duration with reps and weight", TrackingDuration, SetMetrics{DurationSeconds: 60, Reps: 1, Weight: 5}, "weight, reps not used"},
+
+		{"distance", TrackingDistanceDuration, SetMetrics{DistanceMeters: 5000}, ""},
+		{"distance and duration", TrackingDistanceDuration, SetMetrics{DistanceMeters: 5000, DurationSeconds: 1500}, ""},
+		{"distance without distance", TrackingDistanceDuration, SetMetrics{DurationSeconds: 1500}, "distance_meters is required"},
+
+		{"weight and distance", TrackingWeightDistance, SetMetrics{Weight: 32, DistanceMeters: 40}, ""},
+		{"weight and distance with duration", TrackingWeightDistance, SetMetrics{Weight: 32, DistanceMeters: 40, DurationSeconds: 45}, ""},
+		{"weight and distance without weight", TrackingWeightDistance, SetMetrics{DistanceMeters: 40}, "weight is required"},
+
+		{"negative weight", TrackingWeightReps, SetMetrics{Weight: -5, Reps: 5}, "weight cannot be negative"},
+		{"negative reps", TrackingBodyweightReps, SetMetrics{Reps: -1}, "reps cannot be negative"},
+		{"negative duration", TrackingDuration, SetMetrics{DurationSeconds: -30}, "duration_seconds cannot be negative"},
+		{"negative unused field", TrackingBodyweightReps, SetMetrics{Reps: 5, AssistWeight: -10}, "assist_weight cannot be negative"},
+
+		{"unknown tracking type", TrackingType("laps"), SetMetrics{Reps: 5}, "unknown tracking type"},
+	}
+
+	for _, tt := range tests {
+		err := tt.metrics.Validate(tt.tracking)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: Validate = %v, want nil", tt.name, err)
+			}
+			continue
+		}
+
+		if err == nil {
+			t.Errorf("%s: Validate = nil, want an error containing %q", tt.name, tt.wantErr)
+			continue
+		}
+		if !errors.Is(err, ErrInvalidSet) {
+			t.Errorf("%s: Validate = %v, want ErrInvalidSet", tt.name, err)
+		}
+		if !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: Validate = %v, want an error containing %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -4,27 +4,59 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"regexp"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type User struct {
-	ID        primitive.ObjectID `bson:"_id" json:"id"`
-	Username  string             `bson:"username" json:"username"`
-	Email     string             `bson:"email" json:"email"`
-	Password  []byte             `bson:"password_hash" json:"-"`
-	FirstName string             `bson:"first_name" json:"first_name"`
-	LastName  string             `bson:"last_name" json:"last_name"`
-	Age       int8               `bson:"age" json:"age"`
-	Title     string             `bson:"title" json:"title"`
-	Bio       string             `bson:"bio" json:"bio"`
-	Version   int16              `bson:"version" json:"version"`
-	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+	ID                    primitive.ObjectID `bson:"_id" json:"id"`
+	Username              string             `bson:"username" json:"username"`
+	Email                 string             `bson:"email" json:"email"`
+	EmailVerified         bool               `bson:"email_verified" json:"email_verified"`
+	PendingEmail          *string            `bson:"pending_email,omitempty" json:"pending_email,omitempty"` // new address waiting to be confirmed
+	Role                  string             `bson:"role" json:"role"`
+	Disabled              bool               `bson:"disabled" json:"disabled"`
+	DeletionScheduledFor  *time.Time         `bson:"deletion_scheduled_for,omitempty" json:"deletion_scheduled_for,omitempty"` // account is purged after this time
+	PasswordResetRequired bool               `bson:"password_reset_required,omitempty" json:"password_reset_required,omitempty"`
+	Password              []byte             `bson:"password_hash" json:"-"`
+	PasswordChangedAt     *time.Time         `bson:"password_changed_at,omitempty" json:"-"`
+	FailedLoginAttempts   int                `bson:"failed_login_attempts,omitempty" json:"-"`
+	LockedUntil           *time.Time         `bson:"locked_until,omitempty" json:"-"`
+	TOTPEnabled           bool               `bson:"totp_enabled" json:"totp_enabled"`
+	TOTPSecret            string             `bson:"totp_secret,omitempty" json:"-"`
+	TOTPLastUsedStep      int64              `bson:"totp_last_used_step,omitempty" json:"-"`
+	RecoveryCodes         []string           `bson:"recovery_codes,omitempty" json:"-"` // hashed, each can be used once
+	FirstName             string             `bson:"first_name" json:"first_name"`
+	LastName              string             `bson:"last_name" json:"last_name"`
+	Age                   int8               `bson:"age" json:"age"`
+	Title                 string             `bson:"title" json:"title"`
+	Bio                   string             `bson:"bio" json:"bio"`
+	Version               int16              `bson:"version" json:"version"`
+	CreatedAt             time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt             time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+const (
+	RoleUser  = "user"
+	RoleCoach = "coach"
+	RoleAdmin = "admin"
+)
+
+var Roles = []string{RoleUser, RoleCoach, RoleAdmin}
+
+// UserFilter narrows the admin user listing
+type UserFilter struct {
+	Search   string // matched against username and email
+	Role     string
+	Disabled *bool
+	Skip     int64
+	Limit    int64
 }
 
 type Password struct {
@@ -65,6 +97,21 @@ func (u *User) SetPassword(plaintext string) error {
 	return nil
 }
 
+// HasRole reports whether the user holds one of the given roles, accounts created
+// before roles existed count as regular users
+func (u *User) HasRole(roles ...string) bool {
+	role := u.Role
+	if role == "" {
+		role = RoleUser
+	}
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
 func (u *User) CheckPassword(plaintext string) (bool, error) {
 	p := Password{hash: u.Password}
 	return p.Matches(plaintext)
@@ -125,6 +172,10 @@ func (s *UserStore) Create(ctx context.Context, user *User) error {
 		user.Version = 1
 	}
 
+	if user.Role == "" {
+		user.Role = RoleUser
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
@@ -164,9 +215,6 @@ func (s *UserStore) Update(ctx context.Context, userID primitive.ObjectID, updat
 	if username, ok := updates["username"]; ok {
 		updateFields["username"] = username
 	}
-	if email, ok := updates["email"]; ok {
-		updateFields["email"] = email
-	}
 	if firstName, ok := updates["first_name"]; ok {
 		updateFields["first_name"] = firstName
 	}
@@ -200,17 +248,414 @@ func (s *UserStore) Update(ctx context.Context, userID primitive.ObjectID, updat
 	return nil
 }
 
-// DELETING user
-func (s *UserStore) Delete(ctx context.Context, userID primitive.ObjectID) error {
+// holding a new email address until it has been confirmed
+func (s *UserStore) SetPendingEmail(ctx context.Context, userID primitive.ObjectID, email string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": userID}
+	update := bson.M{
+		"$set": bson.M{"pending_email": email, "updated_at": time.Now()},
+		"$inc": bson.M{"version": 1},
+	}
+
+	result, err := s.db.Collection(userCollection).UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to set pending email: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// dropping a pending address the user no longer wants to change to
+func (s *UserStore) ClearPendingEmail(ctx context.Context, userID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": userID}
+	update := bson.M{
+		"$set":   bson.M{"updated_at": time.Now()},
+		"$unset": bson.M{"pending_email": ""},
+		"$inc":   bson.M{"version": 1},
+	}
+
+	result, err := s.db.Collection(userCollection).UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to clear pending email: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// VerifyEmail marks an address as verified. If it is the user's pending address it
+// replaces the current email, otherwise it must be the current email already.
+func (s *UserStore) VerifyEmail(ctx context.Context, userID primitive.ObjectID, email string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"_id": userID,
+		"$or": bson.A{
+			bson.M{"email": email},
+			bson.M{"pending_email": email},
+		},
+	}
+	update := bson.M{
+		"$set":   bson.M{"email": email, "email_verified": true, "updated_at": time.Now()},
+		"$unset": bson.M{"pending_email": ""},
+		"$inc":   bson.M{"version": 1},
+	}
+
+	result, err := s.db.Collection(userCollection).UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to verify email: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// UPDATING user password, expects an already hashed password. Returns the time of the
+// change as stored, tokens issued up to then are no longer valid.
+func (s *UserStore) UpdatePassword(ctx context.Context, userID primitive.ObjectID, hash []byte) (time.Time, error) {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
+	// MongoDB keeps milliseconds
+	changedAt := time.Now().Truncate(time.Millisecond)
+
 	filter := bson.M{"_id": userID}
+	update := bson.M{
+		"$set":   bson.M{"password_hash": hash, "password_changed_at": changedAt, "updated_at": time.Now()},
+		"$unset": bson.M{"password_reset_required": ""},
+		"$inc":   bson.M{"version": 1},
+	}
 
-	_, err := s.db.Collection(userCollection).DeleteOne(ctx, filter)
+	result, err := s.db.Collection(userCollection).UpdateOne(ctx, filter, update)
 	if err != nil {
-		return fmt.Errorf("failed to delete user: %w", err)
+		return time.Time{}, fmt.Errorf("failed to update password: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return time.Time{}, ErrNotFound
+	}
+
+	return changedAt, nil
+}
+
+// storing a new TOTP secret, 2FA stays off until the secret is confirmed
+func (s *UserStore) SetTOTPSecret(ctx context.Context, userID primitive.ObjectID, secret string) error {
+	return s.updateTwoFactor(ctx, bson.M{"_id": userID, "totp_enabled": bson.M{"$ne": true}}, bson.M{
+		"$set":   bson.M{"totp_secret": secret, "totp_enabled": false},
+		"$unset": bson.M{"recovery_codes": "", "totp_last_used_step": ""},
+	})
+}
+
+// turning on 2FA with a confirmed secret and its hashed recovery codes
+func (s *UserStore) EnableTOTP(ctx context.Context, userID primitive.ObjectID, step int64, recoveryCodes []string) error {
+	return s.updateTwoFactor(ctx, bson.M{"_id": userID, "totp_secret": bson.M{"$exists": true}}, bson.M{
+		"$set": bson.M{"totp_enabled": true, "totp_last_used_step": step, "recovery_codes": recoveryCodes},
+	})
+}
+
+// turning off 2FA and forgetting the secret and recovery codes
+func (s *UserStore) DisableTOTP(ctx context.Context, userID primitive.ObjectID) error {
+	return s.updateTwoFactor(ctx, bson.M{"_id": userID}, bson.M{
+		"$set":   bson.M{"totp_enabled": false},
+		"$unset": bson.M{"totp_secret": "", "totp_last_used_step": "", "recovery_codes": ""},
+	})
+}
+
+// UseTOTPStep records a TOTP time step as used. It fails with ErrNotFound if the
+// step is not newer than the last one, so a code cannot be replayed.
+func (s *UserStore) UseTOTPStep(ctx context.Context, userID primitive.ObjectID, step int64) error {
+	return s.updateTwoFactor(ctx, bson.M{
+		"_id": userID,
+		"$or": bson.A{
+			bson.M{"totp_last_used_step": bson.M{"$exists": false}},
+			bson.M{"totp_last_used_step": bson.M{"$lt": step}},
+		},
+	}, bson.M{"$set": bson.M{"totp_last_used_step": step}})
+}
+
+// UseRecoveryCode removes a hashed recovery code, failing with ErrNotFound if the user does not have it
+func (s *UserStore) UseRecoveryCode(ctx context.Context, userID primitive.ObjectID, hash string) error {
+	return s.updateTwoFactor(ctx, bson.M{"_id": userID, "recovery_codes": hash}, bson.M{
+		"$pull": bson.M{"recovery_codes": hash},
+	})
+}
+
+func (s *UserStore) updateTwoFactor(ctx context.Context, filter, update bson.M) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := s.db.Collection(userCollection).UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to update two-factor settings: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrNotFound
 	}
 
 	return nil
 }
+
+// IncrementFailedLogins counts a failed credential check and returns the new total
+func (s *UserStore) IncrementFailedLogins(ctx context.Context, userID primitive.ObjectID) (int, error) {
+	user := &User{}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	update := bson.M{"$inc": bson.M{"failed_login_attempts": 1}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	err := s.db.Collection(userCollection).FindOneAndUpdate(ctx, bson.M{"_id": userID}, update, opts).Decode(user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return 0, ErrNotFound
+		}
+		return 0, fmt.Errorf("failed to record failed login: %w", err)
+	}
+
+	return user.FailedLoginAttempts, nil
+}
+
+// locking the account until the given time
+func (s *UserStore) LockUntil(ctx context.Context, userID primitive.ObjectID, until time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := s.db.Collection(userCollection).UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$set": bson.M{"locked_until": until}})
+	if err != nil {
+		return fmt.Errorf("failed to lock user: %w", err)
+	}
+
+	return nil
+}
+
+// clearing failed attempts and any lock after a successful login
+func (s *UserStore) ResetFailedLogins(ctx context.Context, userID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	update := bson.M{"$unset": bson.M{"failed_login_attempts": "", "locked_until": ""}}
+
+	_, err := s.db.Collection(userCollection).UpdateOne(ctx, bson.M{"_id": userID}, update)
+	if err != nil {
+		return fmt.Errorf("failed to reset failed logins: %w", err)
+	}
+
+	return nil
+}
+
+// listing users for the admin API, newest first, with the total number of matches
+func (s *UserStore) List(ctx context.Context, userFilter UserFilter) ([]*User, int64, error) {
+	var users []*User
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if userFilter.Search != "" {
+		pattern := primitive.Regex{Pattern: regexp.QuoteMeta(userFilter.Search), Options: "i"}
+		filter["$or"] = bson.A{
+			bson.M{"username": pattern},
+			bson.M{"email": pattern},
+		}
+	}
+	if userFilter.Role == RoleUser {
+		// accounts created before roles existed have no role field
+		filter["role"] = bson.M{"$in": bson.A{RoleUser, nil}}
+	} else if userFilter.Role != "" {
+		filter["role"] = userFilter.Role
+	}
+	if userFilter.Disabled != nil {
+		if *userFilter.Disabled {
+			filter["disabled"] = true
+		} else {
+			filter["disabled"] = bson.M{"$ne": true}
+		}
+	}
+
+	total, err := s.db.Collection(userCollection).CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(userFilter.Skip).
+		SetLimit(userFilter.Limit)
+
+	cursor, err := s.db.Collection(userCollection).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch users: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode users: %w", err)
+	}
+
+	return users, total, nil
+}
+
+// counting every user in the system
+func (s *UserStore) Count(ctx context.Context) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	count, err := s.db.Collection(userCollection).CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	return count, nil
+}
+
+// changing a user's role
+func (s *UserStore) SetRole(ctx context.Context, userID primitive.ObjectID, role string) error {
+	return s.updateAccount(ctx, userID, bson.M{"$set": bson.M{"role": role, "updated_at": time.Now()}})
+}
+
+// disabling or re-enabling a user's account
+func (s *UserStore) SetDisabled(ctx context.Context, userID primitive.ObjectID, disabled bool) error {
+	return s.updateAccount(ctx, userID, bson.M{"$set": bson.M{"disabled": disabled, "updated_at": time.Now()}})
+}
+
+// requiring a password reset, tokens issued before now stop working
+func (s *UserStore) ForcePasswordReset(ctx context.Context, userID primitive.ObjectID) error {
+	return s.updateAccount(ctx, userID, bson.M{"$set": bson.M{
+		"password_reset_required": true,
+		"password_changed_at":     time.Now(),
+		"updated_at":              time.Now(),
+	}})
+}
+
+func (s *UserStore) updateAccount(ctx context.Context, userID primitive.ObjectID, update bson.M) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := s.db.Collection(userCollection).UpdateOne(ctx, bson.M{"_id": userID}, update)
+	if err != nil {
+		return fmt.Errorf("failed to update user account: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// scheduling the account to be purged once the grace period is over
+func (s *UserStore) ScheduleDeletion(ctx context.Context, userID primitive.ObjectID, at time.Time) error {
+	return s.updateAccount(ctx, userID, bson.M{"$set": bson.M{"deletion_scheduled_for": at, "updated_at": time.Now()}})
+}
+
+// cancelling a scheduled deletion
+func (s *UserStore) CancelDeletion(ctx context.Context, userID primitive.ObjectID) error {
+	return s.updateAccount(ctx, userID, bson.M{
+		"$unset": bson.M{"deletion_scheduled_for": ""},
+		"$set":   bson.M{"updated_at": time.Now()},
+	})
+}
+
+// listing accounts whose grace period ended before the given time
+func (s *UserStore) GetDueForDeletion(ctx context.Context, before time.Time, limit int64) ([]primitive.ObjectID, error) {
+	var users []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"deletion_scheduled_for": bson.M{"$lte": before}}
+	opts := options.Find().
+		SetProjection(bson.M{"_id": 1}).
+		SetSort(bson.D{{Key: "deletion_scheduled_for", Value: 1}}).
+		SetLimit(limit)
+
+	cursor, err := s.db.Collection(userCollection).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch users due for deletion: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, fmt.Errorf("failed to decode users due for deletion: %w", err)
+	}
+
+	userIDs := make([]primitive.ObjectID, 0, len(users))
+	for _, user := range users {
+		userIDs = append(userIDs, user.ID)
+	}
+
+	return userIDs, nil
+}
+
+// collections holding documents owned by a user through their user_id, removed with the account.
+// The audit and security logs are kept on purpose.
+var userOwnedCollections = []string{
+	exerciseCollection,
+	routineCollection,
+	workoutCollection,
+	refreshTokenCollection,
+	userTokenCollection,
+	apiKeyCollection,
+	jobCollection,
+	mediaCollection,
+	mergeCollection,
+	revisionCollection,
+}
+
+// ErrNotDueForDeletion is returned when purging an account whose deletion was cancelled
+var ErrNotDueForDeletion = errors.New("account is not due for deletion")
+
+// DELETING user and everything they own, in a single transaction. The account must
+// still be scheduled for deletion by dueBy, a deletion cancelled since the account
+// was picked up leaves everything in place.
+func (s *UserStore) Delete(ctx context.Context, userID primitive.ObjectID, dueBy time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	session, err := s.db.Client().StartSession()
+	if err != nil {
+		return fmt.Errorf("failed to start session: %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		filter := bson.M{"_id": userID, "deletion_scheduled_for": bson.M{"$lte": dueBy}}
+		result, err := s.db.Collection(userCollection).DeleteOne(sessCtx, filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete user: %w", err)
+		}
+		if result.DeletedCount == 0 {
+			return nil, ErrNotDueForDeletion
+		}
+
+		for _, collection := range userOwnedCollections {
+			if _, err := s.db.Collection(collection).DeleteMany(sessCtx, bson.M{"user_id": userID}); err != nil {
+				return nil, fmt.Errorf("failed to delete %s documents: %w", collection, err)
+			}
+		}
+
+		return nil, nil
+	})
+
+	return err
+}
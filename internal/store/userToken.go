@@ -0,0 +1,98 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	TokenPurposePasswordReset     = "password_reset"
+	TokenPurposeEmailVerification = "email_verification"
+	TokenPurposeMFA               = "mfa" // the mfa_token between the password and 2FA login steps
+)
+
+// UserToken is a hashed, single-use, time-limited token mailed to a user or handed
+// out between login steps
+type UserToken struct {
+	ID        primitive.ObjectID `bson:"_id" json:"id"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
+	TokenHash string             `bson:"token_hash" json:"-"`
+	Purpose   string             `bson:"purpose" json:"purpose"`
+	Email     string             `bson:"email,omitempty" json:"email,omitempty"` // address being verified
+	ExpiresAt time.Time          `bson:"expires_at" json:"expires_at"`
+	UsedAt    *time.Time         `bson:"used_at,omitempty" json:"used_at,omitempty"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+type UserTokenStore struct {
+	db *mongo.Database
+}
+
+const userTokenCollection = "user_token"
+
+// creating a token
+func (s *UserTokenStore) Create(ctx context.Context, token *UserToken) error {
+	token.ID = primitive.NewObjectID()
+	token.CreatedAt = time.Now()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := s.db.Collection(userTokenCollection).InsertOne(ctx, token)
+	if err != nil {
+		return fmt.Errorf("failed to insert user token: %w", err)
+	}
+
+	return nil
+}
+
+// Consume marks an unused, unexpired token as used and returns it.
+// The lookup and update happen in one operation so a token can only ever be consumed once.
+func (s *UserTokenStore) Consume(ctx context.Context, hash, purpose string) (*UserToken, error) {
+	token := &UserToken{}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+
+	filter := bson.M{
+		"token_hash": hash,
+		"purpose":    purpose,
+		"used_at":    bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": now},
+	}
+	update := bson.M{"$set": bson.M{"used_at": now}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	err := s.db.Collection(userTokenCollection).FindOneAndUpdate(ctx, filter, update, opts).Decode(token)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to consume user token: %w", err)
+	}
+
+	return token, nil
+}
+
+// delete every outstanding token of a purpose for the user
+func (s *UserTokenStore) DeleteAllForUser(ctx context.Context, userID primitive.ObjectID, purpose string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"user_id": userID, "purpose": purpose}
+
+	_, err := s.db.Collection(userTokenCollection).DeleteMany(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to delete user tokens: %w", err)
+	}
+
+	return nil
+}
@@ -28,15 +28,29 @@ type WorkoutSession struct {
 	UpdatedAt   time.Time              `bson:"updated_at" json:"updated_at"`
 }
 
+const (
+	WorkoutStatusInProgress = "in_progress"
+	WorkoutStatusCompleted  = "completed"
+)
+
+// WorkoutFilter narrows the workout history, zero values are ignored
+type WorkoutFilter struct {
+	From       *time.Time // started at or after
+	To         *time.Time // started before
+	RoutineID  *primitive.ObjectID
+	ExerciseID *primitive.ObjectID // contains this exercise
+	Status     string
+}
+
 type SessionExercise struct {
+	EntryID       primitive.ObjectID `bson:"entry_id" json:"entry_id"` // set by the store, unlike order it never repeats or changes
 	ExerciseID    primitive.ObjectID `bson:"exercise_id" json:"exercise_id"`
 	Order         int                `bson:"order" json:"order"` // Position in the workout
 	CompletedSets []SessionSet       `bson:"completed_sets" json:"completed_sets"`
 }
 
 type SessionSet struct {
-	Weight      float32   `bson:"weight" json:"weight"`
-	Reps        int16     `bson:"reps" json:"reps"`
+	SetMetrics  `bson:",inline"`
 	SetNumber   int16     `bson:"set_number" json:"set_number"`
 	CompletedAt time.Time `bson:"completed_at" json:"completed_at"`
 }
@@ -55,12 +69,16 @@ func (s *WorkoutSessionStore) Create(ctx context.Context, session *WorkoutSessio
 	session.CreatedAt = time.Now()
 	session.UpdatedAt = time.Now()
 	session.StartTime = time.Now()
-	session.Status = "in_progress"
+	session.Status = WorkoutStatusInProgress
 
 	if session.Version == 0 {
 		session.Version = 1
 	}
 
+	for i := range session.Exercises {
+		session.Exercises[i].EntryID = primitive.NewObjectID()
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
@@ -90,7 +108,7 @@ func (s *WorkoutSessionStore) CreateFromRoutine(ctx context.Context, routineID,
 		Title:       routine.Title,
 		Description: routine.Description,
 		StartTime:   time.Now(),
-		Status:      "in_progress",
+		Status:      WorkoutStatusInProgress,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 		Exercises:   []SessionExercise{},
@@ -98,6 +116,7 @@ func (s *WorkoutSessionStore) CreateFromRoutine(ctx context.Context, routineID,
 
 	for i, routineExercise := range routine.Exercises {
 		sessionExercise := SessionExercise{
+			EntryID:       primitive.NewObjectID(),
 			ExerciseID:    routineExercise.ExerciseID,
 			Order:         i,
 			CompletedSets: []SessionSet{},
@@ -137,6 +156,42 @@ func (s *WorkoutSessionStore) GetAllUserSessions(ctx context.Context, userID pri
 	return sessions, nil
 }
 
+var workoutListSpec = listSpec{
+	sortFields: map[string]string{
+		"start_time": "start_time",
+		"title":      "title",
+		"created_at": "created_at",
+		"updated_at": "updated_at",
+	},
+	defaultSort: "-start_time",
+}
+
+// one page of the user's workout sessions matching the filter
+func (s *WorkoutSessionStore) List(ctx context.Context, userID primitive.ObjectID, workoutFilter WorkoutFilter, query ListQuery) (*Page[*WorkoutSession], error) {
+	filter := bson.M{"user_id": userID}
+	if workoutFilter.From != nil || workoutFilter.To != nil {
+		startTime := bson.M{}
+		if workoutFilter.From != nil {
+			startTime["$gte"] = *workoutFilter.From
+		}
+		if workoutFilter.To != nil {
+			startTime["$lt"] = *workoutFilter.To
+		}
+		filter["start_time"] = startTime
+	}
+	if workoutFilter.RoutineID != nil {
+		filter["routine_id"] = *workoutFilter.RoutineID
+	}
+	if workoutFilter.ExerciseID != nil {
+		filter["exercises.exercise_id"] = *workoutFilter.ExerciseID
+	}
+	if workoutFilter.Status != "" {
+		filter["status"] = workoutFilter.Status
+	}
+
+	return findPage[*WorkoutSession](ctx, s.db.Collection(workoutCollection), filter, query, workoutListSpec)
+}
+
 // Get a single workout for a user
 func (s *WorkoutSessionStore) GetByID(ctx context.Context, sessionID, userID primitive.ObjectID) (*WorkoutSession, error) {
 	session := &WorkoutSession{}
@@ -238,7 +293,7 @@ func (s *WorkoutSessionStore) CompleteWorkout(ctx context.Context, sessionID, us
 
 	update := bson.M{
 		"$set": bson.M{
-			"status":     "completed",
+			"status":     WorkoutStatusCompleted,
 			"end_time":   endTime,
 			"metrics":    metrics,
 			"updated_at": time.Now(),
@@ -281,3 +336,16 @@ func (s *WorkoutSessionStore) Delete(ctx context.Context, sessionID, userID prim
 
 	return nil
 }
+
+// counting all workouts in the system
+func (s *WorkoutSessionStore) Count(ctx context.Context) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	count, err := s.db.Collection(workoutCollection).CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count workouts: %w", err)
+	}
+
+	return count, nil
+}